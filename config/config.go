@@ -0,0 +1,183 @@
+// Package config loads the optional thought-process config file,
+// ~/.thought-process/config.json.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"thought-process/notifiers"
+	"thought-process/process"
+	"thought-process/secrets"
+)
+
+// Config is the contents of the config file.
+type Config struct {
+	// Secrets configures the secret providers used to resolve env values.
+	Secrets secrets.Config `json:"secrets"`
+	// Projects maps project names to root directories, registered at
+	// startup in addition to those added with register_project.
+	Projects map[string]string `json:"projects,omitempty"`
+	// Defaults are tags and env vars every process is started with unless
+	// it sets them itself.
+	Defaults process.Defaults `json:"defaults"`
+	// Tools selects which tool groups are registered.
+	Tools Tools `json:"tools"`
+	// PortRange is where allocate_ports picks ports from, if set.
+	PortRange *process.PortRange `json:"port_range,omitempty"`
+	// MemoryAlertMB is the RSS, in MiB, above which a process raises a
+	// high_memory alert; 0 turns the alerts off. Unset means
+	// process.DefaultMemoryAlert.
+	MemoryAlertMB *int64 `json:"memory_alert_mb,omitempty"`
+	// LogSpikeFactor is how many times its usual lines per minute a process
+	// must write to raise a log_spike alert; 0 turns the alerts off. Unset
+	// means process.DefaultLogSpikeFactor.
+	LogSpikeFactor *int `json:"log_spike_factor,omitempty"`
+	// StorageQuotaMB caps, in MiB, the total size of the log and data
+	// directories; exited processes' logs, then records, are deleted
+	// oldest first to stay under it. Unset means no cap.
+	StorageQuotaMB *int64 `json:"storage_quota_mb,omitempty"`
+	// Retention bounds how long and how many exited processes are kept,
+	// with their logs. Unset means process.DefaultRetention.
+	Retention *process.Retention `json:"retention,omitempty"`
+	// ToolCallRetentionDays is how long the access log of tool calls is
+	// kept. Unset means process.DefaultToolCallRetention.
+	ToolCallRetentionDays *int `json:"tool_call_retention_days,omitempty"`
+	// Webhooks are URLs lifecycle events are POSTed to.
+	Webhooks []process.Webhook `json:"webhooks,omitempty"`
+	// Notifiers are the Slack and Discord webhooks told of crashes.
+	Notifiers notifiers.Config `json:"notifiers"`
+	// DesktopNotifications shows a native desktop notification when a
+	// process crashes, starts crash looping or times out.
+	DesktopNotifications bool `json:"desktop_notifications,omitempty"`
+	// ShutdownPolicy is what happens to running processes when the server
+	// exits. Unset means process.ShutdownStop.
+	ShutdownPolicy process.ShutdownPolicy `json:"shutdown_policy,omitempty"`
+}
+
+// Tools lists tool groups to enable (optional groups, or "all") and to
+// disable, in addition to any given with the -enable-tools and
+// -disable-tools flags.
+type Tools struct {
+	Enable  []string `json:"enable,omitempty"`
+	Disable []string `json:"disable,omitempty"`
+}
+
+// Load reads the config file at path. A missing file yields an empty Config.
+func Load(path string) (*Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Apply configures mgr with the reloadable settings, resetting unset ones
+// to their defaults. Projects are registered again, but ones removed from
+// the config stay registered.
+func (c *Config) Apply(mgr *process.Manager) error {
+	mgr.SetSecretResolver(secrets.NewResolver(c.Secrets))
+	portRange := process.DefaultPortRange
+	if c.PortRange != nil {
+		portRange = *c.PortRange
+	}
+	if err := mgr.SetPortRange(portRange); err != nil {
+		return err
+	}
+	memoryAlert := int64(process.DefaultMemoryAlert)
+	if c.MemoryAlertMB != nil {
+		memoryAlert = *c.MemoryAlertMB << 20
+	}
+	if err := mgr.SetMemoryAlert(memoryAlert); err != nil {
+		return err
+	}
+	logSpikeFactor := process.DefaultLogSpikeFactor
+	if c.LogSpikeFactor != nil {
+		logSpikeFactor = *c.LogSpikeFactor
+	}
+	if err := mgr.SetLogSpikeFactor(logSpikeFactor); err != nil {
+		return err
+	}
+	if err := mgr.SetDefaults(c.Defaults); err != nil {
+		return err
+	}
+	retention := process.DefaultRetention
+	if c.Retention != nil {
+		retention = *c.Retention
+	}
+	if err := mgr.SetRetention(retention); err != nil {
+		return err
+	}
+	if err := mgr.SetWebhooks(c.Webhooks); err != nil {
+		return err
+	}
+	mgr.SetNotifiers(notifiers.New(c.Notifiers))
+	mgr.SetDesktopNotifications(c.DesktopNotifications)
+	policy := process.ShutdownStop
+	if c.ShutdownPolicy != "" {
+		policy = c.ShutdownPolicy
+	}
+	if err := mgr.SetShutdownPolicy(policy); err != nil {
+		return err
+	}
+
+	var errs []error
+	for name, path := range c.Projects {
+		if _, err := mgr.RegisterProject(name, path); err != nil {
+			errs = append(errs, fmt.Errorf("registering project %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks the settings, so that Apply doesn't fail halfway and a
+// bad reload leaves the running settings alone.
+func (c *Config) validate() error {
+	if r := c.PortRange; r != nil && (r.Min < 1 || r.Max > 65535 || r.Min > r.Max) {
+		return fmt.Errorf("invalid port_range %d-%d", r.Min, r.Max)
+	}
+	if c.MemoryAlertMB != nil && *c.MemoryAlertMB < 0 {
+		return errors.New("memory_alert_mb must not be negative")
+	}
+	if f := c.LogSpikeFactor; f != nil && (*f < 0 || *f == 1) {
+		return errors.New("log_spike_factor must be 0 or at least 2")
+	}
+	if c.StorageQuotaMB != nil && *c.StorageQuotaMB < 1 {
+		return errors.New("storage_quota_mb must be at least 1")
+	}
+	if r := c.Retention; r != nil && (r.MaxAgeDays < 0 || r.MaxCount < 0) {
+		return errors.New("retention limits must not be negative")
+	}
+	if c.ToolCallRetentionDays != nil && *c.ToolCallRetentionDays < 1 {
+		return errors.New("tool_call_retention_days must be at least 1")
+	}
+	for _, hook := range c.Webhooks {
+		if err := hook.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.Notifiers.Validate(); err != nil {
+		return fmt.Errorf("notifiers: %w", err)
+	}
+	if err := c.Defaults.Validate(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	switch c.ShutdownPolicy {
+	case "", process.ShutdownStop, process.ShutdownKeep:
+	default:
+		return fmt.Errorf("unknown shutdown_policy %q: use %q or %q", c.ShutdownPolicy, process.ShutdownStop, process.ShutdownKeep)
+	}
+	return nil
+}
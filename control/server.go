@@ -0,0 +1,395 @@
+// Package control serves a small JSON-RPC 2.0 API over a unix socket, for
+// editor plugins and scripts that want process state without speaking MCP.
+//
+// Messages are newline-delimited JSON. Methods:
+//
+//	list              {"tags": {...}, "exited_since_secs": N} -> [ProcessView]
+//	                  {..., "group_by": "tag:KEY"} -> [ProcessGroup]
+//	logs              {"process_id": "..."} -> {"logs": "..."}
+//	kill              {"process_id": "..."} -> ProcessView
+//	restart           {"process_id": "..."} -> ProcessView
+//	start_procfile    {"cwd": "...", "file": "...", "tags": {...}} -> [StackResult]
+//	subscribe_logs    {"process_id": "..."} -> {"subscription": "..."}
+//	subscribe_events  {} -> {"subscription": "..."}
+//	unsubscribe       {"subscription": "..."} -> {}
+//	report            {"process_id": "...", "token": "...", "ready": true,
+//	                   "health": "healthy", "ports": [N]} -> ProcessView
+//
+// Subscriptions deliver "logs" notifications ({"subscription", "process_id",
+// "data"}) and "event" notifications ({"subscription", "event"}).
+//
+// report is for managed processes themselves, which find their ID, token
+// and this socket in THOUGHT_PROCESS_ID, THOUGHT_PROCESS_TOKEN and
+// THOUGHT_PROCESS_CONTROL_SOCKET.
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"thought-process/process"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	// codeFailed is returned when the manager rejects a valid request.
+	codeFailed = -32000
+)
+
+const (
+	// logTailBytes is how much existing output subscribe_logs sends first.
+	logTailBytes = 16 * 1024
+	// logPollInterval is how often subscribed logs are checked for output.
+	logPollInterval = 250 * time.Millisecond
+)
+
+// Server serves the control API for a process manager.
+type Server struct {
+	mgr process.ProcessManager
+}
+
+// NewServer returns a Server for mgr.
+func NewServer(mgr process.ProcessManager) *Server {
+	return &Server{mgr: mgr}
+}
+
+// Serve listens on the unix socket at path until ctx is done. A stale socket
+// file is replaced; one with a live listener is an error.
+func (s *Server) Serve(ctx context.Context, path string) error {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is already in use", path)
+	}
+	os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	if err := os.Chmod(path, 0o600); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(ctx, conn)
+	}
+}
+
+type request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// writeTimeout bounds each message written to a client, so one that stops
+// reading can't block the notifications and responses meant for it forever.
+const writeTimeout = 10 * time.Second
+
+// conn is one client connection and its subscriptions.
+type conn struct {
+	srv *Server
+	ctx context.Context
+	nc  net.Conn
+
+	writeMu sync.Mutex
+	enc     *json.Encoder
+
+	mu     sync.Mutex
+	nextID int
+	subs   map[string]context.CancelFunc
+}
+
+func (s *Server) serveConn(ctx context.Context, nc net.Conn) {
+	defer nc.Close()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // ends all subscriptions
+
+	c := &conn{srv: s, ctx: ctx, nc: nc, enc: json.NewEncoder(nc), subs: make(map[string]context.CancelFunc)}
+	scanner := bufio.NewScanner(nc)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			c.write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}})
+			continue
+		}
+		result, rerr := c.call(req)
+		if req.ID == nil {
+			continue // a notification from the client; nothing to answer
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr}
+		if rerr == nil && result == nil {
+			resp.Result = struct{}{}
+		}
+		c.write(resp)
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Printf("control connection: %v", err)
+	}
+}
+
+func (c *conn) write(v any) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.nc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := c.enc.Encode(v); err != nil {
+		// The stream may now hold half a message, so drop the client; closing
+		// ends serveConn's read loop and with it the subscriptions.
+		if !errors.Is(err, net.ErrClosed) {
+			log.Printf("control connection: %v", err)
+		}
+		c.nc.Close()
+	}
+}
+
+func (c *conn) notify(method string, params any) {
+	c.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+type processParams struct {
+	ProcessID string `json:"process_id"`
+}
+
+type listParams struct {
+	Tags            map[string]string `json:"tags"`
+	ExitedSinceSecs int               `json:"exited_since_secs"`
+	GroupBy         string            `json:"group_by"`
+}
+
+type procfileParams struct {
+	Cwd  string            `json:"cwd"`
+	File string            `json:"file"`
+	Tags map[string]string `json:"tags"`
+}
+
+type reportParams struct {
+	ProcessID string `json:"process_id"`
+	Token     string `json:"token"`
+	process.Report
+}
+
+type subscriptionParams struct {
+	Subscription string `json:"subscription"`
+}
+
+func (c *conn) call(req request) (any, *rpcError) {
+	mgr := c.srv.mgr
+	switch req.Method {
+	case "list":
+		var p listParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		views, err := mgr.List(process.ListFilter{Tags: p.Tags, ExitedSinceSecs: p.ExitedSinceSecs})
+		if err != nil || p.GroupBy == "" {
+			return failed(views, err)
+		}
+		tag, err := process.ParseGroupBy(p.GroupBy)
+		if err != nil {
+			return nil, &rpcError{codeInvalidParams, err.Error()}
+		}
+		return process.GroupByTag(views, tag), nil
+	case "logs":
+		id, err := processID(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		logs, lerr := mgr.GetLogs(id)
+		if lerr != nil {
+			return nil, &rpcError{codeFailed, lerr.Error()}
+		}
+		return map[string]string{"logs": logs}, nil
+	case "kill":
+		id, err := processID(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		return failed(mgr.Kill(id))
+	case "restart":
+		id, err := processID(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		return failed(mgr.Restart(id))
+	case "start_procfile":
+		var p procfileParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		return failed(mgr.StartProcfile(process.ProcfileOptions{Cwd: p.Cwd, File: p.File, Tags: p.Tags}))
+	case "subscribe_logs":
+		id, err := processID(req.Params)
+		if err != nil {
+			return nil, err
+		}
+		path, perr := mgr.GetLogPath(id)
+		if perr != nil {
+			return nil, &rpcError{codeFailed, perr.Error()}
+		}
+		sub, ctx := c.subscribe()
+		go c.tailLogs(ctx, sub, id, path)
+		return map[string]string{"subscription": sub}, nil
+	case "subscribe_events":
+		sub, ctx := c.subscribe()
+		go c.forwardEvents(ctx, sub)
+		return map[string]string{"subscription": sub}, nil
+	case "report":
+		var p reportParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		if p.ProcessID == "" {
+			return nil, &rpcError{codeInvalidParams, "process_id is required"}
+		}
+		return failed(mgr.Report(p.ProcessID, p.Token, p.Report))
+	case "unsubscribe":
+		var p subscriptionParams
+		if err := decodeParams(req.Params, &p); err != nil {
+			return nil, err
+		}
+		c.mu.Lock()
+		cancel, ok := c.subs[p.Subscription]
+		delete(c.subs, p.Subscription)
+		c.mu.Unlock()
+		if !ok {
+			return nil, &rpcError{codeInvalidParams, "unknown subscription"}
+		}
+		cancel()
+		return nil, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, "unknown method " + strconv.Quote(req.Method)}
+}
+
+func decodeParams(raw json.RawMessage, v any) *rpcError {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{codeInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+func processID(raw json.RawMessage) (string, *rpcError) {
+	var p processParams
+	if err := decodeParams(raw, &p); err != nil {
+		return "", err
+	}
+	if p.ProcessID == "" {
+		return "", &rpcError{codeInvalidParams, "process_id is required"}
+	}
+	return p.ProcessID, nil
+}
+
+// failed converts a manager result into a call result.
+func failed[T any](v T, err error) (any, *rpcError) {
+	if err != nil {
+		return nil, &rpcError{codeFailed, err.Error()}
+	}
+	return v, nil
+}
+
+// subscribe registers a new subscription, returning its ID and a context
+// that is canceled by unsubscribe or when the connection closes.
+func (c *conn) subscribe() (string, context.Context) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	c.subs[id] = cancel
+	return id, ctx
+}
+
+// tailLogs sends the end of the log at path and then everything written to
+// it until ctx is done.
+func (c *conn) tailLogs(ctx context.Context, sub, processID, path string) {
+	tail, err := process.OpenLogTail(path, logTailBytes)
+	if err != nil {
+		return
+	}
+	defer tail.Close()
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+	buf := make([]byte, 32*1024)
+	for {
+		for {
+			n, err := tail.Read(buf)
+			if n > 0 {
+				c.notify("logs", map[string]string{
+					"subscription": sub,
+					"process_id":   processID,
+					"data":         string(buf[:n]),
+				})
+			}
+			if err != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// forwardEvents sends lifecycle events until ctx is done.
+func (c *conn) forwardEvents(ctx context.Context, sub string) {
+	events, cancel := c.srv.mgr.Subscribe()
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			c.notify("event", map[string]any{"subscription": sub, "event": e})
+		}
+	}
+}
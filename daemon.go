@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// defaultDaemonDashboard is the dashboard address of an installed daemon.
+const defaultDaemonDashboard = "127.0.0.1:7420"
+
+const (
+	launchdLabel = "com.thought-process.daemon"
+	systemdUnit  = "thought-process.service"
+)
+
+// daemonSocket is the unix socket the daemon serves MCP sessions on.
+func daemonSocket(baseDir string) string {
+	return filepath.Join(baseDir, "daemon.sock")
+}
+
+// runDaemonCommand implements "daemon install|uninstall|start|stop". "daemon
+// run" is handled by main, since it needs the full server setup.
+func runDaemonCommand(baseDir string, args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: thought-process daemon install|uninstall|start|stop|reload|run")
+	}
+	svc, err := newService(baseDir)
+	if err != nil {
+		log.Fatalf("daemon: %v", err)
+	}
+
+	switch args[0] {
+	case "install":
+		installFlags := flag.NewFlagSet("daemon install", flag.ExitOnError)
+		dashboardAddr := installFlags.String("dashboard", defaultDaemonDashboard, "address for the daemon's dashboard")
+		installFlags.Parse(args[1:])
+		err = svc.install(*dashboardAddr)
+		if err == nil {
+			fmt.Printf("installed %s\nstart it with: thought-process daemon start\n", svc.path)
+		}
+	case "uninstall":
+		run(svc.disableCmd) // not running is fine
+		err = os.Remove(svc.path)
+	case "start":
+		err = svc.start()
+	case "stop":
+		err = svc.stop()
+	case "reload":
+		err = run(svc.reloadCmd)
+	default:
+		err = fmt.Errorf("unknown command %q", args[0])
+	}
+	if err != nil {
+		log.Fatalf("daemon %s: %v", args[0], err)
+	}
+}
+
+// service is the daemon's launchd agent or systemd user unit.
+type service struct {
+	baseDir string
+	// path is the plist or unit file.
+	path string
+	// Commands for the service manager. disableCmd also stops the daemon
+	// from starting at login; reloadCmd sends it SIGHUP.
+	startCmd, stopCmd, disableCmd, reloadCmd [][]string
+	tmpl                                     *template.Template
+}
+
+func newService(baseDir string) (*service, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		path := filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+		return &service{
+			baseDir:    baseDir,
+			path:       path,
+			startCmd:   [][]string{{"launchctl", "load", "-w", path}},
+			stopCmd:    [][]string{{"launchctl", "unload", path}},
+			disableCmd: [][]string{{"launchctl", "unload", "-w", path}},
+			reloadCmd:  [][]string{{"launchctl", "kill", "SIGHUP", fmt.Sprintf("gui/%d/%s", os.Getuid(), launchdLabel)}},
+			tmpl:       launchdTemplate,
+		}, nil
+	case "linux":
+		return &service{
+			baseDir: baseDir,
+			path:    filepath.Join(home, ".config", "systemd", "user", systemdUnit),
+			startCmd: [][]string{
+				{"systemctl", "--user", "daemon-reload"},
+				{"systemctl", "--user", "enable", "--now", systemdUnit},
+			},
+			stopCmd:    [][]string{{"systemctl", "--user", "stop", systemdUnit}},
+			disableCmd: [][]string{{"systemctl", "--user", "disable", "--now", systemdUnit}},
+			reloadCmd:  [][]string{{"systemctl", "--user", "kill", "--kill-whom=main", "--signal", "HUP", systemdUnit}},
+			tmpl:       systemdTemplate,
+		}, nil
+	}
+	return nil, fmt.Errorf("no service manager support on %s", runtime.GOOS)
+}
+
+// install writes the service file. The daemon gets the installing shell's
+// PATH and SHELL, since service managers start it with a minimal
+// environment and processes it launches should see the user's tools.
+func (s *service) install(dashboardAddr string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	// The log lives in baseDir, which must exist before the daemon starts.
+	for _, dir := range []string{filepath.Dir(s.path), s.baseDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.Create(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.tmpl.Execute(f, map[string]any{
+		"Label": launchdLabel,
+		"Args":  []string{exe, "-dashboard", dashboardAddr, "daemon", "run"},
+		"Log":   filepath.Join(s.baseDir, "daemon.log"),
+		"Env": map[string]string{
+			"PATH":  os.Getenv("PATH"),
+			"SHELL": os.Getenv("SHELL"),
+		},
+	})
+}
+
+func (s *service) start() error {
+	if _, err := os.Stat(s.path); err != nil {
+		return fmt.Errorf("%w (run 'thought-process daemon install' first)", err)
+	}
+	return run(s.startCmd)
+}
+
+func (s *service) stop() error {
+	return run(s.stopCmd)
+}
+
+func run(cmds [][]string) error {
+	for _, c := range cmds {
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(c, " "), err)
+		}
+	}
+	return nil
+}
+
+var launchdTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"q": func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	},
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{q .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{q .}}</string>
+{{- end}}
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+{{- range $k, $v := .Env}}
+		<key>{{q $k}}</key>
+		<string>{{q $v}}</string>
+{{- end}}
+	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>{{q .Log}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{q .Log}}</string>
+</dict>
+</plist>
+`))
+
+// KillMode=mixed sends SIGTERM only to the daemon, which stops its processes
+// gracefully, and SIGKILLs whatever is left in the cgroup afterwards.
+var systemdTemplate = template.Must(template.New("unit").Funcs(template.FuncMap{
+	// q escapes s for use inside a double-quoted unit file value.
+	"q": strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace,
+}).Parse(`[Unit]
+Description=thought-process daemon
+
+[Service]
+ExecStart={{range $i, $a := .Args}}{{if $i}} {{end}}"{{q $a}}"{{end}}
+{{- range $k, $v := .Env}}
+Environment="{{q $k}}={{q $v}}"
+{{- end}}
+Restart=on-failure
+KillMode=mixed
+TimeoutStopSec=15
+StandardOutput=append:{{q .Log}}
+StandardError=append:{{q .Log}}
+
+[Install]
+WantedBy=default.target
+`))
+
+// serveDaemon serves an MCP session on the unix socket at path for every
+// client that connects, until ctx is done.
+func serveDaemon(ctx context.Context, server *mcp.Server, path string) error {
+	// A socket left behind by a daemon that died is removed; a live one
+	// means another daemon is already running.
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	// Only the owner may drive the daemon.
+	if err := os.Chmod(path, 0o600); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	log.Printf("Daemon listening on %s", path)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if _, err := server.Connect(ctx, &mcp.IOTransport{Reader: conn, Writer: conn}, nil); err != nil {
+			log.Printf("daemon session: %v", err)
+			conn.Close()
+		}
+	}
+}
+
+// proxyToDaemon relays stdin/stdout to a running daemon, making this process
+// a thin stdio front end for it. It reports false if no daemon is listening.
+func proxyToDaemon(path string) (bool, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return false, nil
+	}
+	defer conn.Close()
+
+	go func() {
+		io.Copy(conn, os.Stdin)
+		// The client is gone; let the daemon end the session.
+		conn.(*net.UnixConn).CloseWrite()
+	}()
+	if _, err := io.Copy(os.Stdout, conn); err != nil && !errors.Is(err, net.ErrClosed) {
+		return true, err
+	}
+	return true, nil
+}
@@ -0,0 +1,176 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"thought-process/process"
+)
+
+const (
+	// defaultProbeSamples is how many requests each side of a comparison
+	// gets unless the request sets samples.
+	defaultProbeSamples = 5
+	maxProbeSamples     = 50
+	// maxCompareErrors caps the error fingerprints listed per side.
+	maxCompareErrors = 5
+)
+
+var probeClient = &http.Client{
+	Timeout: 5 * time.Second,
+	// Time the endpoint itself, not whatever it redirects to.
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// comparison is the response of GET /api/compare.
+type comparison struct {
+	Path  string         `json:"path"`
+	Sides [2]compareSide `json:"sides"`
+}
+
+// compareSide is one process of a comparison.
+type compareSide struct {
+	Process process.ProcessView        `json:"process"`
+	Errors  []process.ErrorFingerprint `json:"errors"`
+	Probe   *probeResult               `json:"probe,omitempty"`
+}
+
+// probeResult summarizes timed GET requests to a process's HTTP port.
+type probeResult struct {
+	URL string `json:"url"`
+	// Status is the HTTP status of the last response.
+	Status   int     `json:"status,omitempty"`
+	Samples  int     `json:"samples"`
+	Failures int     `json:"failures,omitempty"`
+	MinMS    float64 `json:"min_ms,omitempty"`
+	MedianMS float64 `json:"median_ms,omitempty"`
+	MaxMS    float64 `json:"max_ms,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// handleCompare pairs the processes a and b (IDs or names), e.g. the same
+// role on two branches, and returns their views, most recent errors and
+// response times for GET path on their first port, probed the same way at
+// the same time.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	path := q.Get("path")
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		http.Error(w, "path must start with /", http.StatusBadRequest)
+		return
+	}
+	samples := defaultProbeSamples
+	if n, err := strconv.Atoi(q.Get("samples")); err == nil && n > 0 {
+		samples = min(n, maxProbeSamples)
+	}
+
+	views, err := s.mgr.List(process.ListFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := comparison{Path: path}
+	for i, ref := range []string{q.Get("a"), q.Get("b")} {
+		view, ok := findView(views, ref)
+		if !ok {
+			http.Error(w, fmt.Sprintf("process %q not found", ref), http.StatusNotFound)
+			return
+		}
+		fps, err := s.mgr.ErrorFingerprints(view.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Sides[i] = compareSide{Process: view, Errors: fps[:min(len(fps), maxCompareErrors)]}
+	}
+
+	var wg sync.WaitGroup
+	for i := range result.Sides {
+		side := &result.Sides[i]
+		if port, ok := probePort(side.Process); ok {
+			wg.Go(func() {
+				side.Probe = probe(fmt.Sprintf("http://127.0.0.1:%d%s", port, path), samples)
+			})
+		}
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// findView returns the view with ID ref, or else the running (or most
+// recently started) one named ref.
+func findView(views []process.ProcessView, ref string) (process.ProcessView, bool) {
+	if ref == "" {
+		return process.ProcessView{}, false
+	}
+	var found *process.ProcessView
+	for i, v := range views {
+		if v.ID == ref {
+			return v, true
+		}
+		if v.Name != ref {
+			continue
+		}
+		running, foundRunning := v.Status == process.StatusRunning, found != nil && found.Status == process.StatusRunning
+		if found == nil || (running && !foundRunning) || (running == foundRunning && v.StartedAt.After(found.StartedAt)) {
+			found = &views[i]
+		}
+	}
+	if found == nil {
+		return process.ProcessView{}, false
+	}
+	return *found, true
+}
+
+// probePort returns the port to probe on a running process: the first one it
+// listens on, or else the first one it declared.
+func probePort(v process.ProcessView) (int, bool) {
+	if v.Status != process.StatusRunning {
+		return 0, false
+	}
+	ports := slices.Concat(v.DetectedPorts, v.Ports)
+	if len(ports) == 0 {
+		return 0, false
+	}
+	return ports[0], true
+}
+
+// probe sends samples sequential GET requests to url and summarizes their
+// latency, including reading the body. Failed requests are counted but not
+// timed.
+func probe(url string, samples int) *probeResult {
+	res := &probeResult{URL: url, Samples: samples}
+	var times []float64
+	for range samples {
+		start := time.Now()
+		resp, err := probeClient.Get(url)
+		if err != nil {
+			res.Failures++
+			res.Error = err.Error()
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		times = append(times, float64(time.Since(start).Microseconds())/1000)
+		res.Status = resp.StatusCode
+	}
+	if len(times) > 0 {
+		slices.Sort(times)
+		res.MinMS = times[0]
+		res.MedianMS = times[len(times)/2]
+		res.MaxMS = times[len(times)-1]
+	}
+	return res
+}
@@ -1,12 +1,12 @@
 package dashboard
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +14,23 @@ import (
 	"thought-process/process"
 )
 
+// maxWaitForChange caps the wait_for_change query param of
+// GET /api/processes.
+const maxWaitForChange = 5 * time.Minute
+
+// listChanges are the events after which a wait_for_change list returns.
+var listChanges = []process.EventType{
+	process.EventStarted,
+	process.EventExited,
+	process.EventCrashed,
+	process.EventRestarted,
+	process.EventCrashLooping,
+	process.EventTimedOut,
+	process.EventHealthChanged,
+	process.EventEvicted,
+	process.EventDeleted,
+}
+
 func (s *Server) handleListProcesses(w http.ResponseWriter, r *http.Request) {
 	filter := process.ListFilter{
 		ExitedSinceSecs: 10,
@@ -26,14 +43,44 @@ func (s *Server) handleListProcesses(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Parse tag.* query params
-	for key, values := range r.URL.Query() {
-		if strings.HasPrefix(key, "tag.") && len(values) > 0 {
-			tagName := strings.TrimPrefix(key, "tag.")
-			if filter.Tags == nil {
-				filter.Tags = make(map[string]string)
-			}
-			filter.Tags[tagName] = values[0]
+	// Parse include_tree query param
+	if tree := r.URL.Query().Get("include_tree"); tree != "" {
+		filter.IncludeTree, _ = strconv.ParseBool(tree)
+	}
+
+	// Parse include_pending query param
+	if pending := r.URL.Query().Get("include_pending"); pending != "" {
+		filter.IncludePending, _ = strconv.ParseBool(pending)
+	}
+
+	// Parse collapse_lineage query param
+	if collapse := r.URL.Query().Get("collapse_lineage"); collapse != "" {
+		filter.CollapseLineage, _ = strconv.ParseBool(collapse)
+	}
+
+	filter.Tags = tagSelector(r)
+
+	// Parse group_by query param, e.g. group_by=tag:branch
+	var groupTag string
+	if groupBy := r.URL.Query().Get("group_by"); groupBy != "" {
+		tag, err := process.ParseGroupBy(groupBy)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		groupTag = tag
+	}
+
+	// Parse wait_for_change query param, e.g. wait_for_change=30s: the list
+	// is returned after the next lifecycle change or the timeout.
+	if wait := r.URL.Query().Get("wait_for_change"); wait != "" {
+		d, err := time.ParseDuration(wait)
+		if err != nil || d <= 0 || d > maxWaitForChange {
+			http.Error(w, fmt.Sprintf("wait_for_change must be a duration up to %s, e.g. 30s", maxWaitForChange), http.StatusBadRequest)
+			return
+		}
+		if !s.waitForChange(r, d) {
+			return
 		}
 	}
 
@@ -44,9 +91,67 @@ func (s *Server) handleListProcesses(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if groupTag != "" {
+		json.NewEncoder(w).Encode(process.GroupByTag(processes, groupTag))
+		return
+	}
 	json.NewEncoder(w).Encode(processes)
 }
 
+// waitForChange blocks until a process starts, exits, restarts or is
+// evicted, or d has passed. It returns false if the client went away.
+func (s *Server) waitForChange(r *http.Request, d time.Duration) bool {
+	events, cancel := s.mgr.Subscribe()
+	defer cancel()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return false
+		case <-timer.C:
+			return true
+		case e, ok := <-events:
+			if !ok || slices.Contains(listChanges, e.Type) {
+				return true
+			}
+		}
+	}
+}
+
+// handleKillMatching kills every process matching the tag.* query params,
+// e.g. DELETE /api/processes?tag.branch=feature-x.
+func (s *Server) handleKillMatching(w http.ResponseWriter, r *http.Request) {
+	tags := tagSelector(r)
+	if len(tags) == 0 {
+		http.Error(w, "at least one tag.* selector is required", http.StatusBadRequest)
+		return
+	}
+
+	views, err := s.mgr.KillMatching(tags)
+	if err != nil && len(views) == 0 {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// tagSelector parses tag.* query params into a tag filter.
+func tagSelector(r *http.Request) map[string]string {
+	var tags map[string]string
+	for key, values := range r.URL.Query() {
+		if strings.HasPrefix(key, "tag.") && len(values) > 0 {
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			tags[strings.TrimPrefix(key, "tag.")] = values[0]
+		}
+	}
+	return tags
+}
+
 func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -64,6 +169,17 @@ func (s *Server) handleGetLogs(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(logs))
 }
 
+// handleGetErrors returns a process's error fingerprints.
+func (s *Server) handleGetErrors(w http.ResponseWriter, r *http.Request) {
+	fps, err := s.mgr.ErrorFingerprints(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fps)
+}
+
 func (s *Server) handleStreamLogs(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -89,42 +205,23 @@ func (s *Server) handleStreamLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Open the log file
-	f, err := os.Open(logPath)
-	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
-		flusher.Flush()
-		return
-	}
-	defer f.Close()
-
-	// Get initial file size and send existing content
-	stat, err := f.Stat()
+	// Open the log file, starting with the last 100KB. The tail follows
+	// the log if the process truncates or rotates it.
+	const maxInitialRead = 100 * 1024
+	tail, err := process.OpenLogTail(logPath, maxInitialRead)
 	if err != nil {
 		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
 		flusher.Flush()
 		return
 	}
+	defer tail.Close()
 
-	// Read and send initial content (last 100KB)
-	const maxInitialRead = 100 * 1024
-	offset := int64(0)
-	if stat.Size() > maxInitialRead {
-		offset = stat.Size() - maxInitialRead
-	}
-	if offset > 0 {
-		f.Seek(offset, io.SeekStart)
-	}
-
-	reader := bufio.NewReader(f)
-	initialData, _ := io.ReadAll(reader)
+	// Send existing content
+	initialData, _ := io.ReadAll(tail)
 	if len(initialData) > 0 {
 		sendSSEData(w, flusher, string(initialData))
 	}
 
-	// Track position for tailing
-	currentPos, _ := f.Seek(0, io.SeekCurrent)
-
 	// Tail the file for new content
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
@@ -135,24 +232,9 @@ func (s *Server) handleStreamLogs(w http.ResponseWriter, r *http.Request) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			// Check for new content
-			stat, err := f.Stat()
-			if err != nil {
-				continue
-			}
-
-			if stat.Size() > currentPos {
-				// Seek to where we left off
-				f.Seek(currentPos, io.SeekStart)
-				newData := make([]byte, stat.Size()-currentPos)
-				n, err := f.Read(newData)
-				if err != nil && err != io.EOF {
-					continue
-				}
-				if n > 0 {
-					sendSSEData(w, flusher, string(newData[:n]))
-					currentPos += int64(n)
-				}
+			newData, _ := io.ReadAll(tail)
+			if len(newData) > 0 {
+				sendSSEData(w, flusher, string(newData))
 			}
 		}
 	}
@@ -187,3 +269,287 @@ func (s *Server) handleKillProcess(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(view)
 }
+
+// handleDeleteProcess removes an exited process's record and log, e.g.
+// DELETE /api/processes/abc123?force=1 to kill it first if running.
+func (s *Server) handleDeleteProcess(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "process ID required", http.StatusBadRequest)
+		return
+	}
+	force, _ := strconv.ParseBool(r.URL.Query().Get("force"))
+
+	view, err := s.mgr.Delete(id, force)
+	if errors.Is(err, process.ErrStillRunning) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+func (s *Server) handlePauseProcess(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "process ID required", http.StatusBadRequest)
+		return
+	}
+
+	view, err := s.mgr.Pause(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+func (s *Server) handleResumeProcess(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "process ID required", http.StatusBadRequest)
+		return
+	}
+
+	view, err := s.mgr.Resume(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// handleRestartProcess kills a process and starts it again. The restarted
+// process has a new ID.
+func (s *Server) handleRestartProcess(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "process ID required", http.StatusBadRequest)
+		return
+	}
+
+	view, err := s.mgr.Restart(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// maxInputBytes caps the request body accepted by handleSendInput.
+const maxInputBytes = 64 * 1024
+
+// handleSendInput writes the request body to the process's stdin as a line.
+func (s *Server) handleSendInput(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "process ID required", http.StatusBadRequest)
+		return
+	}
+
+	input, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInputBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	view, err := s.mgr.SendInput(id, string(input))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+func (s *Server) handleFindByPort(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil {
+		http.Error(w, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	owner, err := s.mgr.FindByPort(port)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(owner)
+}
+
+// handlePortMap returns the ports of all tracked processes, schedules and
+// stacks.
+func (s *Server) handlePortMap(w http.ResponseWriter, r *http.Request) {
+	portMap, err := s.mgr.PortMap()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(portMap)
+}
+
+// handleCancelPending cancels the next run of a schedule listed with
+// include_pending.
+func (s *Server) handleCancelPending(w http.ResponseWriter, r *http.Request) {
+	schedule, err := s.mgr.CancelPending(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// handleAlerts returns the high-memory and OOM-kill alerts recorded on all
+// processes, newest first.
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts, err := s.mgr.Alerts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// handleThoughts returns journal entries, newest first, filtered by the
+// project, tag.*, since, until and limit query params.
+func (s *Server) handleThoughts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := process.ThoughtFilter{Project: q.Get("project"), Tags: tagSelector(r)}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		filter.Limit = n
+	}
+	var err error
+	if since := q.Get("since"); since != "" {
+		filter.Since, err = process.ParseThoughtTime(since, false)
+	}
+	if until := q.Get("until"); err == nil && until != "" {
+		filter.Until, err = process.ParseThoughtTime(until, true)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	thoughts, err := s.mgr.Thoughts(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(thoughts)
+}
+
+// handleToolCalls returns the access log of MCP tool calls, newest first,
+// filtered by tool, outcome, since, until and limit (default 200).
+func (s *Server) handleToolCalls(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := process.ToolCallFilter{Tool: q.Get("tool"), Outcome: q.Get("outcome"), Limit: 200}
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		filter.Limit = n
+	}
+	var err error
+	if since := q.Get("since"); since != "" {
+		filter.Since, err = process.ParseThoughtTime(since, false)
+	}
+	if until := q.Get("until"); err == nil && until != "" {
+		filter.Until, err = process.ParseThoughtTime(until, true)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	calls, err := s.mgr.ToolCalls(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(calls)
+}
+
+// handleListStacks returns the defined stacks with their current members.
+func (s *Server) handleListStacks(w http.ResponseWriter, r *http.Request) {
+	stacks, err := s.mgr.Stacks()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stacks)
+}
+
+func (s *Server) handleStartStack(w http.ResponseWriter, r *http.Request) {
+	results, err := s.mgr.StartStack(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (s *Server) handleStopStack(w http.ResponseWriter, r *http.Request) {
+	views, err := s.mgr.StopStack(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+func (s *Server) handleRestartStack(w http.ResponseWriter, r *http.Request) {
+	results, err := s.mgr.RestartStack(r.PathValue("name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleSummary returns process counts for status bars; ?format=text
+// returns a single line such as "3 running, 1 failing" instead of JSON.
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.mgr.Summary()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, summary)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, m := range s.metrics {
+		if err := m.WriteMetrics(w); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"thought-process/process"
+)
+
+// handlePreview reverse-proxies /preview/{id}/{port}/{path...} to the
+// process's local HTTP server, so the dashboard can show it in an iframe
+// without CORS or mixed-origin problems. Only ports the process declared or
+// is listening on can be reached, so the proxy can't be pointed at other
+// local services.
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil {
+		http.Error(w, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	views, err := s.mgr.List(process.ListFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	i := slices.IndexFunc(views, func(v process.ProcessView) bool { return v.ID == id })
+	if i < 0 || views[i].Status != process.StatusRunning {
+		http.Error(w, "process not running", http.StatusNotFound)
+		return
+	}
+	if !slices.Contains(views[i].Ports, port) && !slices.Contains(views[i].DetectedPorts, port) {
+		http.Error(w, "port not used by this process", http.StatusForbidden)
+		return
+	}
+
+	target := &url.URL{Scheme: "http", Host: "127.0.0.1:" + strconv.Itoa(port)}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.URL.Path = "/" + r.PathValue("path")
+			pr.Out.URL.RawPath = ""
+			pr.Out.Host = target.Host
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// The dashboard is the framing page; let the app be framed.
+			resp.Header.Del("X-Frame-Options")
+			// Sandbox the app even when it is opened directly rather than
+			// in the iframe, so its scripts never run with the dashboard's
+			// origin. Added to, not replacing, the app's own policy.
+			resp.Header.Add("Content-Security-Policy", "sandbox allow-scripts allow-forms allow-popups")
+			return nil
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,106 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"thought-process/process"
+)
+
+// defaultSearchLimit caps search results unless the request sets limit.
+const defaultSearchLimit = 20
+
+// handleSearch returns the processes matching every term of the q query
+// param, best match first. A term matches a process's ID, name, command,
+// port, or a tag written as "key:value", "key=value" or just the value.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	terms := strings.Fields(strings.ToLower(r.URL.Query().Get("q")))
+	limit := defaultSearchLimit
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+
+	views, err := s.mgr.List(process.ListFilter{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type scored struct {
+		view  process.ProcessView
+		score int
+	}
+	var matches []scored
+	for _, v := range views {
+		score, ok := searchScore(v, terms)
+		if !ok {
+			continue
+		}
+		// Live processes are what you usually want to jump to.
+		if v.Status == process.StatusRunning || v.Status == process.StatusPaused {
+			score += 5
+		}
+		matches = append(matches, scored{v, score})
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return b.view.StartedAt.Compare(a.view.StartedAt)
+	})
+
+	results := make([]process.ProcessView, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		results = append(results, m.view)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// searchScore reports whether v matches all terms and how well. Exact and
+// prefix matches on the name or ID rank above substring matches elsewhere.
+func searchScore(v process.ProcessView, terms []string) (int, bool) {
+	name := strings.ToLower(v.Name)
+	command := strings.ToLower(strings.Join(append([]string{v.Command}, v.Args...), " "))
+
+	total := 0
+	for _, t := range terms {
+		score := 0
+		switch {
+		case name == t:
+			score = 100
+		case strings.HasPrefix(v.ID, t):
+			score = 80
+		case name != "" && strings.HasPrefix(name, t):
+			score = 60
+		case strings.Contains(name, t):
+			score = 40
+		}
+		for k, val := range v.Tags {
+			k, val = strings.ToLower(k), strings.ToLower(val)
+			switch {
+			case t == k+":"+val || t == k+"="+val || t == val:
+				score = max(score, 50)
+			case strings.Contains(val, t):
+				score = max(score, 20)
+			}
+		}
+		for _, p := range slices.Concat(v.Ports, v.DetectedPorts) {
+			if t == strconv.Itoa(p) || t == ":"+strconv.Itoa(p) {
+				score = max(score, 50)
+			}
+		}
+		if score == 0 && strings.Contains(command, t) {
+			score = 10
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
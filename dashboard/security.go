@@ -0,0 +1,28 @@
+package dashboard
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// checkHost rejects requests whose Host header isn't an IP address,
+// localhost, or the host the dashboard was bound to. A DNS-rebinding page
+// reaches the server under its own host name, which this refuses, while IP
+// literals can't be rebound.
+func checkHost(addr string, next http.Handler) http.Handler {
+	bound, _, _ := net.SplitHostPort(addr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(strings.Trim(host, "[]"))
+
+		if net.ParseIP(host) == nil && host != "localhost" && !strings.HasSuffix(host, ".localhost") && host != strings.ToLower(bound) {
+			http.Error(w, "unexpected Host header", http.StatusMisdirectedRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -3,6 +3,7 @@ package dashboard
 import (
 	"context"
 	"embed"
+	"io"
 	"io/fs"
 	"net/http"
 
@@ -12,31 +13,68 @@ import (
 //go:embed static/*
 var staticFS embed.FS
 
+// MetricsSource writes metrics in the Prometheus text exposition format.
+type MetricsSource interface {
+	WriteMetrics(w io.Writer) error
+}
+
 // Server serves the web dashboard for viewing and managing processes.
 type Server struct {
-	mgr    process.ProcessManager
-	server *http.Server
+	mgr     process.ProcessManager
+	metrics []MetricsSource
+	server  *http.Server
 }
 
 // NewServer creates a new dashboard server bound to the given address.
-func NewServer(addr string, mgr process.ProcessManager) *Server {
-	s := &Server{mgr: mgr}
+// Metrics from each source are served at /metrics.
+func NewServer(addr string, mgr process.ProcessManager, metrics ...MetricsSource) *Server {
+	s := &Server{mgr: mgr, metrics: metrics}
 
 	mux := http.NewServeMux()
 
 	// API routes
 	mux.HandleFunc("GET /api/processes", s.handleListProcesses)
+	mux.HandleFunc("DELETE /api/processes", s.handleKillMatching)
+	mux.HandleFunc("DELETE /api/processes/{id}", s.handleDeleteProcess)
 	mux.HandleFunc("GET /api/processes/{id}/logs", s.handleGetLogs)
 	mux.HandleFunc("GET /api/processes/{id}/logs/stream", s.handleStreamLogs)
+	mux.HandleFunc("GET /api/processes/{id}/errors", s.handleGetErrors)
 	mux.HandleFunc("POST /api/processes/{id}/kill", s.handleKillProcess)
+	mux.HandleFunc("POST /api/processes/{id}/pause", s.handlePauseProcess)
+	mux.HandleFunc("POST /api/processes/{id}/resume", s.handleResumeProcess)
+	mux.HandleFunc("POST /api/processes/{id}/stdin", s.handleSendInput)
+	mux.HandleFunc("POST /api/processes/{id}/restart", s.handleRestartProcess)
+	mux.HandleFunc("POST /api/pending/{id}/cancel", s.handleCancelPending)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /api/summary", s.handleSummary)
+	mux.HandleFunc("GET /api/alerts", s.handleAlerts)
+	mux.HandleFunc("GET /api/thoughts", s.handleThoughts)
+	mux.HandleFunc("GET /api/tool-calls", s.handleToolCalls)
+	mux.HandleFunc("GET /api/stacks", s.handleListStacks)
+	mux.HandleFunc("POST /api/stacks/{name}/start", s.handleStartStack)
+	mux.HandleFunc("POST /api/stacks/{name}/stop", s.handleStopStack)
+	mux.HandleFunc("POST /api/stacks/{name}/restart", s.handleRestartStack)
+	mux.HandleFunc("GET /api/compare", s.handleCompare)
+	mux.HandleFunc("GET /api/ports", s.handlePortMap)
+	mux.HandleFunc("GET /api/ports/{port}", s.handleFindByPort)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("/preview/{id}/{port}/{path...}", s.handlePreview)
 
 	// Static files
 	staticContent, _ := fs.Sub(staticFS, "static")
 	mux.Handle("/", http.FileServer(http.FS(staticContent)))
 
+	// Reject cross-origin mutations (CSRF) and requests addressed to
+	// unexpected host names (DNS rebinding) so that a web page open in the
+	// user's browser can't drive or read the local API.
+	csrf := http.NewCrossOriginProtection()
+	// Previews run sandboxed with an opaque origin, so their own form posts
+	// look cross-origin; they only ever reach the previewed app, not the API.
+	csrf.AddInsecureBypassPattern("/preview/")
 	s.server = &http.Server{
 		Addr:    addr,
-		Handler: mux,
+		Handler: checkHost(addr, csrf.Handler(mux)),
 	}
 
 	return s
@@ -7,6 +7,32 @@
     const logsContent = document.getElementById('logs-content');
     const logsStatus = document.getElementById('logs-status');
     const detailKillBtn = document.getElementById('detail-kill-btn');
+    const detailPauseBtn = document.getElementById('detail-pause-btn');
+    const previewSection = document.getElementById('preview-section');
+    const previewPort = document.getElementById('preview-port');
+    const previewOpen = document.getElementById('preview-open');
+    const previewFrame = document.getElementById('preview-frame');
+    const palette = document.getElementById('palette');
+    const paletteInput = document.getElementById('palette-input');
+    const paletteResults = document.getElementById('palette-results');
+    const eventsStatus = document.getElementById('events-status');
+    const crashBanner = document.getElementById('crash-banner');
+    const crashBannerTitle = document.getElementById('crash-banner-title');
+    const crashBannerList = document.getElementById('crash-banner-list');
+    const journal = document.getElementById('journal');
+    const journalProject = document.getElementById('journal-project');
+    const journalTags = document.getElementById('journal-tags');
+    const journalSince = document.getElementById('journal-since');
+    const journalEntries = document.getElementById('journal-entries');
+    const activity = document.getElementById('activity');
+    const activityTool = document.getElementById('activity-tool');
+    const activityOutcome = document.getElementById('activity-outcome');
+    const activitySince = document.getElementById('activity-since');
+    const activityEntries = document.getElementById('activity-entries');
+    const compare = document.getElementById('compare');
+    const compareOther = document.getElementById('compare-other');
+    const comparePath = document.getElementById('compare-path');
+    const compareBody = document.getElementById('compare-body');
 
     let autoRefreshInterval = null;
     let currentLogStream = null;
@@ -29,16 +55,31 @@
 
     function formatTimeAgo(dateStr) {
         if (!dateStr) return '-';
-        const date = new Date(dateStr);
-        const now = new Date();
-        const seconds = Math.floor((now - date) / 1000);
+        return formatSecondsAgo(Math.floor((new Date() - new Date(dateStr)) / 1000));
+    }
 
+    // The server's uptime_secs and exited_secs_ago don't depend on this
+    // browser's clock or on clock changes, so prefer them to timestamps.
+    function formatSecondsAgo(seconds) {
         if (seconds < 60) return seconds + 's ago';
         if (seconds < 3600) return Math.floor(seconds / 60) + 'm ago';
         if (seconds < 86400) return Math.floor(seconds / 3600) + 'h ago';
         return Math.floor(seconds / 86400) + 'd ago';
     }
 
+    function formatStartedAgo(proc) {
+        if (proc.uptime_secs == null) return formatTimeAgo(proc.started_at);
+        return formatSecondsAgo(proc.uptime_secs + (proc.exited_secs_ago || 0));
+    }
+
+    function formatTimeUntil(dateStr) {
+        const seconds = Math.max(0, Math.floor((new Date(dateStr) - new Date()) / 1000));
+        if (seconds < 60) return 'in ' + seconds + 's';
+        if (seconds < 3600) return 'in ' + Math.floor(seconds / 60) + 'm';
+        if (seconds < 86400) return 'in ' + Math.floor(seconds / 3600) + 'h';
+        return 'in ' + Math.floor(seconds / 86400) + 'd';
+    }
+
     function formatTimestamp(dateStr) {
         if (!dateStr) return '-';
         const date = new Date(dateStr);
@@ -71,11 +112,21 @@
             .join('');
     }
 
-    function formatPorts(ports) {
-        if (!ports || ports.length === 0) {
+    function formatHealth(health) {
+        if (!health) return '';
+        return `<span class="health health-${health}">${health}</span>`;
+    }
+
+    function formatPorts(ports, detected) {
+        const declared = ports || [];
+        const undeclared = (detected || []).filter(p => !declared.includes(p));
+        if (declared.length === 0 && undeclared.length === 0) {
             return '<span class="muted">-</span>';
         }
-        return `<span class="ports">${ports.join(', ')}</span>`;
+        const parts = declared.map(p => detected && !detected.includes(p)
+            ? `<span class="port-idle" title="declared, not listening">${p}</span>` : `${p}`);
+        parts.push(...undeclared.map(p => `<span class="port-detected" title="detected, not declared">${p}</span>`));
+        return `<span class="ports">${parts.join(', ')}</span>`;
     }
 
     function formatEnv(env) {
@@ -87,6 +138,74 @@
             .join('');
     }
 
+    function formatDescendants(nodes) {
+        if (!nodes || nodes.length === 0) {
+            return '<span class="muted">-</span>';
+        }
+        return nodes
+            .map(n => `<div class="child-proc"><span class="child-pid">${n.pid}</span> ${escapeHtml(n.command)}</div>`)
+            .join('');
+    }
+
+    function formatBytes(bytes) {
+        const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+        let i = 0;
+        while (bytes >= 1024 && i < units.length - 1) {
+            bytes /= 1024;
+            i++;
+        }
+        return `${bytes < 10 && i > 0 ? bytes.toFixed(1) : Math.round(bytes)} ${units[i]}`;
+    }
+
+    // formatUsage shows a running process's memory and CPU use, or '' when
+    // it hasn't been sampled.
+    function formatUsage(proc) {
+        if (!proc.rss_bytes) return '';
+        return `${formatBytes(proc.rss_bytes)} · ${(proc.cpu_percent || 0).toFixed(1)}% CPU`;
+    }
+
+    // formatContainer describes a compose process's container, or '' for
+    // other processes.
+    function formatContainer(proc) {
+        const c = proc.container;
+        if (!c) return '';
+        const health = c.health ? ` (${c.health})` : '';
+        return `${c.name}: ${c.state}${health}`;
+    }
+
+    // formatAlert labels a process with its most recent alert.
+    function formatAlert(proc) {
+        if (!proc.alerts || proc.alerts.length === 0) return '';
+        const alert = proc.alerts[proc.alerts.length - 1];
+        const labels = {oom_killed: 'OOM killed', log_spike: 'log spike', exit_warning: 'about to exit'};
+        const label = labels[alert.kind] || 'high memory';
+        return `<span class="alert-info" title="${escapeHtml(alert.message)}">${label}</span>`;
+    }
+
+    function formatErrors(errors) {
+        if (!errors || errors.length === 0) {
+            return '<span class="muted">-</span>';
+        }
+        return errors
+            .map(e => `<div class="error-fp" title="${escapeHtml(e.sample)}"><span class="error-count">${e.count}×</span> ${escapeHtml(e.message)}</div>`)
+            .join('');
+    }
+
+    // loadErrors fills in the selected process's error fingerprints.
+    async function loadErrors(id) {
+        const el = document.getElementById('detail-errors');
+        try {
+            const response = await fetch(`/api/processes/${encodeURIComponent(id)}/errors`);
+            if (!response.ok) throw new Error(response.statusText);
+            const errors = await response.json();
+            if (selectedProcessId === id) {
+                el.innerHTML = formatErrors(errors);
+            }
+        } catch (err) {
+            el.innerHTML = '<span class="muted">-</span>';
+        }
+    }
+
     function escapeHtml(str) {
         if (str == null) return '';
         const div = document.createElement('div');
@@ -97,8 +216,8 @@
     async function fetchProcesses() {
         const exitedSecs = exitedFilter.value;
         const url = exitedSecs === '0'
-            ? '/api/processes?exited_since_secs=999999999'
-            : `/api/processes?exited_since_secs=${exitedSecs}`;
+            ? '/api/processes?include_tree=1&include_pending=1&exited_since_secs=999999999'
+            : `/api/processes?include_tree=1&include_pending=1&exited_since_secs=${exitedSecs}`;
 
         try {
             const response = await fetch(url);
@@ -132,24 +251,63 @@
 
         processesCache = processes;
 
-        processesBody.innerHTML = processes.map(proc => `
+        processesBody.innerHTML = processes.map(proc => proc.run_at ? renderPending(proc) : `
             <div class="process-item ${selectedProcessId === proc.id ? 'selected' : ''}"
                  data-id="${escapeHtml(proc.id)}"
                  data-status="${proc.status}"
                  onclick="window.selectProcess('${proc.id}')">
                 <div class="process-item-header">
                     <span class="status status-${proc.status}">${proc.status}</span>
-                    <span class="process-time">${formatTimeAgo(proc.started_at)}</span>
+                    ${formatHealth(proc.health)}
+                    ${proc.name ? `<span class="process-name">${escapeHtml(proc.name)}</span>` : ''}
+                    <span class="process-time">${formatStartedAgo(proc)}</span>
                 </div>
                 <div class="process-command">${escapeHtml(formatCommand(proc.command, proc.args))}</div>
                 <div class="process-meta">
-                    ${proc.exited_at ? `<span class="exit-info">exited ${formatTimeAgo(proc.exited_at)}</span>` : ''}
+                    ${proc.exited_at ? `<span class="exit-info">exited ${proc.exited_secs_ago != null ? formatSecondsAgo(proc.exited_secs_ago) : formatTimeAgo(proc.exited_at)}</span>` : ''}
+                    ${formatUsage(proc) ? `<span class="usage-info">${formatUsage(proc)}</span>` : ''}
+                    ${formatAlert(proc)}
+                    ${proc.pending_env ? '<span class="pending-info" title="An env change is applied on the next restart">env change pending</span>' : ''}
                 </div>
                 <div class="process-tags">${formatTagsCompact(proc.tags)}</div>
             </div>
         `).join('');
     }
 
+    // renderPending renders the next run of a schedule, which has no logs or
+    // details yet, with a button to cancel it.
+    function renderPending(proc) {
+        const what = proc.status === 'restart_scheduled' ? 'restart' : 'start';
+        return `
+            <div class="process-item pending" data-id="${escapeHtml(proc.id)}" data-status="${proc.status}">
+                <div class="process-item-header">
+                    <span class="status status-${proc.status}">${proc.status.replace('_', ' ')}</span>
+                    ${proc.name ? `<span class="process-name">${escapeHtml(proc.name)}</span>` : ''}
+                    <span class="process-time" title="${escapeHtml(formatTimestamp(proc.run_at))}">${what} ${formatTimeUntil(proc.run_at)}</span>
+                </div>
+                <div class="process-command">${escapeHtml(formatCommand(proc.command, proc.args))}</div>
+                <div class="process-meta">
+                    <button class="btn-link" onclick="window.cancelPending('${escapeHtml(proc.id)}')">Cancel</button>
+                </div>
+                <div class="process-tags">${formatTagsCompact(proc.tags)}</div>
+            </div>
+        `;
+    }
+
+    window.cancelPending = async function(id) {
+        try {
+            const response = await fetch(`/api/pending/${encodeURIComponent(id)}/cancel`, {
+                method: 'POST'
+            });
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            refresh();
+        } catch (error) {
+            alert('Error canceling run: ' + error.message);
+        }
+    };
+
     function showProcessDetail(proc) {
         if (!proc) {
             noSelection.classList.remove('hidden');
@@ -163,18 +321,71 @@
         document.getElementById('detail-command').textContent = formatCommand(proc.command, proc.args);
         document.getElementById('detail-status').textContent = proc.status;
         document.getElementById('detail-status').className = `status status-${proc.status}`;
-        document.getElementById('detail-id').textContent = proc.id;
+        document.getElementById('detail-id').textContent = proc.name ? `${proc.id} (${proc.name})` : proc.id;
         document.getElementById('detail-pid').textContent = proc.pid;
         document.getElementById('detail-started').textContent = formatTimestamp(proc.started_at);
         document.getElementById('detail-exited').textContent = proc.exited_at ? formatTimestamp(proc.exited_at) : '-';
         document.getElementById('detail-cwd').textContent = proc.cwd || '-';
-        document.getElementById('detail-ports').innerHTML = formatPorts(proc.ports);
+        document.getElementById('detail-usage').textContent = formatUsage(proc) || '-';
+        document.getElementById('detail-container').textContent = formatContainer(proc) || '-';
+        document.getElementById('detail-health').innerHTML = formatHealth(proc.health) || '<span class="muted">-</span>';
+        document.getElementById('detail-ports').innerHTML = formatPorts(proc.ports, proc.detected_ports);
         document.getElementById('detail-tags').innerHTML = formatTags(proc.tags);
         document.getElementById('detail-env').innerHTML = formatEnv(proc.env);
+        document.getElementById('detail-children').innerHTML = formatDescendants(proc.descendants);
+        loadErrors(proc.id);
+
+        updatePreview(proc);
+
+        detailKillBtn.disabled = proc.status !== 'running' && proc.status !== 'paused';
+        detailPauseBtn.disabled = proc.status !== 'running' && proc.status !== 'paused';
+        detailPauseBtn.textContent = proc.status === 'paused' ? 'Resume' : 'Pause';
+    }
+
+    // previewPorts returns the declared and detected ports of a running process.
+    function previewPorts(proc) {
+        if (proc.status !== 'running') return [];
+        const ports = [...(proc.detected_ports || []), ...(proc.ports || [])];
+        return [...new Set(ports)].sort((a, b) => a - b);
+    }
+
+    function showPreview(proc, port) {
+        const src = `/preview/${encodeURIComponent(proc.id)}/${port}/`;
+        previewOpen.href = src;
+        if (previewFrame.dataset.src !== src) {
+            previewFrame.dataset.src = src;
+            previewFrame.src = src;
+        }
+    }
+
+    // updatePreview refreshes the port choices without reloading the frame
+    // unless the selected process or port changed.
+    function updatePreview(proc) {
+        const ports = previewPorts(proc);
+        if (ports.length === 0) {
+            previewSection.classList.add('hidden');
+            previewFrame.dataset.src = '';
+            previewFrame.src = 'about:blank';
+            return;
+        }
+        previewSection.classList.remove('hidden');
 
-        detailKillBtn.disabled = proc.status !== 'running';
+        const current = Number(previewPort.value);
+        const options = ports.map(p => `<option value="${p}">:${p}</option>`).join('');
+        if (previewPort.innerHTML !== options) {
+            previewPort.innerHTML = options;
+        }
+        previewPort.value = ports.includes(current) ? current : ports[0];
+        showPreview(proc, previewPort.value);
     }
 
+    previewPort.addEventListener('change', function() {
+        const proc = processesCache.find(p => p.id === selectedProcessId);
+        if (proc) {
+            showPreview(proc, previewPort.value);
+        }
+    });
+
     function closeLogStream() {
         if (currentLogStream) {
             currentLogStream.close();
@@ -264,11 +475,23 @@
         // Start streaming logs
         if (proc) {
             startLogStream(processId);
+            // On phones the details are below the list.
+            if (window.matchMedia('(max-width: 768px)').matches) {
+                processDetail.scrollIntoView({ behavior: 'smooth' });
+            }
         }
     };
 
     window.killProcess = async function(processId) {
-        if (!confirm(`Kill process ${processId}?`)) {
+        const proc = processesCache.find(p => p.id === processId);
+        const dependents = processesCache.filter(p =>
+            (p.status === 'running' || p.status === 'paused') && p.id !== processId &&
+            (p.depends_on || []).some(d => d === processId || (proc && proc.name && d === proc.name)));
+        let message = `Kill process ${processId}?`;
+        if (dependents.length > 0) {
+            message += `\n\nStill running processes depend on it: ${dependents.map(p => p.name || p.id).join(', ')}`;
+        }
+        if (!confirm(message)) {
             return;
         }
 
@@ -286,6 +509,29 @@
         }
     };
 
+    window.togglePause = async function(processId) {
+        const proc = processesCache.find(p => p.id === processId);
+        const action = proc && proc.status === 'paused' ? 'resume' : 'pause';
+
+        try {
+            const response = await fetch(`/api/processes/${processId}/${action}`, {
+                method: 'POST'
+            });
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            refresh();
+        } catch (error) {
+            alert(`Error trying to ${action} process: ` + error.message);
+        }
+    };
+
+    detailPauseBtn.addEventListener('click', function() {
+        if (selectedProcessId) {
+            window.togglePause(selectedProcessId);
+        }
+    });
+
     detailKillBtn.addEventListener('click', function() {
         if (selectedProcessId) {
             window.killProcess(selectedProcessId);
@@ -305,10 +551,479 @@
         }
     }
 
+    // Command palette: "[verb] query" searches processes via /api/search and
+    // runs the verb (open by default) on the chosen one.
+    const paletteVerbs = {
+        open: { label: 'Open', run: proc => openProcess(proc) },
+        logs: { label: 'Tail logs', run: proc => openProcess(proc) },
+        kill: { label: 'Kill', run: proc => window.killProcess(proc.id) },
+        restart: { label: 'Restart', run: proc => restartProcess(proc) },
+    };
+    let paletteItems = [];
+    let paletteIndex = 0;
+    let paletteSeq = 0;
+
+    function parsePaletteQuery(text) {
+        const [first, ...rest] = text.trim().split(/\s+/);
+        if (paletteVerbs[first]) {
+            return { verb: first, query: rest.join(' ') };
+        }
+        return { verb: 'open', query: text.trim() };
+    }
+
+    function openPalette() {
+        palette.classList.remove('hidden');
+        paletteInput.value = '';
+        paletteInput.focus();
+        updatePalette();
+    }
+
+    function closePalette() {
+        palette.classList.add('hidden');
+    }
+
+    async function updatePalette() {
+        const { verb, query } = parsePaletteQuery(paletteInput.value);
+        const seq = ++paletteSeq;
+        let processes = [];
+        try {
+            const response = await fetch(`/api/search?q=${encodeURIComponent(query)}`);
+            if (response.ok) {
+                processes = await response.json();
+            }
+        } catch (error) {
+            console.error('Error searching processes:', error);
+        }
+        if (seq !== paletteSeq) return; // a newer query is in flight
+
+        paletteItems = processes.map(proc => ({ verb, proc }));
+        paletteIndex = 0;
+        renderPalette();
+    }
+
+    function renderPalette() {
+        if (paletteItems.length === 0) {
+            paletteResults.innerHTML = '<li class="palette-empty">No matching processes</li>';
+            return;
+        }
+        paletteResults.innerHTML = paletteItems.map((item, i) => `
+            <li class="palette-item ${i === paletteIndex ? 'active' : ''}" data-index="${i}">
+                <span class="palette-verb">${paletteVerbs[item.verb].label}</span>
+                <span class="status status-${item.proc.status}">${item.proc.status}</span>
+                ${item.proc.name ? `<span class="process-name">${escapeHtml(item.proc.name)}</span>` : `<code>${escapeHtml(item.proc.id)}</code>`}
+                <span class="palette-command">${escapeHtml(formatCommand(item.proc.command, item.proc.args))}</span>
+                <span class="process-tags">${formatTagsCompact(item.proc.tags)}</span>
+            </li>
+        `).join('');
+        paletteResults.querySelector('.active')?.scrollIntoView({ block: 'nearest' });
+    }
+
+    function runPaletteItem(index) {
+        const item = paletteItems[index];
+        if (!item) return;
+        closePalette();
+        paletteVerbs[item.verb].run(item.proc);
+    }
+
+    // openProcess selects proc even if the exited filter hides it from the list.
+    function openProcess(proc) {
+        if (!processesCache.some(p => p.id === proc.id)) {
+            processesCache.push(proc);
+        }
+        window.selectProcess(proc.id);
+    }
+
+    async function restartProcess(proc) {
+        try {
+            const response = await fetch(`/api/processes/${proc.id}/restart`, {
+                method: 'POST'
+            });
+            if (!response.ok) {
+                throw new Error(await response.text());
+            }
+            const restarted = await response.json();
+            await refresh();
+            openProcess(restarted);
+        } catch (error) {
+            alert('Error restarting process: ' + error.message);
+        }
+    }
+
+    paletteInput.addEventListener('input', updatePalette);
+
+    paletteInput.addEventListener('keydown', function(event) {
+        if (event.key === 'ArrowDown' || event.key === 'ArrowUp') {
+            event.preventDefault();
+            const step = event.key === 'ArrowDown' ? 1 : -1;
+            paletteIndex = (paletteIndex + step + paletteItems.length) % Math.max(paletteItems.length, 1);
+            renderPalette();
+        } else if (event.key === 'Enter') {
+            event.preventDefault();
+            runPaletteItem(paletteIndex);
+        } else if (event.key === 'Escape') {
+            closePalette();
+        }
+    });
+
+    paletteResults.addEventListener('click', function(event) {
+        const item = event.target.closest('.palette-item');
+        if (item) {
+            runPaletteItem(Number(item.dataset.index));
+        }
+    });
+
+    palette.addEventListener('click', function(event) {
+        if (event.target === palette) {
+            closePalette();
+        }
+    });
+
+    document.getElementById('palette-btn').addEventListener('click', openPalette);
+
+    document.addEventListener('keydown', function(event) {
+        const typing = ['INPUT', 'SELECT', 'TEXTAREA'].includes(document.activeElement?.tagName);
+        if ((event.key === 'k' && (event.ctrlKey || event.metaKey)) || (event.key === '/' && !typing)) {
+            event.preventDefault();
+            openPalette();
+        }
+    });
+
+    // Journal: entries written with append_thought, filtered by project,
+    // "key=value" tags and a start date.
+    let journalSeq = 0;
+
+    function openJournal() {
+        journal.classList.remove('hidden');
+        updateJournal();
+    }
+
+    function closeJournal() {
+        journal.classList.add('hidden');
+    }
+
+    async function updateJournal() {
+        const params = new URLSearchParams({ limit: '200' });
+        if (journalProject.value) params.set('project', journalProject.value);
+        if (journalSince.value) params.set('since', journalSince.value);
+        for (const term of journalTags.value.split(/\s+/)) {
+            const [key, value] = term.split('=');
+            if (key && value !== undefined) params.set('tag.' + key, value);
+        }
+        const seq = ++journalSeq;
+        let thoughts = [];
+        try {
+            const response = await fetch('/api/thoughts?' + params);
+            if (response.ok) thoughts = await response.json() || [];
+        } catch (error) {
+            console.error('Failed to load journal:', error);
+        }
+        if (seq !== journalSeq) return; // a newer query is in flight
+
+        const known = new Set([...journalProject.options].map(o => o.value));
+        for (const t of thoughts) {
+            if (!known.has(t.project)) {
+                known.add(t.project);
+                journalProject.add(new Option(t.project, t.project));
+            }
+        }
+        if (thoughts.length === 0) {
+            journalEntries.innerHTML = '<li class="palette-empty">No journal entries</li>';
+            return;
+        }
+        journalEntries.innerHTML = thoughts.map(t => `
+            <li class="journal-entry">
+                <div class="journal-meta">
+                    <span class="journal-project">${escapeHtml(t.project)}</span>
+                    <span title="${escapeHtml(formatTimestamp(t.created_at))}">${formatTimeAgo(t.created_at)}</span>
+                    ${formatTagsCompact(t.tags)}
+                </div>
+                <div class="journal-text">${escapeHtml(t.text)}</div>
+            </li>
+        `).join('');
+    }
+
+    document.getElementById('journal-btn').addEventListener('click', openJournal);
+    journalProject.addEventListener('change', updateJournal);
+    journalTags.addEventListener('input', updateJournal);
+    journalSince.addEventListener('change', updateJournal);
+
+    journal.addEventListener('click', function(event) {
+        if (event.target === journal) {
+            closeJournal();
+        }
+    });
+
+    document.addEventListener('keydown', function(event) {
+        if (event.key === 'Escape' && !journal.classList.contains('hidden')) {
+            closeJournal();
+        }
+    });
+
+    // Activity: the access log of tool calls, filtered by tool, outcome and
+    // a start date.
+    let activitySeq = 0;
+
+    function openActivity() {
+        activity.classList.remove('hidden');
+        updateActivity();
+    }
+
+    function closeActivity() {
+        activity.classList.add('hidden');
+    }
+
+    async function updateActivity() {
+        const params = new URLSearchParams({ limit: '200' });
+        if (activityTool.value.trim()) params.set('tool', activityTool.value.trim());
+        if (activityOutcome.value) params.set('outcome', activityOutcome.value);
+        if (activitySince.value) params.set('since', activitySince.value);
+        const seq = ++activitySeq;
+        let calls = [];
+        try {
+            const response = await fetch('/api/tool-calls?' + params);
+            if (response.ok) calls = await response.json() || [];
+        } catch (error) {
+            console.error('Failed to load tool calls:', error);
+        }
+        if (seq !== activitySeq) return; // a newer query is in flight
+
+        if (calls.length === 0) {
+            activityEntries.innerHTML = '<li class="palette-empty">No tool calls</li>';
+            return;
+        }
+        activityEntries.innerHTML = calls.map(c => `
+            <li class="journal-entry">
+                <div class="journal-meta">
+                    <span class="journal-project">${escapeHtml(c.tool)}</span>
+                    ${c.target ? `<code>${escapeHtml(c.target)}</code>` : ''}
+                    <span class="activity-${escapeHtml(c.outcome)}">${escapeHtml(c.outcome)}</span>
+                    <span>${c.duration_ms} ms</span>
+                    <span title="${escapeHtml(formatTimestamp(c.time))}">${formatTimeAgo(c.time)}</span>
+                    ${c.client ? `<span>${escapeHtml(c.client)}</span>` : ''}
+                </div>
+                ${c.error ? `<div class="journal-text">${escapeHtml(c.error)}</div>` : ''}
+            </li>
+        `).join('');
+    }
+
+    document.getElementById('activity-btn').addEventListener('click', openActivity);
+    activityTool.addEventListener('input', updateActivity);
+    activityOutcome.addEventListener('change', updateActivity);
+    activitySince.addEventListener('change', updateActivity);
+
+    activity.addEventListener('click', function(event) {
+        if (event.target === activity) {
+            closeActivity();
+        }
+    });
+
+    document.addEventListener('keydown', function(event) {
+        if (event.key === 'Escape' && !activity.classList.contains('hidden')) {
+            closeActivity();
+        }
+    });
+
+    // Compare: the selected process side by side with another one, e.g. the
+    // same role on another branch, via /api/compare.
+    function openCompare() {
+        const proc = processesCache.find(p => p.id === selectedProcessId);
+        if (!proc) return;
+        const others = processesCache.filter(p => p.id !== proc.id && !p.run_at); // skip pending runs
+        const role = proc.tags?.role;
+        const counterpart = others.find(p => role && p.tags?.role === role && p.tags?.branch !== proc.tags?.branch && p.status === 'running')
+            || others.find(p => p.status === 'running');
+        compareOther.innerHTML = others.map(p => {
+            const label = `${p.name || p.id} · ${formatTagsText(p.tags)} · ${p.status}`;
+            return `<option value="${escapeHtml(p.id)}" ${p === counterpart ? 'selected' : ''}>${escapeHtml(label)}</option>`;
+        }).join('');
+        compare.classList.remove('hidden');
+        runCompare();
+    }
+
+    function closeCompare() {
+        compare.classList.add('hidden');
+    }
+
+    function formatTagsText(tags) {
+        return Object.entries(tags || {}).map(([k, v]) => `${k}:${v}`).join(' ') || 'no tags';
+    }
+
+    function formatProbe(probe) {
+        if (!probe) return '<span class="muted">no port to probe</span>';
+        if (probe.failures === probe.samples) {
+            return `<span class="compare-bad" title="${escapeHtml(probe.url)}">${escapeHtml(probe.error || 'failed')}</span>`;
+        }
+        const failures = probe.failures ? ` · <span class="compare-bad">${probe.failures}/${probe.samples} failed</span>` : '';
+        return `<span title="${escapeHtml(probe.url)}">${probe.median_ms.toFixed(1)} ms</span> ` +
+            `<span class="muted">(${probe.min_ms.toFixed(1)}–${probe.max_ms.toFixed(1)}, HTTP ${probe.status})</span>${failures}`;
+    }
+
+    async function runCompare() {
+        if (!compareOther.value) {
+            compareBody.innerHTML = '<div class="palette-empty">No other process to compare with</div>';
+            return;
+        }
+        compareBody.innerHTML = '<div class="palette-empty">Comparing...</div>';
+        const params = new URLSearchParams({ a: selectedProcessId, b: compareOther.value, path: comparePath.value || '/' });
+        let result;
+        try {
+            const response = await fetch('/api/compare?' + params);
+            if (!response.ok) {
+                compareBody.innerHTML = `<div class="palette-empty">${escapeHtml(await response.text())}</div>`;
+                return;
+            }
+            result = await response.json();
+        } catch (error) {
+            compareBody.innerHTML = `<div class="palette-empty">${escapeHtml(error.message)}</div>`;
+            return;
+        }
+
+        const rows = [
+            ['Process', s => `<code>${escapeHtml(s.process.name || s.process.id)}</code>`],
+            ['Status', s => `<span class="status status-${s.process.status}">${s.process.status}</span> ${formatHealth(s.process.health)}`],
+            ['Tags', s => formatTags(s.process.tags)],
+            ['Memory / CPU', s => formatUsage(s.process) || '<span class="muted">-</span>'],
+            ['Restarts', s => String(s.process.restarts || 0)],
+            ['GET ' + escapeHtml(result.path), s => formatProbe(s.probe)],
+            ['Recent errors', s => formatErrors(s.errors)],
+        ];
+        compareBody.innerHTML = `<table class="compare-table">${rows.map(([label, cell]) => `
+            <tr><th>${label}</th><td>${cell(result.sides[0])}</td><td>${cell(result.sides[1])}</td></tr>
+        `).join('')}</table>`;
+    }
+
+    document.getElementById('detail-compare-btn').addEventListener('click', openCompare);
+    document.getElementById('compare-run').addEventListener('click', runCompare);
+    compareOther.addEventListener('change', runCompare);
+    comparePath.addEventListener('keydown', function(event) {
+        if (event.key === 'Enter') runCompare();
+    });
+
+    compare.addEventListener('click', function(event) {
+        if (event.target === compare) {
+            closeCompare();
+        }
+    });
+
+    document.addEventListener('keydown', function(event) {
+        if (event.key === 'Escape' && !compare.classList.contains('hidden')) {
+            closeCompare();
+        }
+    });
+
+    // Crash banner: crashes reported on /api/events since the page loaded
+    // stay listed until dismissed.
+    const pageTitle = document.title;
+    let crashes = [];
+
+    function setEventsStatus(connected) {
+        eventsStatus.classList.toggle('connected', connected);
+        eventsStatus.title = connected ? 'Live: watching for crashes' : 'Event stream disconnected, retrying';
+    }
+
+    function renderCrashBanner() {
+        document.title = crashes.length > 0 ? `(${crashes.length}) ${pageTitle}` : pageTitle;
+        if (crashes.length === 0) {
+            crashBanner.classList.add('hidden');
+            return;
+        }
+        crashBanner.classList.remove('hidden');
+        crashBannerTitle.textContent = crashes.length === 1
+            ? '1 crash since page load:'
+            : `${crashes.length} crashes since page load:`;
+        crashBannerList.innerHTML = crashes.slice().reverse().map((event, i) => {
+            const proc = event.process;
+            const label = proc.name || proc.id;
+            const detail = event.type === 'crash_looping' ? 'crash looping' : `exit ${proc.exit_code}`;
+            return `<a href="#" class="crash-link" data-index="${crashes.length - 1 - i}">${escapeHtml(label)} (${detail}, ${formatTimeAgo(event.time)})</a>`;
+        }).join('');
+    }
+
+    crashBannerList.addEventListener('click', function(event) {
+        const link = event.target.closest('.crash-link');
+        if (link) {
+            event.preventDefault();
+            openProcess(crashes[Number(link.dataset.index)].process);
+        }
+    });
+
+    document.getElementById('crash-banner-dismiss').addEventListener('click', function() {
+        crashes = [];
+        renderCrashBanner();
+    });
+
+    // Toasts for log_match, new_error and alert events: gone after 10 seconds or
+    // on click, which also opens the process.
+    const toasts = document.getElementById('toasts');
+
+    // showToast shows titleHtml (already escaped) above line for proc.
+    function showToast(proc, titleHtml, line) {
+        const toast = document.createElement('div');
+        toast.className = 'toast';
+        toast.innerHTML = `<div class="toast-title">${titleHtml}</div>
+            <code class="toast-line">${escapeHtml(line)}</code>`;
+        toast.addEventListener('click', function() {
+            toast.remove();
+            openProcess(proc);
+        });
+        toasts.appendChild(toast);
+        setTimeout(() => toast.remove(), 10000);
+    }
+
+    function showLogMatch(event) {
+        const proc = event.process;
+        const match = event.match;
+        const count = match.count > 1 ? ` (${match.count} lines)` : '';
+        showToast(proc, `${escapeHtml(proc.name || proc.id)} matched <code>${escapeHtml(match.pattern)}</code>${count}`, match.line);
+    }
+
+    function showNewError(event) {
+        const proc = event.process;
+        showToast(proc, `New error in ${escapeHtml(proc.name || proc.id)}`, event.error.message);
+    }
+
+    function showAlert(event) {
+        const proc = event.process;
+        const titles = {oom_killed: 'OOM killed', log_spike: 'Log spike', exit_warning: 'About to exit'};
+        const title = titles[event.type] || 'High memory';
+        showToast(proc, `${title}: ${escapeHtml(proc.name || proc.id)}`, event.alert.message);
+    }
+
+    function showDiedInSleep(event) {
+        const proc = event.process;
+        const code = proc.exit_code != null ? `exit ${proc.exit_code}` : 'exit code unknown';
+        showToast(proc, `Died during sleep: ${escapeHtml(proc.name || proc.id)}`, `${code}; slept ${Math.max(1, Math.round(event.sleep.secs / 60))}m`);
+    }
+
+    function connectEvents() {
+        // EventSource reconnects by itself; onerror only updates the indicator.
+        const events = new EventSource('/api/events');
+        events.onopen = () => setEventsStatus(true);
+        events.onerror = () => setEventsStatus(false);
+        for (const type of ['crashed', 'crash_looping']) {
+            events.addEventListener(type, function(message) {
+                crashes.push(JSON.parse(message.data));
+                renderCrashBanner();
+                refresh();
+            });
+        }
+        for (const type of ['started', 'exited', 'restarted', 'timed_out', 'health_changed', 'evicted', 'deleted']) {
+            events.addEventListener(type, refresh);
+        }
+        events.addEventListener('log_match', message => showLogMatch(JSON.parse(message.data)));
+        events.addEventListener('new_error', message => showNewError(JSON.parse(message.data)));
+        for (const type of ['high_memory', 'oom_killed', 'log_spike', 'exit_warning']) {
+            events.addEventListener(type, message => showAlert(JSON.parse(message.data)));
+        }
+        events.addEventListener('died_in_sleep', message => showDiedInSleep(JSON.parse(message.data)));
+    }
+
     exitedFilter.addEventListener('change', refresh);
     refreshBtn.addEventListener('click', refresh);
 
     // Initial load and auto-refresh every 5 seconds
     refresh();
+    connectEvents();
+    setInterval(renderCrashBanner, 30000); // keep the "ago" times current
     autoRefreshInterval = setInterval(refresh, 5000);
 })();
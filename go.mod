@@ -2,7 +2,10 @@ module thought-process
 
 go 1.25.1
 
-require github.com/modelcontextprotocol/go-sdk v1.2.0
+require (
+	github.com/modelcontextprotocol/go-sdk v1.2.0
+	golang.org/x/sys v0.40.0
+)
 
 require (
 	github.com/google/jsonschema-go v0.3.0 // indirect
@@ -0,0 +1,52 @@
+// Package keychain reads secrets from the operating system's credential
+// store: the macOS Keychain via security(1), or the freedesktop Secret
+// Service (GNOME Keyring, KWallet) via secret-tool(1) elsewhere.
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// getTimeout bounds a lookup, which may wait on an unlock prompt.
+const getTimeout = 30 * time.Second
+
+// ErrNotFound is returned when no secret is stored under the requested name.
+var ErrNotFound = errors.New("secret not found in keychain")
+
+// Get returns the secret stored for service and account.
+func Get(service, account string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	} else {
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "account", account)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("reading %s/%s from the keychain: timed out after %s", service, account, getTimeout)
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%w: %s/%s", ErrNotFound, service, account)
+		}
+		return "", fmt.Errorf("running %s: %w", cmd.Path, err)
+	}
+	secret := strings.TrimRight(string(out), "\n")
+	if secret == "" {
+		return "", fmt.Errorf("%w: %s/%s", ErrNotFound, service, account)
+	}
+	return secret, nil
+}
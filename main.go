@@ -2,17 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/config"
+	"thought-process/control"
 	"thought-process/dashboard"
+	"thought-process/keychain"
 	"thought-process/process"
 	"thought-process/store"
 	"thought-process/tools"
@@ -20,6 +25,10 @@ import (
 
 func main() {
 	dashboardAddr := flag.String("dashboard", "", "address to serve dashboard on (e.g. :8080)")
+	enableTools := flag.String("enable-tools", "", "comma-separated optional tool groups to register, or 'all'")
+	disableTools := flag.String("disable-tools", "", "comma-separated tool groups not to register")
+	encryptStore := flag.Bool("encrypt-store", false, "encrypt process records at rest (key from $THOUGHT_PROCESS_STORE_KEY or the OS keychain)")
+	noDaemon := flag.Bool("no-daemon", false, "serve in this process even if a daemon is running")
 	flag.Parse()
 
 	homeDir, err := os.UserHomeDir()
@@ -31,6 +40,25 @@ func main() {
 	dataDir := filepath.Join(baseDir, "data")
 	logDir := filepath.Join(baseDir, "logs")
 
+	daemonMode := flag.Arg(0) == "daemon"
+	if daemonMode && flag.Arg(1) != "run" {
+		runDaemonCommand(baseDir, flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "procfile" {
+		runProcfileCommand(baseDir, flag.Args()[1:])
+		return
+	}
+	if !daemonMode && flag.Arg(0) != "fsck" && !*noDaemon {
+		// With a daemon running, this process is only a stdio front end.
+		if proxied, err := proxyToDaemon(daemonSocket(baseDir)); proxied {
+			if err != nil {
+				log.Fatalf("daemon connection: %v", err)
+			}
+			return
+		}
+	}
+
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
 		log.Fatalf("creating data directory: %v", err)
 	}
@@ -39,16 +67,62 @@ func main() {
 	}
 
 	dirStore := store.NewDirStore(dataDir)
+	if err := dirStore.Migrate(); err != nil {
+		log.Fatalf("migrating data directory: %v", err)
+	}
 
-	mgr := process.NewManager(dirStore, logDir)
+	var backing store.Store = dirStore
+	var encrypted *store.Encrypted
+	if *encryptStore {
+		secret, err := storeKey()
+		if err != nil {
+			log.Fatalf("loading store encryption key: %v", err)
+		}
+		if encrypted, err = store.NewEncrypted(dirStore, secret); err != nil {
+			log.Fatalf("creating encrypted store: %v", err)
+		}
+		backing = encrypted
+	}
+
+	cfg, err := config.Load(filepath.Join(baseDir, "config.json"))
+	if err != nil {
+		log.Fatalf("loading config: %v", err)
+	}
+
+	storeMetrics := store.NewInstrumented(backing)
+	mgr := process.NewManager(storeMetrics, logDir)
+	mgr.SetLockFile(filepath.Join(baseDir, "locks.lock"))
+	mgr.SetControlSocket(filepath.Join(baseDir, "control.sock"))
+	if err := cfg.Apply(mgr); err != nil {
+		log.Printf("config: %v", err)
+	}
+	retention := process.DefaultToolCallRetention
+	if cfg.ToolCallRetentionDays != nil {
+		retention = time.Duration(*cfg.ToolCallRetentionDays) * 24 * time.Hour
+	}
+
+	if flag.Arg(0) == "fsck" {
+		runFsck(mgr, encrypted, flag.Args()[1:])
+		return
+	}
+
+	if n, err := mgr.Adopt(); err != nil {
+		log.Printf("adopting processes from a previous run: %v", err)
+	} else if n > 0 {
+		log.Printf("Adopted %d processes from a previous run", n)
+	}
 
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "thought-process",
-		Version: "0.3.0",
+		Version: tools.Version,
 	}, nil)
 
-	tools.RegisterEcho(server)
-	tools.RegisterProcessTools(server, mgr)
+	enable := append(cfg.Tools.Enable, splitList(*enableTools)...)
+	disable := append(cfg.Tools.Disable, splitList(*disableTools)...)
+	if err := tools.Register(server, mgr, enable, disable); err != nil {
+		log.Fatalf("registering tools: %v", err)
+	}
+	server.AddReceivingMiddleware(tools.AccessLog(mgr))
 
 	// Graceful shutdown on signal or when server.Run returns (stdin closed).
 	ctx, cancel := context.WithCancel(context.Background())
@@ -57,7 +131,7 @@ func main() {
 	// Start dashboard HTTP server if requested.
 	var dashServer *dashboard.Server
 	if *dashboardAddr != "" {
-		dashServer = dashboard.NewServer(*dashboardAddr, mgr)
+		dashServer = dashboard.NewServer(*dashboardAddr, mgr, storeMetrics)
 		go func() {
 			log.Printf("Dashboard available at http://%s", *dashboardAddr)
 			if err := dashServer.Start(); err != nil && err != http.ErrServerClosed {
@@ -66,6 +140,107 @@ func main() {
 		}()
 	}
 
+	// Editor plugins talk JSON-RPC to whichever server holds the control
+	// socket: the daemon, or else the first MCP server to start.
+	go func() {
+		if err := control.NewServer(mgr).Serve(ctx, filepath.Join(baseDir, "control.sock")); err != nil && daemonMode {
+			log.Printf("control socket: %v", err)
+		}
+	}()
+
+	// Schedules are run by one server per data directory at a time.
+	go func() {
+		if err := mgr.RunScheduler(ctx, filepath.Join(baseDir, "scheduler.lock")); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+	}()
+
+	// Per-branch env files for shells and other tools to source.
+	go func() {
+		if err := mgr.RunEnvExport(ctx, filepath.Join(baseDir, "env")); err != nil {
+			log.Printf("env export: %v", err)
+		}
+	}()
+
+	// Exited processes and their logs are deleted past the retention
+	// policy.
+	go func() {
+		if err := mgr.RunRetention(ctx); err != nil {
+			log.Printf("retention: %v", err)
+		}
+	}()
+
+	// Stable ROLE-BRANCH.log links to the latest logs, for tail -f.
+	go func() {
+		if err := mgr.RunLogLinks(ctx, filepath.Join(logDir, "by-name")); err != nil {
+			log.Printf("log links: %v", err)
+		}
+	}()
+
+	// Tool calls are kept for the configured number of days.
+	go func() {
+		if err := mgr.RunToolCallRetention(ctx, retention); err != nil {
+			log.Printf("tool call retention: %v", err)
+		}
+	}()
+
+	// Lifecycle events are POSTed to the configured webhooks.
+	go func() {
+		if err := mgr.RunWebhooks(ctx, func(err error) { log.Printf("%v", err) }); err != nil {
+			log.Printf("webhooks: %v", err)
+		}
+	}()
+
+	// Crashes are posted to the configured Slack and Discord webhooks.
+	go func() {
+		if err := mgr.RunNotifiers(ctx, func(err error) { log.Printf("%v", err) }); err != nil {
+			log.Printf("notifiers: %v", err)
+		}
+	}()
+
+	// Failures pop up as desktop notifications when turned on.
+	go func() {
+		if err := mgr.RunDesktopNotifications(ctx, func(err error) { log.Printf("%v", err) }); err != nil {
+			log.Printf("desktop notifications: %v", err)
+		}
+	}()
+
+	// Processes are re-verified when the machine wakes from sleep.
+	go func() {
+		if err := mgr.RunSleepWatch(ctx); err != nil {
+			log.Printf("sleep watch: %v", err)
+		}
+	}()
+
+	if cfg.StorageQuotaMB != nil {
+		// Exited processes' logs and records are evicted to stay under it.
+		go func() {
+			if err := mgr.RunStorageQuota(ctx, dataDir, *cfg.StorageQuotaMB<<20); err != nil {
+				log.Printf("storage quota: %v", err)
+			}
+		}()
+	}
+
+	// SIGHUP reloads the config; the settings Apply covers take effect
+	// right away, the rest at the next start.
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func() {
+		for range hupCh {
+			cfg, err := config.Load(filepath.Join(baseDir, "config.json"))
+			if err != nil {
+				log.Printf("reloading config: %v", err)
+				continue
+			}
+			if err := cfg.Apply(mgr); err != nil {
+				log.Printf("reloading config: %v", err)
+			}
+			log.Printf("Reloaded config")
+		}
+	}()
+
+	// SIGINT and SIGTERM stop the server; whether its processes stop too is
+	// up to shutdown_policy.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
@@ -80,7 +255,11 @@ func main() {
 		cancel()
 	}()
 
-	if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil {
+	if daemonMode {
+		if err := serveDaemon(ctx, server, daemonSocket(baseDir)); err != nil {
+			log.Fatalf("daemon: %v", err)
+		}
+	} else if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil {
 		// Context cancellation from signal is expected.
 		if ctx.Err() == nil {
 			log.Fatalf("server error: %v", err)
@@ -94,3 +273,52 @@ func main() {
 	}
 	mgr.Shutdown()
 }
+
+// storeKey returns the store encryption secret from $THOUGHT_PROCESS_STORE_KEY,
+// falling back to the "store-key" entry of the "thought-process" keychain
+// service.
+func storeKey() (string, error) {
+	if key := os.Getenv("THOUGHT_PROCESS_STORE_KEY"); key != "" {
+		return key, nil
+	}
+	return keychain.Get("thought-process", "store-key")
+}
+
+// runFsck implements the fsck subcommand, printing the report as JSON.
+// encrypted is the store's encrypting wrapper, if -encrypt-store is set.
+func runFsck(mgr *process.Manager, encrypted *store.Encrypted, args []string) {
+	fsckFlags := flag.NewFlagSet("fsck", flag.ExitOnError)
+	repair := fsckFlags.Bool("repair", false, "remove store leftovers and corrupted records")
+	encrypt := fsckFlags.Bool("encrypt", false, "encrypt records written before -encrypt-store was enabled")
+	fsckFlags.Parse(args)
+
+	if *encrypt {
+		if encrypted == nil {
+			log.Fatalf("fsck -encrypt needs -encrypt-store")
+		}
+		n, err := encrypted.EncryptPlaintext()
+		if err != nil {
+			log.Fatalf("encrypting records: %v", err)
+		}
+		log.Printf("Encrypted %d records", n)
+	}
+
+	report, err := mgr.Fsck(*repair)
+	if err != nil {
+		log.Fatalf("fsck: %v", err)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
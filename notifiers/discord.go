@@ -0,0 +1,31 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+)
+
+// discordLimit is the most content a Discord message may have.
+const discordLimit = 2000
+
+// Discord posts to a Discord channel webhook.
+type Discord struct {
+	WebhookURL string `json:"webhook_url"`
+	// Username overrides the webhook's name (default "thought-process").
+	Username string `json:"username,omitempty"`
+}
+
+func (d Discord) Notify(ctx context.Context, msg Message) error {
+	username := d.Username
+	if username == "" {
+		username = "thought-process"
+	}
+	err := postJSON(ctx, d.WebhookURL, map[string]string{
+		"content":  format(msg, "**", discordLimit),
+		"username": username,
+	})
+	if err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package notifiers
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// summary returns the title line and the tags line of msg, marking the
+// title up with bold, e.g. "*" for Slack or "**" for Discord.
+func summary(msg Message, bold string) (title, tags string) {
+	title = bold + msg.Title + bold
+	if msg.ExitCode != nil {
+		title += fmt.Sprintf(" (exit code %d)", *msg.ExitCode)
+	}
+	title += " — " + msg.ProcessID
+
+	pairs := make([]string, 0, len(msg.Tags))
+	for _, k := range slices.Sorted(maps.Keys(msg.Tags)) {
+		pairs = append(pairs, "`"+k+"="+msg.Tags[k]+"`")
+	}
+	return title, strings.Join(pairs, " ")
+}
+
+// format renders msg as chat markdown of at most limit bytes, dropping the
+// oldest log lines to fit.
+func format(msg Message, bold string, limit int) string {
+	title, tags := summary(msg, bold)
+	head := title
+	if tags != "" {
+		head += "\n" + tags
+	}
+	lines := msg.LogLines
+	for len(lines) > 0 {
+		// Backticks in the log would end the code block early.
+		block := "\n```\n" + strings.ReplaceAll(strings.Join(lines, "\n"), "```", "'''") + "\n```"
+		if len(head)+len(block) <= limit {
+			return head + block
+		}
+		lines = lines[1:]
+	}
+	if len(head) > limit {
+		head = head[:limit]
+	}
+	return head
+}
@@ -0,0 +1,116 @@
+// Package notifiers posts messages about failed processes to chat services,
+// through Slack and Discord incoming webhooks.
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Message describes a failed process.
+type Message struct {
+	// Title is a one-line summary, e.g. "api crashed".
+	Title     string
+	ProcessID string
+	Tags      map[string]string
+	ExitCode  *int
+	// LogLines are the last lines of the process's log, oldest first.
+	LogLines []string
+}
+
+// Notifier delivers a Message to one destination.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Config holds the notifiers from the config file.
+type Config struct {
+	Slack   []Slack   `json:"slack,omitempty"`
+	Discord []Discord `json:"discord,omitempty"`
+}
+
+// Validate checks the webhook URLs.
+func (c Config) Validate() error {
+	for _, s := range c.Slack {
+		if err := validateURL(s.WebhookURL); err != nil {
+			return fmt.Errorf("slack: %w", err)
+		}
+	}
+	for _, d := range c.Discord {
+		if err := validateURL(d.WebhookURL); err != nil {
+			return fmt.Errorf("discord: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook_url %q must be an http or https URL", s)
+	}
+	return nil
+}
+
+// Set sends each Message to all of its Notifiers.
+type Set struct {
+	notifiers []Notifier
+}
+
+// New returns a Set of the notifiers configured in cfg.
+func New(cfg Config) *Set {
+	s := &Set{}
+	for _, n := range cfg.Slack {
+		s.notifiers = append(s.notifiers, n)
+	}
+	for _, n := range cfg.Discord {
+		s.notifiers = append(s.notifiers, n)
+	}
+	return s
+}
+
+// Empty reports whether the Set has no Notifiers.
+func (s *Set) Empty() bool {
+	return len(s.notifiers) == 0
+}
+
+// Notify sends msg to every Notifier, returning their errors joined.
+func (s *Set) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range s.notifiers {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON POSTs payload as JSON to target, failing on a non-2xx response.
+func postJSON(ctx context.Context, target string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
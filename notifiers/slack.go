@@ -0,0 +1,24 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+)
+
+// slackLimit keeps messages within what Slack shows without truncating.
+const slackLimit = 3000
+
+// Slack posts to a Slack incoming webhook.
+type Slack struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+func (s Slack) Notify(ctx context.Context, msg Message) error {
+	err := postJSON(ctx, s.WebhookURL, map[string]string{
+		"text": format(msg, "*", slackLimit),
+	})
+	if err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
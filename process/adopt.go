@@ -0,0 +1,132 @@
+package process
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// adoptPollInterval is how often adopted processes are checked for exit.
+const adoptPollInterval = time.Second
+
+// Adopt reconciles records left behind by a previous server. Processes that
+// are still alive are tracked again: their exit is detected by polling and
+// they get port detection, health checks and Kill like processes started by
+// this Manager, but no stdin and no restart policy, and Shutdown leaves them
+// running as before. Records of processes that died unobserved are marked
+// exited, with the log file's last write as the exit time. It returns the
+// number of processes adopted.
+func (m *Manager) Adopt() (int, error) {
+	infos, err := m.records()
+	if err != nil {
+		return 0, err
+	}
+
+	adopted := 0
+	for _, info := range infos {
+		if info.ExitCode != nil || info.ExitedAt != nil {
+			continue
+		}
+		m.mu.Lock()
+		_, live := m.running[info.ID]
+		m.mu.Unlock()
+		if live {
+			continue
+		}
+
+		if !sameProcess(info.PID, info.PIDStart) {
+			m.recordLostExit(info, m.lastOutput(info))
+			continue
+		}
+
+		proc, err := os.FindProcess(info.PID)
+		if err != nil {
+			continue
+		}
+		rp := &runningProc{cmd: &exec.Cmd{Process: proc}, adopted: true, done: make(chan struct{})}
+		if info.HealthCheck != nil {
+			rp.health = HealthStarting
+		}
+		m.mu.Lock()
+		m.running[info.ID] = rp
+		m.mu.Unlock()
+
+		go m.watchAdopted(info, rp)
+		go m.watchPorts(rp)
+		go m.watchUsage(info.ID, rp)
+		go m.scanLogs(info, rp)
+		if info.HealthCheck != nil {
+			go m.watchHealth(info, rp)
+		}
+		adopted++
+	}
+	return adopted, nil
+}
+
+// watchAdopted polls an adopted process until it exits and records the exit.
+// The exit code of a process that isn't our child can't be known.
+func (m *Manager) watchAdopted(info ProcessInfo, rp *runningProc) {
+	defer close(rp.done)
+	ticker := time.NewTicker(adoptPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if sameProcess(info.PID, info.PIDStart) {
+			continue
+		}
+		m.mu.Lock()
+		stopped := rp.stopped
+		delete(m.running, info.ID)
+		m.mu.Unlock()
+
+		now := time.Now().UTC()
+		info.Killed = stopped
+		m.recordLostExit(info, now)
+		if info.OnExit != nil {
+			// Nothing else writes to the log now.
+			if path, err := m.logPath(info); err == nil {
+				if f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0); err == nil {
+					m.runOnExit(info, EventExited, nil, f)
+					f.Close()
+				}
+			}
+		}
+		return
+	}
+}
+
+// recordLostExit marks info as exited at t with an unknown exit code, unless
+// an exit was recorded in the meantime (e.g. by the server that started it).
+func (m *Manager) recordLostExit(info ProcessInfo, t time.Time) {
+	updated, err := m.update(info.ID, func(p *ProcessInfo) {
+		if p.ExitCode == nil && p.ExitedAt == nil {
+			p.ExitedAt = &t
+			p.Killed = info.Killed
+			p.Paused = false
+			p.Warning = nil
+		}
+	})
+	if err == nil {
+		m.publish(EventExited, updated)
+	}
+}
+
+// lastOutput returns when info's log was last written, or now if that can't
+// be determined.
+func (m *Manager) lastOutput(info ProcessInfo) time.Time {
+	if path, err := m.logPath(info); err == nil {
+		if st, err := os.Stat(path); err == nil {
+			return st.ModTime().UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
+// pidAlive reports whether a process with this PID exists.
+func pidAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,175 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// DefaultMemoryAlert is the RSS above which a process raises a high_memory
+// alert unless SetMemoryAlert says otherwise.
+const DefaultMemoryAlert = 2 << 30 // 2 GiB
+
+// maxAlerts bounds ProcessInfo.Alerts; the oldest are dropped first.
+const maxAlerts = 10
+
+// AlertKind says what an Alert is about.
+type AlertKind string
+
+const (
+	// AlertHighMemory is a process group whose RSS crossed the memory alert
+	// threshold.
+	AlertHighMemory AlertKind = "high_memory"
+	// AlertOOMKilled is a process killed by a SIGKILL the Manager didn't
+	// send, which is almost always the kernel's OOM killer.
+	AlertOOMKilled AlertKind = "oom_killed"
+	// AlertLogSpike is a process whose lines per minute jumped to the log
+	// spike factor times its usual rate, such as a retry storm or an error
+	// loop.
+	AlertLogSpike AlertKind = "log_spike"
+	// AlertExitWarning is output that usually comes shortly before a
+	// process dies, such as a port already in use or a JavaScript heap
+	// running out.
+	AlertExitWarning AlertKind = "exit_warning"
+)
+
+// Alert is a resource problem recorded on a process.
+type Alert struct {
+	Kind    AlertKind `json:"kind"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+	// RSSBytes is the last sampled memory use of the process group.
+	RSSBytes       int64 `json:"rss_bytes,omitempty"`
+	ThresholdBytes int64 `json:"threshold_bytes,omitempty"`
+	// LinesPerMin is the output rate over the last minute of a log spike;
+	// UsualLinesPerMin the rate it is compared against.
+	LinesPerMin      int `json:"lines_per_min,omitempty"`
+	UsualLinesPerMin int `json:"usual_lines_per_min,omitempty"`
+	// Reason is what an exit warning expects to kill the process, e.g.
+	// port_in_use; Line is the log line that gave it away.
+	Reason string `json:"reason,omitempty"`
+	Line   string `json:"line,omitempty"`
+}
+
+// ProcessAlert is an Alert together with the process it was recorded on.
+type ProcessAlert struct {
+	Alert
+	ProcessID   string            `json:"process_id"`
+	ProcessName string            `json:"process_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// SetMemoryAlert sets the RSS, in bytes, above which a process raises a
+// high_memory alert. Zero turns the alerts off.
+func (m *Manager) SetMemoryAlert(bytes int64) error {
+	if bytes < 0 {
+		return errors.New("memory alert threshold must not be negative")
+	}
+	m.mu.Lock()
+	m.memoryAlert = bytes
+	m.mu.Unlock()
+	return nil
+}
+
+// Alerts returns the alerts recorded on all processes, newest first.
+func (m *Manager) Alerts() ([]ProcessAlert, error) {
+	infos, err := m.records()
+	if err != nil {
+		return nil, err
+	}
+	alerts := []ProcessAlert{}
+	for _, info := range infos {
+		for _, a := range info.Alerts {
+			alerts = append(alerts, ProcessAlert{Alert: a, ProcessID: info.ID, ProcessName: info.Name, Tags: info.Tags})
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Time.After(alerts[j].Time) })
+	return alerts, nil
+}
+
+// checkMemory raises a high_memory alert when rss crosses the threshold. It
+// fires once per crossing: the process must drop below the threshold again
+// before it can fire another.
+func (m *Manager) checkMemory(id string, rp *runningProc, rss int64) {
+	m.mu.Lock()
+	threshold := m.memoryAlert
+	over := threshold > 0 && rss > threshold
+	fire := over && !rp.memoryAlerted
+	rp.memoryAlerted = over
+	m.mu.Unlock()
+	if !fire {
+		return
+	}
+
+	m.recordAlert(id, Alert{
+		Kind:           AlertHighMemory,
+		Time:           time.Now().UTC(),
+		Message:        fmt.Sprintf("using %s of memory, over the %s alert threshold", formatBytes(rss), formatBytes(threshold)),
+		RSSBytes:       rss,
+		ThresholdBytes: threshold,
+	})
+}
+
+// oomKilled reports whether state is a death by SIGKILL: of the process
+// itself, or of the command a shell ran for it (exit status 128+9).
+func oomKilled(state *os.ProcessState) bool {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() && ws.Signal() == syscall.SIGKILL {
+		return true
+	}
+	return state.ExitCode() == 128+int(syscall.SIGKILL)
+}
+
+// oomAlert describes an OOM kill of a process last seen using rss bytes.
+func oomAlert(rss int64) Alert {
+	msg := "killed by SIGKILL, most likely by the kernel's OOM killer"
+	if rss > 0 {
+		msg += fmt.Sprintf("; last seen using %s", formatBytes(rss))
+	}
+	return Alert{Kind: AlertOOMKilled, Time: time.Now().UTC(), Message: msg, RSSBytes: rss}
+}
+
+// recordAlert adds a to the process id and publishes it.
+func (m *Manager) recordAlert(id string, a Alert) {
+	info, err := m.update(id, func(p *ProcessInfo) { p.Alerts = appendAlert(p.Alerts, a) })
+	if err != nil {
+		return
+	}
+	m.publishEvent(Event{Type: alertEvent(a.Kind), Time: a.Time, Process: m.view(info), Alert: &a})
+}
+
+func appendAlert(alerts []Alert, a Alert) []Alert {
+	alerts = append(alerts, a)
+	if len(alerts) > maxAlerts {
+		alerts = alerts[len(alerts)-maxAlerts:]
+	}
+	return alerts
+}
+
+func alertEvent(kind AlertKind) EventType {
+	switch kind {
+	case AlertOOMKilled:
+		return EventOOMKilled
+	case AlertLogSpike:
+		return EventLogSpike
+	case AlertExitWarning:
+		return EventExitWarning
+	}
+	return EventHighMemory
+}
+
+// formatBytes formats n bytes with a binary unit, e.g. "1.5 GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,99 @@
+package process
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strconv"
+)
+
+// maxAllocatePorts caps StartOptions.AllocatePorts.
+const maxAllocatePorts = 16
+
+// PortRange is an inclusive range of TCP ports.
+type PortRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// DefaultPortRange is where allocated ports come from unless SetPortRange
+// is called. It sits above the usual dev server defaults (3000, 5173, 8080).
+var DefaultPortRange = PortRange{Min: 20000, Max: 29999}
+
+// SetPortRange sets the range StartOptions.AllocatePorts draws from. It must
+// be called before any process is started.
+func (m *Manager) SetPortRange(r PortRange) error {
+	if r.Min < 1 || r.Max > 65535 || r.Min > r.Max {
+		return fmt.Errorf("invalid port range %d-%d", r.Min, r.Max)
+	}
+	m.storeMu.Lock()
+	m.portRange = r
+	m.storeMu.Unlock()
+	return nil
+}
+
+// allocatePorts picks n ports from the configured range that aren't in
+// exclude, declared or detected on a running process, or bound by anything
+// else on the machine. The caller must hold storeMu so that concurrent Starts
+// don't pick the same ports.
+func (m *Manager) allocatePorts(n int, exclude []int) ([]int, error) {
+	views, err := m.List(ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[int]bool)
+	for _, p := range exclude {
+		taken[p] = true
+	}
+	for _, v := range views {
+		if v.Status != StatusRunning && v.Status != StatusPaused {
+			continue
+		}
+		for _, p := range slices.Concat(v.Ports, v.ReportedPorts, v.DetectedPorts) {
+			taken[p] = true
+		}
+	}
+
+	// Start at a random offset so servers restarted in quick succession
+	// don't keep landing on ports their predecessors may still hold.
+	r := m.portRange
+	size := r.Max - r.Min + 1
+	offset := rand.IntN(size)
+	var ports []int
+	for i := 0; i < size && len(ports) < n; i++ {
+		port := r.Min + (offset+i)%size
+		if taken[port] || !portFree(port) {
+			continue
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) < n {
+		return nil, fmt.Errorf("only %d of %d ports free in range %d-%d", len(ports), n, r.Min, r.Max)
+	}
+	return ports, nil
+}
+
+// portFree reports whether port can be bound on all interfaces.
+func portFree(port int) bool {
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// portEnv returns the env vars that expose allocated ports to the child:
+// PORT for the first, then PORT_2, PORT_3 and so on.
+func portEnv(ports []int) []string {
+	env := make([]string, len(ports))
+	for i, p := range ports {
+		key := "PORT"
+		if i > 0 {
+			key += "_" + strconv.Itoa(i+1)
+		}
+		env[i] = key + "=" + strconv.Itoa(p)
+	}
+	return env
+}
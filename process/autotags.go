@@ -0,0 +1,39 @@
+package process
+
+import "maps"
+
+const (
+	// TagBranch and TagWorktree are the tags Start fills in from git.
+	TagBranch   = "branch"
+	TagWorktree = "worktree"
+)
+
+// autoTags returns tags with the branch and worktree tags filled in from
+// the git checkout cwd is in, where they aren't set, and the keys it
+// added. Outside a git repository tags is returned unchanged.
+func autoTags(tags map[string]string, cwd string) (map[string]string, []string) {
+	var added []string
+	set := func(key string, value string, err error) {
+		if err != nil || value == "" {
+			// Not a repository, or git isn't installed.
+			return
+		}
+		if added == nil {
+			tags = maps.Clone(tags)
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+		}
+		tags[key] = value
+		added = append(added, key)
+	}
+	if _, ok := tags[TagBranch]; !ok {
+		branch, err := gitBranch(cwd)
+		set(TagBranch, branch, err)
+	}
+	if _, ok := tags[TagWorktree]; !ok {
+		root, err := git(cwd, "rev-parse", "--show-toplevel")
+		set(TagWorktree, root, err)
+	}
+	return tags, added
+}
@@ -0,0 +1,49 @@
+package process
+
+import (
+	"errors"
+	"sync"
+)
+
+// KillMatching kills every running or paused process whose tags include all
+// of tags, in parallel, and returns their final views. At least one tag is
+// required so an empty selector can't take down everything.
+func (m *Manager) KillMatching(tags map[string]string) ([]ProcessView, error) {
+	if len(tags) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+	views, err := m.List(ListFilter{Tags: tags})
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	for _, v := range views {
+		if v.Status == StatusRunning || v.Status == StatusPaused {
+			targets = append(targets, v.ID)
+		}
+	}
+
+	results := make([]ProcessView, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, id := range targets {
+		wg.Go(func() {
+			view, err := m.Kill(id)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = *view
+		})
+	}
+	wg.Wait()
+
+	killed := results[:0]
+	for i, view := range results {
+		if errs[i] == nil {
+			killed = append(killed, view)
+		}
+	}
+	return killed, errors.Join(errs...)
+}
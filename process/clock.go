@@ -0,0 +1,52 @@
+package process
+
+import "time"
+
+// Clock is a reading of a clock that the system clock being set doesn't
+// move. Readings from the same Boot can be subtracted to get durations that
+// wall-clock timestamps get wrong across clock changes.
+type Clock struct {
+	// Boot identifies the clock: readings with different Boots aren't
+	// comparable.
+	Boot  string `json:"boot"`
+	Nanos int64  `json:"nanos"`
+}
+
+// clockNow reads the clock, or returns nil if it can't be read.
+func clockNow() *Clock {
+	boot, nanos, ok := bootClock()
+	if !ok {
+		return nil
+	}
+	return &Clock{Boot: boot, Nanos: nanos}
+}
+
+// elapsed returns the time from the reading from to to. If the readings
+// aren't comparable, it falls back to the wall-clock times wallFrom and
+// wallTo. Negative durations, which only the fallback can give, are 0.
+func elapsed(from, to *Clock, wallFrom, wallTo time.Time) time.Duration {
+	d := wallTo.Sub(wallFrom)
+	if from != nil && to != nil && from.Boot == to.Boot {
+		d = time.Duration(to.Nanos - from.Nanos)
+	}
+	return max(d, 0)
+}
+
+// setDurations sets v's UptimeSecs and ExitedSecsAgo from its clock
+// readings, or its timestamps for records without them.
+func (v *ProcessView) setDurations() {
+	if v.StartedAt.IsZero() {
+		return
+	}
+	now, wallNow := clockNow(), time.Now()
+	switch v.Status {
+	case StatusRunning, StatusPaused:
+		v.UptimeSecs = int64(elapsed(v.StartedClock, now, v.StartedAt, wallNow).Seconds())
+	default:
+		if v.ExitedAt == nil {
+			return
+		}
+		v.UptimeSecs = int64(elapsed(v.StartedClock, v.ExitedClock, v.StartedAt, *v.ExitedAt).Seconds())
+		v.ExitedSecsAgo = int64(elapsed(v.ExitedClock, now, *v.ExitedAt, wallNow).Seconds())
+	}
+}
@@ -0,0 +1,36 @@
+package process
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// clockBoottime is CLOCK_BOOTTIME, which counts time in suspend, unlike
+// CLOCK_MONOTONIC.
+const clockBoottime = 7
+
+// bootID reads the kernel's ID of the current boot once.
+var bootID = sync.OnceValue(func() string {
+	id, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(id))
+})
+
+// bootClock returns the boot's ID and nanoseconds since boot, suspend
+// included.
+func bootClock() (string, int64, bool) {
+	boot := bootID()
+	if boot == "" {
+		return "", 0, false
+	}
+	var ts syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockBoottime, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return "", 0, false
+	}
+	return boot, ts.Nano(), true
+}
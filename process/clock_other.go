@@ -0,0 +1,22 @@
+//go:build !linux
+
+package process
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// clockStart is when this server started, with Go's monotonic reading, and
+// clockSession tells its readings apart from other servers'.
+var (
+	clockStart   = time.Now()
+	clockSession = "session-" + rand.Text()
+)
+
+// bootClock returns nanoseconds since this server started, by Go's
+// monotonic clock. Readings only compare within one server run, and only
+// count time in suspend where that clock does.
+func bootClock() (string, int64, bool) {
+	return clockSession, int64(time.Since(clockStart)), true
+}
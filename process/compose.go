@@ -0,0 +1,282 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// composeUpTimeout bounds the docker compose up run by StartCompose,
+	// which may pull and build images.
+	composeUpTimeout = 10 * time.Minute
+	// composeTimeout bounds the other docker compose commands.
+	composeTimeout = 30 * time.Second
+	// containerInterval is how often the state of a process's container is
+	// read.
+	containerInterval = 5 * time.Second
+)
+
+// ComposeService identifies the docker compose service a process follows.
+type ComposeService struct {
+	Project string `json:"project"`
+	Service string `json:"service"`
+	// Files are the -f compose files, relative to the process's cwd; empty
+	// means compose's default lookup.
+	Files []string `json:"files,omitempty"`
+}
+
+// ContainerState is the container of a compose service, as reported by
+// docker compose ps, or of a process run in a container.
+type ContainerState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	// Health is the container's health check status, if it has one.
+	Health   string `json:"health,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	// Ports are the host ports published for the service.
+	Ports []int `json:"ports,omitempty"`
+}
+
+// ComposeOptions describes the compose project StartCompose brings up.
+type ComposeOptions struct {
+	// Cwd is the project directory; compose files are looked up in it.
+	Cwd string
+	// Files are compose files to pass with -f; empty means compose's
+	// default lookup (compose.yaml, docker-compose.yml, ...).
+	Files []string
+	// Services limits which services are started; empty means all.
+	Services []string
+	// Tags are added to every process, besides role=SERVICE and
+	// compose=PROJECT.
+	Tags map[string]string
+}
+
+// composeContainer is one entry of docker compose ps --format json.
+type composeContainer struct {
+	Name       string
+	Project    string
+	Service    string
+	State      string
+	Health     string
+	ExitCode   int
+	Publishers []struct {
+		PublishedPort int
+	}
+}
+
+// StartCompose runs docker compose up -d in opts.Cwd, then tracks each
+// service as a process that follows its logs with docker compose logs
+// --follow. The process's status follows the container's, its view carries
+// the container's state, and killing it stops the service's container.
+// Calling it again for services already tracked returns them as they are.
+// Results are in service order.
+func (m *Manager) StartCompose(opts ComposeOptions) ([]StackResult, error) {
+	if opts.Cwd == "" {
+		return nil, errors.New("cwd is required")
+	}
+	cwd, err := m.resolveCwd(opts.Cwd)
+	if err != nil {
+		return nil, err
+	}
+	if cwd, err = checkCwd(cwd, false); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, errors.New("docker is not installed or not on PATH")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), composeUpTimeout)
+	defer cancel()
+	up := slices.Concat([]string{"up", "-d"}, opts.Services)
+	if _, err := runCompose(ctx, cwd, opts.Files, up...); err != nil {
+		return nil, err
+	}
+	containers, err := composePS(cwd, opts.Files, opts.Services...)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, errors.New("docker compose up started no containers")
+	}
+	// ps sorts by container name; keep the first container of each service.
+	services := make(map[string]composeContainer)
+	var order []string
+	for _, c := range containers {
+		if _, ok := services[c.Service]; !ok {
+			services[c.Service] = c
+			order = append(order, c.Service)
+		}
+	}
+
+	results := make([]StackResult, len(order))
+	for i, name := range order {
+		svc := ComposeService{Project: services[name].Project, Service: name, Files: opts.Files}
+		tags := maps.Clone(opts.Tags)
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags["role"] = name
+		tags["compose"] = svc.Project
+
+		results[i].Name = name
+		view, err := m.Start(StartOptions{
+			Command: svc.followCommand(),
+			Cwd:     cwd,
+			Tags:    tags,
+			compose: &svc,
+		})
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Process = view
+	}
+	return results, nil
+}
+
+// followCommand is the shell command a compose service's process runs: it
+// brings the service up, which is a no-op if it's running, so that Restart
+// starts it again, and then follows its logs until the container stops.
+func (s ComposeService) followCommand() string {
+	compose := "docker compose"
+	for _, f := range s.Files {
+		compose += " -f " + shellQuote(f)
+	}
+	svc := shellQuote(s.Service)
+	return fmt.Sprintf("%s up -d %s && exec %s logs --follow --no-log-prefix %s", compose, svc, compose, svc)
+}
+
+// watchContainer records the state of info's container, of its compose
+// service or its own, on rp until the process exits for good.
+func (m *Manager) watchContainer(info ProcessInfo, rp *runningProc) {
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+	for {
+		select {
+		case <-rp.done:
+			return
+		case <-timer.C:
+		}
+
+		state, err := readContainer(info)
+		if err == nil {
+			m.mu.Lock()
+			rp.container = state
+			m.mu.Unlock()
+		}
+		timer.Reset(containerInterval)
+	}
+}
+
+// readContainer returns the state of info's container, or nil if it has
+// none.
+func readContainer(info ProcessInfo) (*ContainerState, error) {
+	if info.InContainer != nil {
+		return inspectContainer(info)
+	}
+	containers, err := composePS(info.Cwd, info.Compose.Files, info.Compose.Service)
+	if err != nil || len(containers) == 0 {
+		return nil, err
+	}
+	return containers[0].state(), nil
+}
+
+// container returns the last read state of a process's container, or nil
+// if it isn't running under this Manager.
+func (m *Manager) container(id string) *ContainerState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rp, ok := m.running[id]; ok {
+		return rp.container
+	}
+	return nil
+}
+
+// stopComposeService stops the container of info's compose service, giving
+// it grace to exit. Errors are ignored: the process following it is killed
+// either way.
+func stopComposeService(info ProcessInfo, grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), composeTimeout+grace)
+	defer cancel()
+	runCompose(ctx, info.Cwd, info.Compose.Files, "stop", "--timeout", strconv.Itoa(int(grace.Seconds())), info.Compose.Service)
+}
+
+func (c composeContainer) state() *ContainerState {
+	s := &ContainerState{Name: c.Name, State: c.State, Health: c.Health, ExitCode: c.ExitCode}
+	for _, p := range c.Publishers {
+		if p.PublishedPort > 0 && !slices.Contains(s.Ports, p.PublishedPort) {
+			s.Ports = append(s.Ports, p.PublishedPort)
+		}
+	}
+	slices.Sort(s.Ports)
+	return s
+}
+
+// composePS lists the containers of services, or of every service, in the
+// compose project of cwd and files, including stopped ones.
+func composePS(cwd string, files []string, services ...string) ([]composeContainer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), composeTimeout)
+	defer cancel()
+	out, err := runCompose(ctx, cwd, files, slices.Concat([]string{"ps", "--all", "--format", "json"}, services)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseComposePS(out)
+}
+
+// parseComposePS decodes docker compose ps --format json output, which is a
+// JSON array in older versions of compose and one object per line since
+// 2.21.
+func parseComposePS(out []byte) ([]composeContainer, error) {
+	out = bytes.TrimSpace(out)
+	var containers []composeContainer
+	if bytes.HasPrefix(out, []byte("[")) {
+		if err := json.Unmarshal(out, &containers); err != nil {
+			return nil, fmt.Errorf("decoding docker compose ps: %w", err)
+		}
+		return containers, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var c composeContainer
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("decoding docker compose ps: %w", err)
+		}
+		containers = append(containers, c)
+	}
+	return containers, nil
+}
+
+// runCompose runs docker compose with files and args in cwd and returns its
+// stdout. A failure's error includes the end of stderr.
+func runCompose(ctx context.Context, cwd string, files []string, args ...string) ([]byte, error) {
+	argv := []string{"compose"}
+	for _, f := range files {
+		argv = append(argv, "-f", f)
+	}
+	cmd := exec.CommandContext(ctx, "docker", append(argv, args...)...)
+	cmd.Dir = cwd
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 1024 {
+			msg = "…" + msg[len(msg)-1024:]
+		}
+		if msg == "" {
+			return nil, fmt.Errorf("docker compose %s: %w", args[0], err)
+		}
+		return nil, fmt.Errorf("docker compose %s: %w: %s", args[0], err, msg)
+	}
+	return out, nil
+}
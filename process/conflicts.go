@@ -0,0 +1,90 @@
+package process
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PortConflict describes a declared port that another process already uses.
+// ProcessID is empty when the port is held by a process this server doesn't
+// track; PID and Command then identify it, if the OS lets us see it.
+type PortConflict struct {
+	Port      int               `json:"port"`
+	ProcessID string            `json:"process_id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+
+	PID     int    `json:"pid,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// PortConflictError is returned by Start when declared ports are in use.
+type PortConflictError struct {
+	Conflicts []PortConflict `json:"conflicts"`
+}
+
+func (e *PortConflictError) Error() string {
+	parts := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		var owner string
+		switch {
+		case c.ProcessID != "":
+			owner = "process " + c.ProcessID
+			if c.Name != "" {
+				owner += " (" + c.Name + ")"
+			}
+		case c.PID != 0:
+			owner = fmt.Sprintf("untracked process %d", c.PID)
+			if c.Command != "" {
+				owner += " (" + c.Command + ")"
+			}
+		default:
+			owner = "an untracked process"
+		}
+		parts[i] = fmt.Sprintf("port %d is used by %s", c.Port, owner)
+	}
+	return "port conflict: " + strings.Join(parts, "; ")
+}
+
+// checkPorts returns a *PortConflictError if any of ports is declared by, or
+// detected on, another running or paused process, or is being listened on by
+// a process this server doesn't track.
+func (m *Manager) checkPorts(ports []int) error {
+	if len(ports) == 0 {
+		return nil
+	}
+	live, err := m.liveViews()
+	if err != nil {
+		return err
+	}
+
+	var conflicts []PortConflict
+	for _, port := range ports {
+		i := slices.IndexFunc(live, func(v ProcessView) bool {
+			return slices.Contains(v.Ports, port) || slices.Contains(v.ReportedPorts, port) || slices.Contains(v.DetectedPorts, port)
+		})
+		if i >= 0 {
+			v := live[i]
+			conflicts = append(conflicts, PortConflict{Port: port, ProcessID: v.ID, Name: v.Name, Tags: v.Tags})
+			continue
+		}
+
+		listening, listeners, err := portListeners(port)
+		if err != nil || !listening {
+			continue
+		}
+		c := PortConflict{Port: port}
+		if v, l := trackedListener(listeners, live); v != nil {
+			c.ProcessID, c.Name, c.Tags = v.ID, v.Name, v.Tags
+			c.PID, c.Command = l.PID, l.Command
+		} else if len(listeners) > 0 {
+			c.PID, c.Command = listeners[0].PID, listeners[0].Command
+		}
+		conflicts = append(conflicts, c)
+	}
+	if len(conflicts) > 0 {
+		return &PortConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
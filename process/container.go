@@ -0,0 +1,186 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Container runtimes a ContainerSpec can use.
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// ContainerSpec runs a process's command in a container instead of on the
+// host. The runtime's CLI is the tracked process, running in the
+// foreground, so the container's output is the process's log, stdin reaches
+// the container and the process lives as long as the container.
+type ContainerSpec struct {
+	Image string `json:"image"`
+	// Runtime is RuntimeDocker or RuntimePodman; empty means docker if it is
+	// installed, else podman. Start records the one it picked.
+	Runtime string `json:"runtime,omitempty"`
+	// Volumes are mounts as "SOURCE:TARGET[:OPTIONS]". A source starting
+	// with "." is a path relative to the process's cwd; other sources
+	// without a "/" are named volumes.
+	Volumes []string `json:"volumes,omitempty"`
+	// Ports publish container ports as "HOST:CONTAINER", or "PORT" for the
+	// same port on both. The host ports are added to the process's ports.
+	Ports []string `json:"ports,omitempty"`
+	// Workdir is the working directory inside the container; empty keeps
+	// the image's.
+	Workdir string `json:"workdir,omitempty"`
+}
+
+// validate checks s and returns a copy with the runtime picked and relative
+// volume sources made absolute against cwd.
+func (s ContainerSpec) validate(cwd string) (*ContainerSpec, error) {
+	if s.Image == "" {
+		return nil, errors.New("container image is required")
+	}
+	switch s.Runtime {
+	case "":
+		for _, rt := range []string{RuntimeDocker, RuntimePodman} {
+			if _, err := exec.LookPath(rt); err == nil {
+				s.Runtime = rt
+				break
+			}
+		}
+		if s.Runtime == "" {
+			return nil, errors.New("no container runtime found: install docker or podman")
+		}
+	case RuntimeDocker, RuntimePodman:
+		if _, err := exec.LookPath(s.Runtime); err != nil {
+			return nil, fmt.Errorf("%s is not installed or not on PATH", s.Runtime)
+		}
+	default:
+		return nil, fmt.Errorf("unknown container runtime %q", s.Runtime)
+	}
+	if _, err := s.hostPorts(); err != nil {
+		return nil, err
+	}
+
+	volumes := make([]string, len(s.Volumes))
+	for i, v := range s.Volumes {
+		source, rest, ok := strings.Cut(v, ":")
+		if !ok || source == "" || rest == "" {
+			return nil, fmt.Errorf("invalid volume %q: expected SOURCE:TARGET", v)
+		}
+		if strings.HasPrefix(source, ".") {
+			source = filepath.Join(cwd, source)
+		}
+		volumes[i] = source + ":" + rest
+	}
+	s.Volumes = volumes
+	return &s, nil
+}
+
+// hostPorts returns the host side of s.Ports.
+func (s ContainerSpec) hostPorts() ([]int, error) {
+	var ports []int
+	for _, p := range s.Ports {
+		host, container, ok := strings.Cut(p, ":")
+		if !ok {
+			container = host
+		}
+		h, err := strconv.Atoi(host)
+		if err != nil || h < 1 || h > 65535 {
+			return nil, fmt.Errorf("invalid port mapping %q: expected HOST:CONTAINER", p)
+		}
+		if c, err := strconv.Atoi(container); err != nil || c < 1 || c > 65535 {
+			return nil, fmt.Errorf("invalid port mapping %q: expected HOST:CONTAINER", p)
+		}
+		ports = append(ports, h)
+	}
+	return ports, nil
+}
+
+// image returns the image of s, or "" for a process run on the host.
+func (s *ContainerSpec) image() string {
+	if s == nil {
+		return ""
+	}
+	return s.Image
+}
+
+// containerName is the name of the container a process with id runs in.
+func containerName(id string) string {
+	return "thought-process-" + id
+}
+
+// runArgs returns the runtime's arguments to run info's container with
+// command, or the image's own command if command is nil. envKeys are the
+// variables to pass through from the runtime's environment.
+func (s ContainerSpec) runArgs(info *ProcessInfo, command []string, envKeys []string) []string {
+	args := []string{"run", "--rm", "--name", containerName(info.ID), "--interactive"}
+	if info.PTY {
+		args = append(args, "--tty")
+	}
+	if info.StopSignal != "" {
+		args = append(args, "--stop-signal", info.StopSignal)
+	}
+	for _, k := range envKeys {
+		// Without a value, the runtime copies it from its own environment,
+		// so secrets stay out of the command line.
+		args = append(args, "--env", k)
+	}
+	for _, v := range s.Volumes {
+		args = append(args, "--volume", v)
+	}
+	for _, p := range s.Ports {
+		if !strings.Contains(p, ":") {
+			p += ":" + p
+		}
+		args = append(args, "--publish", p)
+	}
+	if s.Workdir != "" {
+		args = append(args, "--workdir", s.Workdir)
+	}
+	args = append(args, s.Image)
+	return append(args, command...)
+}
+
+// stopContainer stops the container info runs in, which sends its stop
+// signal, giving it grace to exit, as Kill does for processes. Errors are
+// ignored: the runtime's CLI is signalled either way.
+func stopContainer(info ProcessInfo, grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), composeTimeout+grace)
+	defer cancel()
+	exec.CommandContext(ctx, info.InContainer.Runtime, "stop", "--time", strconv.Itoa(int(grace.Seconds())), containerName(info.ID)).Run()
+}
+
+// inspectContainer reads the state of the container info runs in.
+func inspectContainer(info ProcessInfo) (*ContainerState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), composeTimeout)
+	defer cancel()
+	name := containerName(info.ID)
+	out, err := exec.CommandContext(ctx, info.InContainer.Runtime, "inspect", "--format", "{{json .State}}", name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("inspecting container: %w", err)
+	}
+	var state struct {
+		Status   string
+		ExitCode int
+		Health   *struct {
+			Status string
+		}
+	}
+	if err := json.Unmarshal(out, &state); err != nil {
+		return nil, fmt.Errorf("decoding container state: %w", err)
+	}
+	s := &ContainerState{Name: name, State: state.Status, ExitCode: state.ExitCode}
+	if state.Health != nil {
+		s.Health = state.Health.Status
+	}
+	s.Ports, _ = info.InContainer.hostPorts()
+	slices.Sort(s.Ports)
+	return s, nil
+}
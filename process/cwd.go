@@ -0,0 +1,56 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CwdError is returned by Start when the working directory can't be used.
+type CwdError struct {
+	Cwd string `json:"cwd"`
+	// Reason is "not_found", "not_a_directory" or "inaccessible".
+	Reason string `json:"reason"`
+	Err    error  `json:"-"`
+}
+
+func (e *CwdError) Error() string {
+	switch e.Reason {
+	case "not_found":
+		return fmt.Sprintf("working directory %s does not exist (pass create_cwd to create it)", e.Cwd)
+	case "not_a_directory":
+		return fmt.Sprintf("working directory %s is not a directory", e.Cwd)
+	}
+	return fmt.Sprintf("working directory %s: %v", e.Cwd, e.Err)
+}
+
+func (e *CwdError) Unwrap() error { return e.Err }
+
+// checkCwd makes cwd absolute and checks that it is a directory, creating it
+// first if create is set. An empty cwd, meaning the server's own working
+// directory, is returned unchanged.
+func checkCwd(cwd string, create bool) (string, error) {
+	if cwd == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(cwd)
+	if err != nil {
+		return "", &CwdError{Cwd: cwd, Reason: "inaccessible", Err: err}
+	}
+	if create {
+		if err := os.MkdirAll(abs, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+			return "", &CwdError{Cwd: abs, Reason: "inaccessible", Err: err}
+		}
+	}
+	st, err := os.Stat(abs)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return "", &CwdError{Cwd: abs, Reason: "not_found", Err: err}
+	case err != nil:
+		return "", &CwdError{Cwd: abs, Reason: "inaccessible", Err: err}
+	case !st.IsDir():
+		return "", &CwdError{Cwd: abs, Reason: "not_a_directory"}
+	}
+	return abs, nil
+}
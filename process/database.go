@@ -0,0 +1,119 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+)
+
+// Database describes the database served by a process tagged role=db, and
+// the commands that manage its schema.
+type Database struct {
+	// URL is the connection string, passed to migration and reset tasks as
+	// DATABASE_URL with placeholders expanded for the database process. It
+	// may be a secret reference. Views show it with any
+	// password redacted.
+	URL string `json:"url"`
+	// MigrateCommand and ResetCommand are shell commands run by
+	// RunMigrations and ResetDatabase.
+	MigrateCommand string `json:"migrate_command,omitempty"`
+	ResetCommand   string `json:"reset_command,omitempty"`
+	// Cwd is where the commands run, typically the app's repo; the database
+	// process's cwd if empty.
+	Cwd string `json:"cwd,omitempty"`
+}
+
+// Roles of the tasks started by RunMigrations and ResetDatabase, set as
+// their role tag.
+const (
+	RoleMigrations = "migrations"
+	RoleDBReset    = "db-reset"
+)
+
+var (
+	// urlPassword and dsnPassword match the password in URL and key=value
+	// connection strings. URLs aren't parsed, since they may still contain
+	// placeholders.
+	urlPassword = regexp.MustCompile(`(://[^:/@\s]*:)[^@\s]*@`)
+	dsnPassword = regexp.MustCompile(`(?i)\b(password|pwd)=('[^']*'|[^\s;]*)`)
+)
+
+// redactDSN hides the password in a URL or key=value connection string.
+func redactDSN(dsn string) string {
+	dsn = urlPassword.ReplaceAllString(dsn, "${1}xxxxx@")
+	return dsnPassword.ReplaceAllString(dsn, "${1}=xxxxx")
+}
+
+// redacted returns a copy of d with its URL redacted.
+func (d *Database) redacted() *Database {
+	if d == nil {
+		return nil
+	}
+	r := *d
+	r.URL = redactDSN(d.URL)
+	return &r
+}
+
+func (d *Database) validate(tags map[string]string) error {
+	if tags["role"] != "db" {
+		return errors.New("database can only be set on a process tagged role=db")
+	}
+	if d.URL == "" {
+		return errors.New("database url is required")
+	}
+	return nil
+}
+
+// RunMigrations starts the MigrateCommand of a role=db process's Database as
+// a tracked one-off task with DATABASE_URL set, and returns the task.
+func (m *Manager) RunMigrations(processID string) (*ProcessView, error) {
+	return m.runDatabaseTask(processID, RoleMigrations, func(d *Database) string { return d.MigrateCommand })
+}
+
+// ResetDatabase starts the ResetCommand of a role=db process's Database as a
+// tracked one-off task with DATABASE_URL set, and returns the task.
+func (m *Manager) ResetDatabase(processID string) (*ProcessView, error) {
+	return m.runDatabaseTask(processID, RoleDBReset, func(d *Database) string { return d.ResetCommand })
+}
+
+// runDatabaseTask starts command(db) for the database process processID. The
+// task gets the database's tags, so it is tied to the same branch, with role
+// set to role and database set to the database process's ID.
+func (m *Manager) runDatabaseTask(processID, role string, command func(*Database) string) (*ProcessView, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	db := info.Database
+	if db == nil {
+		return nil, fmt.Errorf("process %s has no database configured; start it with database", info.ID)
+	}
+	cmd := command(db)
+	if cmd == "" {
+		return nil, fmt.Errorf("process %s has no %s command configured", info.ID, role)
+	}
+	if status := m.status(info); status != StatusRunning {
+		return nil, fmt.Errorf("database process %s is %s", info.ID, status)
+	}
+
+	// Placeholders refer to the database process, not the task.
+	dbURL, err := newExpander(&info).expand(db.URL)
+	if err != nil {
+		return nil, err
+	}
+	cwd := db.Cwd
+	if cwd == "" {
+		cwd = info.Cwd
+	}
+	tags := maps.Clone(info.Tags)
+	tags["role"] = role
+	tags["database"] = info.ID
+	return m.Start(StartOptions{
+		Command:    cmd,
+		Cwd:        cwd,
+		Env:        map[string]string{"DATABASE_URL": dbURL},
+		Tags:       tags,
+		databaseID: info.ID,
+	})
+}
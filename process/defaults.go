@@ -0,0 +1,50 @@
+package process
+
+import (
+	"errors"
+	"maps"
+)
+
+// Defaults are tags and env vars every process gets unless it is started
+// with its own, e.g. a machine tag that tells apart processes of several
+// machines sharing a store.
+type Defaults struct {
+	Tags map[string]string `json:"tags,omitempty"`
+	// Env values may use placeholders such as ${BRANCH}, expanded at spawn
+	// like the process's own.
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Validate checks the tag keys and variable names.
+func (d Defaults) Validate() error {
+	for k := range d.Tags {
+		if k == "" {
+			return errors.New("default tag keys must not be empty")
+		}
+	}
+	for k := range d.Env {
+		if err := validateEnvName(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetDefaults sets the tags and env vars merged into every Start.
+func (m *Manager) SetDefaults(d Defaults) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	m.defaults.Store(&d)
+	return nil
+}
+
+// withDefaults returns own with the entries of defaults it doesn't have.
+func withDefaults(defaults, own map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return own
+	}
+	merged := maps.Clone(defaults)
+	maps.Copy(merged, own)
+	return merged
+}
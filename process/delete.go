@@ -0,0 +1,51 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStillRunning is returned by Delete for a process that hasn't exited,
+// unless forced.
+var ErrStillRunning = errors.New("process is still running")
+
+// Delete removes a process's record, error fingerprints and log, and
+// publishes a deleted event. A running or paused process, or one its
+// restart policy is about to relaunch, is refused with ErrStillRunning
+// unless force is set, in which case it is killed first. The returned view
+// is the process as it was last recorded.
+func (m *Manager) Delete(processID string, force bool) (*ProcessView, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	rp := m.running[info.ID]
+	m.mu.Unlock()
+	status := m.status(info)
+	if rp != nil || status == StatusRunning || status == StatusPaused || status == StatusUnknown {
+		if !force {
+			return nil, fmt.Errorf("%w: %s is %s; kill it first or pass force", ErrStillRunning, info.ID, status)
+		}
+		if _, err := m.Kill(info.ID); err != nil {
+			return nil, err
+		}
+		if rp != nil {
+			// Let the wait loop, and any on_exit hook, finish with the
+			// record before it goes.
+			<-rp.done
+		}
+		if info, err = m.load(info.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := m.deleteRecord(info); err != nil {
+		return nil, err
+	}
+	view := m.view(info)
+	m.publishEvent(Event{Type: EventDeleted, Time: time.Now().UTC(), Process: view})
+	return &view, nil
+}
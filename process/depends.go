@@ -0,0 +1,91 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// defaultDependsTimeout is how long Start waits for dependencies when
+// StartOptions.DependsTimeoutSecs is unset.
+const defaultDependsTimeout = 60 * time.Second
+
+// waitForDependencies blocks until every process in deps is running and,
+// if it has a health check, healthy. It fails as soon as a dependency is
+// unknown or has stopped without a restart pending, or once timeout passes.
+func (m *Manager) waitForDependencies(deps []string, timeout time.Duration) error {
+	if len(deps) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var waiting []string
+		for _, dep := range deps {
+			info, err := m.lookup(dep)
+			if err != nil {
+				return fmt.Errorf("dependency %q: %w", dep, err)
+			}
+			view := m.view(info)
+			switch view.Status {
+			case StatusRunning:
+				if view.HealthCheck != nil && view.Health != HealthHealthy {
+					waiting = append(waiting, fmt.Sprintf("%s (%s)", dep, view.Health))
+				}
+			case StatusExited, StatusFailed, StatusTimedOut, StatusCrashLooping:
+				// An exited process still in m.running is about to be
+				// relaunched by its restart policy.
+				m.mu.Lock()
+				_, restarting := m.running[info.ID]
+				m.mu.Unlock()
+				if !restarting || view.Status == StatusCrashLooping {
+					return fmt.Errorf("dependency %q is %s", dep, view.Status)
+				}
+				waiting = append(waiting, fmt.Sprintf("%s (restarting)", dep))
+			default:
+				waiting = append(waiting, fmt.Sprintf("%s (%s)", dep, view.Status))
+			}
+		}
+		if len(waiting) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s waiting for dependencies: %v", timeout, waiting)
+		case <-ticker.C:
+		}
+	}
+}
+
+// dependents returns the names, or IDs of unnamed processes, of the
+// running and paused processes that declared a dependency on info.
+func (m *Manager) dependents(info ProcessInfo) []string {
+	infos, err := m.records()
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, other := range infos {
+		if other.ID == info.ID || len(other.DependsOn) == 0 {
+			continue
+		}
+		if !slices.Contains(other.DependsOn, info.ID) && (info.Name == "" || !slices.Contains(other.DependsOn, info.Name)) {
+			continue
+		}
+		if st := m.status(other); st != StatusRunning && st != StatusPaused {
+			continue
+		}
+		if other.Name != "" {
+			out = append(out, other.Name)
+		} else {
+			out = append(out, other.ID)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,90 @@
+package process
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// desktopNotifyTimeout bounds each notification command.
+const desktopNotifyTimeout = 10 * time.Second
+
+// SetDesktopNotifications turns desktop notifications of failed processes
+// on or off; see RunDesktopNotifications.
+func (m *Manager) SetDesktopNotifications(on bool) {
+	m.desktopNotify.Store(on)
+}
+
+// RunDesktopNotifications shows a native desktop notification (osascript
+// on macOS, notify-send on Linux) for each crashed, crash_looping and
+// timed_out event while they are turned on, until ctx is done. Commands
+// that fail are passed to onError.
+func (m *Manager) RunDesktopNotifications(ctx context.Context, onError func(error)) error {
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-events:
+			if !m.desktopNotify.Load() {
+				continue
+			}
+			title, body, ok := desktopNotification(e)
+			if !ok {
+				continue
+			}
+			go func() {
+				ctx, cancel := context.WithTimeout(ctx, desktopNotifyTimeout)
+				defer cancel()
+				cmd, err := desktopNotifyCommand(ctx, title, body)
+				if err == nil {
+					var out []byte
+					if out, err = cmd.CombinedOutput(); err != nil && len(out) > 0 {
+						err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+					}
+				}
+				if err != nil {
+					onError(fmt.Errorf("desktop notification for %s: %w", e.Process.ID, err))
+				}
+			}()
+		}
+	}
+}
+
+// desktopNotification returns the title and body of the notification for
+// e, or false if e isn't a failure.
+func desktopNotification(e Event) (title, body string, ok bool) {
+	v := e.Process
+	var what string
+	switch e.Type {
+	case EventCrashed:
+		what = "crashed"
+	case EventCrashLooping:
+		what = "is crash looping"
+	case EventTimedOut:
+		what = "timed out"
+	default:
+		return "", "", false
+	}
+
+	var details []string
+	if v.ExitCode != nil {
+		details = append(details, fmt.Sprintf("exit code %d", *v.ExitCode))
+	}
+	if e.Type == EventCrashLooping {
+		details = append(details, fmt.Sprintf("%d restarts", v.Restarts))
+	}
+	if branch := v.Tags[TagBranch]; branch != "" {
+		details = append(details, "branch "+branch)
+	}
+	details = append(details, v.Command)
+	return notifyLabel(v) + " " + what, strings.Join(details, " · "), true
+}
+
+// notifyLabel is how notifications name v: by name, else role, else ID.
+func notifyLabel(v ProcessView) string {
+	return cmp.Or(v.Name, v.Tags["role"], v.ID)
+}
@@ -0,0 +1,19 @@
+package process
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// desktopNotifyCommand returns an osascript command that shows a
+// notification.
+func desktopNotifyCommand(ctx context.Context, title, body string) (*exec.Cmd, error) {
+	script := "display notification " + appleScriptString(body) + " with title " + appleScriptString("thought-process") + " subtitle " + appleScriptString(title)
+	return exec.CommandContext(ctx, "osascript", "-e", script), nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
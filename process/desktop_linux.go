@@ -0,0 +1,12 @@
+package process
+
+import (
+	"context"
+	"os/exec"
+)
+
+// desktopNotifyCommand returns a notify-send command that shows a
+// notification.
+func desktopNotifyCommand(ctx context.Context, title, body string) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "notify-send", "--app-name=thought-process", "--urgency=critical", "--", title, body), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package process
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+func desktopNotifyCommand(ctx context.Context, title, body string) (*exec.Cmd, error) {
+	return nil, errors.New("desktop notifications are not supported on this platform")
+}
@@ -0,0 +1,92 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveEnvFiles makes the dotenv file paths in files absolute, relative to
+// cwd, and checks that they exist.
+func resolveEnvFiles(files []string, cwd string) ([]string, error) {
+	var resolved []string
+	for _, f := range files {
+		if f == "" {
+			return nil, fmt.Errorf("empty env file path")
+		}
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(cwd, f)
+		}
+		if _, err := os.Stat(f); err != nil {
+			return nil, fmt.Errorf("env file: %w", err)
+		}
+		resolved = append(resolved, f)
+	}
+	return resolved, nil
+}
+
+// loadEnvFiles reads the dotenv files in order, later files overriding
+// earlier ones, and returns env on top of the result.
+func loadEnvFiles(files []string, env map[string]string) (map[string]string, error) {
+	if len(files) == 0 {
+		return env, nil
+	}
+	out := make(map[string]string)
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading env file: %w", err)
+		}
+		vars, err := parseDotenv(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", f, err)
+		}
+		maps.Copy(out, vars)
+	}
+	maps.Copy(out, env)
+	return out, nil
+}
+
+// parseDotenv parses KEY=VALUE lines. Blank lines and lines starting with #
+// are skipped, a leading "export " is ignored, and values may be single
+// quoted (taken literally) or double quoted (with \n, \t, \" and \\
+// escapes). Unquoted values end at a " #" comment.
+func parseDotenv(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || validateEnvName(key) != nil || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && strings.LastIndexByte(value, '\'') > 0:
+			value = value[1:strings.LastIndexByte(value, '\'')]
+		case len(value) >= 2 && value[0] == '"' && strings.LastIndexByte(value, '"') > 0:
+			value = unescapeDotenv(value[1:strings.LastIndexByte(value, '"')])
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
+
+var dotenvEscapes = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+
+func unescapeDotenv(s string) string {
+	return dotenvEscapes.Replace(s)
+}
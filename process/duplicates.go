@@ -0,0 +1,27 @@
+package process
+
+import (
+	"maps"
+	"slices"
+)
+
+// findDuplicate returns a running or paused process started with the same
+// command, args, working directory, tags and container image as opts, whose
+// cwd has already been resolved to cwd.
+func (m *Manager) findDuplicate(opts StartOptions, cwd string) (ProcessInfo, bool, error) {
+	infos, err := m.records()
+	if err != nil {
+		return ProcessInfo{}, false, err
+	}
+	for _, info := range infos {
+		if info.Command != opts.Command || !slices.Equal(info.Args, opts.Args) ||
+			info.Cwd != cwd || !maps.Equal(info.Tags, opts.Tags) ||
+			info.InContainer.image() != opts.InContainer.image() {
+			continue
+		}
+		if st := m.status(info); st == StatusRunning || st == StatusPaused {
+			return info, true, nil
+		}
+	}
+	return ProcessInfo{}, false, nil
+}
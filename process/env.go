@@ -0,0 +1,151 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// EnvChange is a change to a process's environment that takes effect when
+// the process is next started again.
+type EnvChange struct {
+	Set   map[string]string `json:"set,omitempty"`
+	Unset []string          `json:"unset,omitempty"`
+}
+
+// apply returns env with c applied. A nil change returns env unchanged.
+func (c *EnvChange) apply(env map[string]string) map[string]string {
+	if c == nil {
+		return env
+	}
+	out := maps.Clone(env)
+	if out == nil {
+		out = make(map[string]string)
+	}
+	for _, k := range c.Unset {
+		delete(out, k)
+	}
+	maps.Copy(out, c.Set)
+	return out
+}
+
+// merge returns c followed by setting set and unsetting unset.
+func (c *EnvChange) merge(set map[string]string, unset []string) *EnvChange {
+	merged := &EnvChange{Set: make(map[string]string)}
+	if c != nil {
+		maps.Copy(merged.Set, c.Set)
+		merged.Unset = slices.Clone(c.Unset)
+	}
+	for _, k := range unset {
+		delete(merged.Set, k)
+		if !slices.Contains(merged.Unset, k) {
+			merged.Unset = append(merged.Unset, k)
+		}
+	}
+	for k, v := range set {
+		merged.Unset = slices.DeleteFunc(merged.Unset, func(u string) bool { return u == k })
+		merged.Set[k] = v
+	}
+	return merged
+}
+
+// UpdateEnv records a change to the environment of a running or paused
+// process: set adds or replaces variables, unset removes them. The change is
+// kept as PendingEnv until the process is next started again — by Restart,
+// RestartMatching or its restart policy — or, if restart is set, applied
+// right away by restarting the process, whose new view is returned then.
+func (m *Manager) UpdateEnv(processID string, set map[string]string, unset []string, restart bool) (*ProcessView, error) {
+	if len(set) == 0 && len(unset) == 0 {
+		return nil, errors.New("nothing to change: give variables to set or unset")
+	}
+	for k := range set {
+		if err := validateEnvName(k); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range unset {
+		if err := validateEnvName(k); err != nil {
+			return nil, err
+		}
+		if _, ok := set[k]; ok {
+			return nil, fmt.Errorf("%s is both set and unset", k)
+		}
+	}
+
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	if status := m.status(info); status != StatusRunning && status != StatusPaused {
+		return nil, fmt.Errorf("process %s is %s; start it again with the new env instead", info.ID, status)
+	}
+	info, err = m.update(info.ID, func(p *ProcessInfo) {
+		p.PendingEnv = p.PendingEnv.merge(set, unset)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("persisting env change: %w", err)
+	}
+	if restart {
+		return m.Restart(info.ID)
+	}
+	view := m.view(info)
+	return &view, nil
+}
+
+func validateEnvName(name string) error {
+	if name == "" || strings.ContainsAny(name, "=\x00") {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return nil
+}
+
+// cleanEnvVars are the variables a CleanEnv process still gets from the
+// server's environment: enough to find programs and a home directory.
+var cleanEnvVars = []string{"PATH", "HOME", "USER", "SHELL", "TMPDIR", "LANG"}
+
+// cleanEnv returns cleanEnvVars as KEY=VALUE pairs, skipping unset ones.
+func cleanEnv() []string {
+	var env []string
+	for _, k := range cleanEnvVars {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+// identityEnv returns the variables that tell a process its own managed
+// identity: THOUGHT_PROCESS_ID, THOUGHT_PROCESS_TOKEN and
+// THOUGHT_PROCESS_CONTROL_SOCKET to Report with, and, for each tag,
+// THOUGHT_PROCESS_TAG_KEY with the key upper-cased and anything but
+// letters, digits and underscores replaced by underscores.
+func (m *Manager) identityEnv(info *ProcessInfo) []string {
+	env := []string{"THOUGHT_PROCESS_ID=" + info.ID}
+	if info.ReportToken != "" {
+		env = append(env, "THOUGHT_PROCESS_TOKEN="+info.ReportToken)
+	}
+	if m.controlSocket != "" {
+		env = append(env, "THOUGHT_PROCESS_CONTROL_SOCKET="+m.controlSocket)
+	}
+	for _, k := range slices.Sorted(maps.Keys(info.Tags)) {
+		env = append(env, "THOUGHT_PROCESS_TAG_"+tagEnvName(k)+"="+info.Tags[k])
+	}
+	return env
+}
+
+// tagEnvName turns a tag key into the end of a variable name.
+func tagEnvName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
@@ -0,0 +1,196 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envExportRefresh is how often RunEnvExport rewrites the files even without
+// events, to pick up detected ports and processes of other servers.
+const envExportRefresh = 10 * time.Second
+
+var (
+	unsafeFileChar = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+	unsafeVarChar  = regexp.MustCompile(`[^A-Z0-9]+`)
+)
+
+// EnvExport is the JSON form of a branch's export file.
+type EnvExport struct {
+	Branch    string             `json:"branch"`
+	Processes []EnvExportProcess `json:"processes"`
+	Env       map[string]string  `json:"env"`
+}
+
+// EnvExportProcess is a running process in an EnvExport.
+type EnvExportProcess struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+	// Prefix is the start of the process's variables in Env.
+	Prefix string   `json:"prefix"`
+	Ports  []int    `json:"ports,omitempty"`
+	URLs   []string `json:"urls,omitempty"`
+}
+
+// RunEnvExport keeps dir/BRANCH.env and dir/BRANCH.json up to date with the
+// ports and URLs of the running processes tagged with each branch, until ctx
+// is done. The .env file can be sourced by a shell. Files of branches with
+// no running processes are removed.
+func (m *Manager) RunEnvExport(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	ticker := time.NewTicker(envExportRefresh)
+	defer ticker.Stop()
+	for {
+		if err := m.writeEnvExports(dir); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-events:
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeEnvExports rewrites the export files whose contents changed.
+func (m *Manager) writeEnvExports(dir string) error {
+	views, err := m.List(ListFilter{})
+	if err != nil {
+		return err
+	}
+	// Oldest first, so a longer-running process keeps the plain prefix.
+	slices.SortFunc(views, func(a, b ProcessView) int { return a.StartedAt.Compare(b.StartedAt) })
+	exports := make(map[string]*EnvExport)
+	for _, v := range views {
+		branch := v.Tags["branch"]
+		if branch == "" || (v.Status != StatusRunning && v.Status != StatusPaused) {
+			continue
+		}
+		e, ok := exports[branch]
+		if !ok {
+			e = &EnvExport{Branch: branch, Env: make(map[string]string)}
+			exports[branch] = e
+		}
+		e.add(v)
+	}
+
+	keep := make(map[string]bool)
+	for branch, e := range exports {
+		base := unsafeFileChar.ReplaceAllString(branch, "_")
+		keep[base+".env"], keep[base+".json"] = true, true
+		data, err := json.MarshalIndent(e, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeIfChanged(filepath.Join(dir, base+".json"), append(data, '\n')); err != nil {
+			return err
+		}
+		if err := writeIfChanged(filepath.Join(dir, base+".env"), e.dotenv()); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if (strings.HasSuffix(name, ".env") || strings.HasSuffix(name, ".json")) && !keep[name] {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+	return nil
+}
+
+// add records v's ports and URLs under a prefix derived from its name, else
+// its role, else its ID, e.g. API_DEV_PORT and API_DEV_URL.
+func (e *EnvExport) add(v ProcessView) {
+	label := v.Name
+	if label == "" {
+		label = v.Tags["role"]
+	}
+	if label == "" {
+		label = v.ID
+	}
+	prefix := strings.Trim(unsafeVarChar.ReplaceAllString(strings.ToUpper(label), "_"), "_")
+	if prefix == "" || prefix[0] >= '0' && prefix[0] <= '9' {
+		prefix = "P_" + prefix
+	}
+	// Two processes with the same role on one branch get distinct prefixes.
+	for _, p := range e.Processes {
+		if p.Prefix == prefix {
+			prefix += "_" + strings.ToUpper(v.ID)
+			break
+		}
+	}
+
+	p := EnvExportProcess{ID: v.ID, Name: v.Name, Role: v.Tags["role"], Prefix: prefix}
+	ports := v.AllocatedPorts
+	if len(ports) == 0 {
+		ports = v.Ports
+	}
+	if len(ports) == 0 {
+		ports = v.ReportedPorts
+	}
+	if len(ports) == 0 {
+		ports = v.DetectedPorts
+	}
+	for i, port := range ports {
+		suffix := ""
+		if i > 0 {
+			suffix = "_" + strconv.Itoa(i+1)
+		}
+		url := fmt.Sprintf("http://localhost:%d", port)
+		p.Ports = append(p.Ports, port)
+		p.URLs = append(p.URLs, url)
+		e.Env[prefix+"_PORT"+suffix] = strconv.Itoa(port)
+		e.Env[prefix+"_URL"+suffix] = url
+	}
+	e.Env[prefix+"_ID"] = v.ID
+	e.Processes = append(e.Processes, p)
+	slices.SortFunc(e.Processes, func(a, b EnvExportProcess) int { return strings.Compare(a.Prefix, b.Prefix) })
+}
+
+// dotenv renders e as a shell-sourceable file.
+func (e *EnvExport) dotenv() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Processes on branch %s, kept up to date by thought-process.\n", e.Branch)
+	keys := make([]string, 0, len(e.Env))
+	for k := range e.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "export %s=%s\n", k, shellQuote(e.Env[k]))
+	}
+	return b.Bytes()
+}
+
+// writeIfChanged atomically replaces path with data unless it already holds
+// exactly that.
+func writeIfChanged(path string, data []byte) error {
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
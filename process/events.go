@@ -0,0 +1,133 @@
+package process
+
+import "time"
+
+// EventType is the kind of lifecycle change an Event reports.
+type EventType string
+
+const (
+	EventStarted EventType = "started"
+	// EventExited is an exit with code 0, or any exit after Kill.
+	EventExited EventType = "exited"
+	// EventCrashed is a non-zero exit the process wasn't asked to make.
+	EventCrashed EventType = "crashed"
+	// EventRestarted is a relaunch by the restart policy.
+	EventRestarted EventType = "restarted"
+	// EventCrashLooping is the exit after which the restart policy gives up.
+	EventCrashLooping EventType = "crash_looping"
+	// EventTimedOut is an exit forced by a timeout such as max_runtime_secs.
+	EventTimedOut EventType = "timed_out"
+	// EventHealthChanged is a running process's health check result
+	// changing, e.g. from starting to healthy; see ProcessView.Health.
+	EventHealthChanged EventType = "health_changed"
+	// EventLogMatch is output matching a log watch; see AddLogWatch.
+	EventLogMatch EventType = "log_match"
+	// EventNewError is an error no process has printed before; see
+	// ErrorFingerprints.
+	EventNewError EventType = "new_error"
+	// EventHighMemory is a process crossing the memory alert threshold; see
+	// SetMemoryAlert.
+	EventHighMemory EventType = "high_memory"
+	// EventOOMKilled is a process killed by the OOM killer. It follows the
+	// crashed or crash_looping event for the same exit.
+	EventOOMKilled EventType = "oom_killed"
+	// EventLogSpike is a process writing far more output than usual; see
+	// SetLogSpikeFactor.
+	EventLogSpike EventType = "log_spike"
+	// EventExitWarning is a process printing output that usually comes
+	// shortly before it dies; see ProcessInfo.Warning.
+	EventExitWarning EventType = "exit_warning"
+	// EventEvicted is the storage quota or the retention policy deleting
+	// an exited process's log or record; see RunStorageQuota and
+	// RunRetention.
+	EventEvicted EventType = "evicted"
+	// EventDeleted is a process's record and log being removed by Delete.
+	EventDeleted EventType = "deleted"
+	// EventDiedInSleep is a process found to have exited, during or right
+	// after a system sleep, on waking; see RunSleepWatch. It follows the
+	// process's exit event, if one was published.
+	EventDiedInSleep EventType = "died_in_sleep"
+)
+
+// eventTypes are all the event types, for validating event filters.
+var eventTypes = []EventType{
+	EventStarted, EventExited, EventCrashed, EventRestarted, EventCrashLooping,
+	EventTimedOut, EventHealthChanged, EventLogMatch, EventNewError,
+	EventHighMemory, EventOOMKilled, EventLogSpike, EventExitWarning,
+	EventEvicted, EventDeleted, EventDiedInSleep,
+}
+
+// eventBuffer is how many events a subscriber can fall behind by before
+// further events are dropped for it.
+const eventBuffer = 64
+
+// Event is a lifecycle change of a process started by this Manager, a
+// health change, a log watch match, a new error, an alert, an eviction or a death in sleep.
+type Event struct {
+	Type    EventType   `json:"type"`
+	Time    time.Time   `json:"time"`
+	Process ProcessView `json:"process"`
+	// Match is set for log_match events.
+	Match *LogMatch `json:"match,omitempty"`
+	// Error is set for new_error events.
+	Error *ErrorFingerprint `json:"error,omitempty"`
+	// Alert is set for high_memory, oom_killed, log_spike and exit_warning
+	// events.
+	Alert *Alert `json:"alert,omitempty"`
+	// Eviction is set for evicted events.
+	Eviction *Eviction `json:"eviction,omitempty"`
+	// Sleep is set for died_in_sleep events.
+	Sleep *Sleep `json:"sleep,omitempty"`
+}
+
+// Subscribe returns a channel of lifecycle events for processes started by
+// this Manager and a function that ends the subscription. Events are
+// dropped, not queued, for subscribers that fall behind.
+func (m *Manager) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+	m.subsMu.Lock()
+	m.subs[ch] = struct{}{}
+	m.subsMu.Unlock()
+
+	return ch, func() {
+		m.subsMu.Lock()
+		defer m.subsMu.Unlock()
+		if _, ok := m.subs[ch]; ok {
+			delete(m.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends an event for info to every subscriber. It must not be called
+// with m.mu held.
+func (m *Manager) publish(t EventType, info ProcessInfo) {
+	m.publishEvent(Event{Type: t, Time: time.Now().UTC(), Process: m.view(info)})
+}
+
+// publishEvent sends e to every subscriber.
+func (m *Manager) publishEvent(e Event) {
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+	for ch := range m.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// exitEvent classifies an exit with code; stopped means Kill or Shutdown
+// asked for it, and reason is the Manager's reason for stopping it, if any.
+func exitEvent(code int, stopped, crashLooping bool, reason string) EventType {
+	switch {
+	case timedOut(reason):
+		return EventTimedOut
+	case crashLooping:
+		return EventCrashLooping
+	case stopped || code == 0:
+		return EventExited
+	default:
+		return EventCrashed
+	}
+}
@@ -0,0 +1,246 @@
+package process
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"thought-process/store"
+)
+
+const (
+	errorsKeyPrefix = "errors:"
+	// maxErrorBlockLines bounds an error block; longer traces are cut off.
+	maxErrorBlockLines = 50
+	// maxSampleLines is how much of an error block is kept as its sample.
+	maxSampleLines = 20
+	// fingerprintLines is how many lines of a block are hashed: the message
+	// and the top of the trace.
+	fingerprintLines = 6
+	// maxFingerprints is how many fingerprints are kept per process; the
+	// least recently seen are dropped first.
+	maxFingerprints = 100
+)
+
+var (
+	// errorLine starts an error block.
+	errorLine = regexp.MustCompile(`(?i)\b(error|exception|panic|fatal|traceback|uncaught|segmentation fault)\b`)
+	// continuationLine continues an error block: indented stack frames and
+	// the usual trace markers.
+	continuationLine = regexp.MustCompile(`^(\s+\S|at |Caused by:|goroutine \d+|\.\.\. \d+ more)`)
+
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+	// Parts of a line that vary between occurrences of the same error.
+	hexNumber = regexp.MustCompile(`0x[0-9a-fA-F]+|\b[0-9a-fA-F]{8,}\b`)
+	number    = regexp.MustCompile(`\d+`)
+)
+
+// ErrorFingerprint is a distinct error a process has printed. Occurrences
+// that differ only in numbers, addresses and IDs share a fingerprint.
+type ErrorFingerprint struct {
+	Fingerprint string `json:"fingerprint"`
+	// Message is the first line of the error; Sample is the start of the
+	// block (message and trace) as first seen.
+	Message   string    `json:"message"`
+	Sample    string    `json:"sample"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// ErrorFingerprints returns the errors recorded for a process, most recently
+// seen first.
+func (m *Manager) ErrorFingerprints(processID string) ([]ErrorFingerprint, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	fps, err := m.loadFingerprints(info.ID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(fps, func(i, j int) bool { return fps[i].LastSeen.After(fps[j].LastSeen) })
+	return fps, nil
+}
+
+func (m *Manager) loadFingerprints(id string) ([]ErrorFingerprint, error) {
+	data, err := m.store.Get(errorsKeyPrefix + id)
+	if errors.Is(err, store.ErrNotFound) {
+		return []ErrorFingerprint{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading error fingerprints: %w", err)
+	}
+	var fps []ErrorFingerprint
+	if err := json.Unmarshal(data, &fps); err != nil {
+		return nil, fmt.Errorf("decoding error fingerprints: %w", err)
+	}
+	return fps, nil
+}
+
+// knownFingerprint reports whether a process other than id has recorded fp.
+func (m *Manager) knownFingerprint(id, fp string) bool {
+	keys, err := m.store.List(errorsKeyPrefix, 0)
+	if err != nil {
+		return false
+	}
+	for _, key := range keys {
+		if key == errorsKeyPrefix+id {
+			continue
+		}
+		data, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var fps []ErrorFingerprint
+		if json.Unmarshal(data, &fps) == nil && containsFingerprint(fps, fp) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFingerprint(fps []ErrorFingerprint, fp string) bool {
+	for _, f := range fps {
+		if f.Fingerprint == fp {
+			return true
+		}
+	}
+	return false
+}
+
+// recordErrors adds the error blocks info's process printed to its
+// fingerprints. A fingerprint no process has recorded before is published
+// as a new_error event; recurring ones only update their counts.
+func (m *Manager) recordErrors(info ProcessInfo, blocks [][]string) {
+	fps, err := m.loadFingerprints(info.ID)
+	if err != nil {
+		return
+	}
+	now := time.Now().UTC()
+	var fresh []ErrorFingerprint
+	for _, block := range blocks {
+		fp := fingerprint(block)
+		i := 0
+		for i < len(fps) && fps[i].Fingerprint != fp {
+			i++
+		}
+		if i < len(fps) {
+			fps[i].Count++
+			fps[i].LastSeen = now
+			continue
+		}
+		sample := block[:min(len(block), maxSampleLines)]
+		f := ErrorFingerprint{
+			Fingerprint: fp,
+			Message:     errorMessage(block),
+			Sample:      strings.Join(sample, "\n"),
+			Count:       1,
+			FirstSeen:   now,
+			LastSeen:    now,
+		}
+		fps = append(fps, f)
+		if !m.knownFingerprint(info.ID, fp) {
+			fresh = append(fresh, f)
+		}
+	}
+	if len(fps) > maxFingerprints {
+		sort.Slice(fps, func(i, j int) bool { return fps[i].LastSeen.After(fps[j].LastSeen) })
+		fps = fps[:maxFingerprints]
+	}
+	data, err := json.Marshal(fps)
+	if err != nil {
+		return
+	}
+	if err := m.store.Set(errorsKeyPrefix+info.ID, data); err != nil {
+		return
+	}
+
+	if current, err := m.lookup(info.ID); err == nil {
+		info = current
+	}
+	for _, f := range fresh {
+		m.publishEvent(Event{Type: EventNewError, Time: now, Process: m.view(info), Error: &f})
+	}
+}
+
+// errorMessage returns the line of block that says what went wrong: the
+// first, or the last for a Python traceback.
+func errorMessage(block []string) string {
+	if strings.HasPrefix(block[0], "Traceback") {
+		return strings.TrimSpace(block[len(block)-1])
+	}
+	return block[0]
+}
+
+// fingerprint hashes the message and start of an error block with the parts
+// that vary between occurrences (numbers, addresses, IDs) masked.
+func fingerprint(block []string) string {
+	h := sha256.New()
+	for _, line := range append([]string{errorMessage(block)}, block[:min(len(block), fingerprintLines)]...) {
+		line = hexNumber.ReplaceAllString(line, "H")
+		line = number.ReplaceAllString(line, "N")
+		h.Write([]byte(strings.Join(strings.Fields(line), " ")))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// errorExtractor groups log lines into error blocks: a line that looks like
+// an error plus the stack trace lines after it.
+type errorExtractor struct {
+	block []string
+	// traceback is set for Python tracebacks, whose message comes after the
+	// frames.
+	traceback bool
+}
+
+// feed returns the error blocks completed by lines. A block still open when
+// a read brings no new lines is complete too, since traces are written in
+// one go.
+func (e *errorExtractor) feed(lines [][]byte) [][]string {
+	if len(lines) == 0 {
+		return e.flush()
+	}
+	var blocks [][]string
+	for _, raw := range lines {
+		line := strings.TrimRight(ansiEscape.ReplaceAllString(string(raw), ""), "\r")
+		if e.block != nil {
+			if continuationLine.MatchString(line) {
+				if len(e.block) < maxErrorBlockLines {
+					e.block = append(e.block, line)
+				}
+				continue
+			}
+			if e.traceback && strings.TrimSpace(line) != "" {
+				// The exception line ends the traceback.
+				e.block = append(e.block, line)
+				blocks = append(blocks, e.flush()...)
+				continue
+			}
+			blocks = append(blocks, e.flush()...)
+		}
+		if errorLine.MatchString(line) {
+			e.block = []string{strings.TrimSpace(line)}
+			e.traceback = strings.HasPrefix(line, "Traceback")
+		}
+	}
+	return blocks
+}
+
+// flush returns the open block, if any, as complete.
+func (e *errorExtractor) flush() [][]string {
+	if e.block == nil {
+		return nil
+	}
+	block := e.block
+	e.block = nil
+	e.traceback = false
+	return [][]string{block}
+}
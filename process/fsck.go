@@ -0,0 +1,100 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"thought-process/store"
+)
+
+// FsckReport describes the problems found (and fixed) by Manager.Fsck.
+type FsckReport struct {
+	// Checked is the number of process records examined.
+	Checked int `json:"checked"`
+	// Leftovers are store leftovers from interrupted writes.
+	Leftovers []string `json:"leftovers,omitempty"`
+	// Corrupted lists keys whose records could not be read or decoded.
+	Corrupted []string `json:"corrupted,omitempty"`
+	// Encrypted lists keys holding encrypted records that couldn't be
+	// decrypted with the current key. They are never deleted by repair.
+	Encrypted []string `json:"encrypted,omitempty"`
+	// Plaintext lists keys holding unencrypted records in an encrypted
+	// store. They are never deleted by repair; fsck -encrypt encrypts them
+	// if they are trusted.
+	Plaintext []string `json:"plaintext,omitempty"`
+	// Mismatched lists keys whose record ID doesn't match the key.
+	Mismatched []string `json:"mismatched,omitempty"`
+	// MissingLogs lists process IDs whose log file no longer exists,
+	// other than those evicted by the storage quota.
+	MissingLogs []string `json:"missing_logs,omitempty"`
+	// Repaired is true if the problems above were fixed. Missing logs are
+	// reported only; there is nothing to restore them from.
+	Repaired bool `json:"repaired"`
+}
+
+// Fsck verifies that every process record in the store decodes and is
+// consistent with its key. With repair set, it removes store leftovers,
+// deletes undecodable records and rewrites mismatched IDs.
+func (m *Manager) Fsck(repair bool) (*FsckReport, error) {
+	report := &FsckReport{Repaired: repair}
+
+	if c, ok := m.store.(store.Compactor); ok {
+		leftovers, err := c.Compact(!repair)
+		report.Leftovers = leftovers
+		if err != nil {
+			return report, fmt.Errorf("compacting store: %w", err)
+		}
+	}
+
+	keys, err := m.store.List(keyPrefix, 0)
+	if err != nil {
+		return report, fmt.Errorf("listing process keys: %w", err)
+	}
+
+	for _, key := range keys {
+		report.Checked++
+		id := strings.TrimPrefix(key, keyPrefix)
+
+		raw, err := m.store.Get(key)
+		if errors.Is(err, store.ErrDecrypt) || (err == nil && store.IsEncrypted(raw)) {
+			report.Encrypted = append(report.Encrypted, key)
+			continue
+		}
+		if errors.Is(err, store.ErrPlaintext) {
+			report.Plaintext = append(report.Plaintext, key)
+			continue
+		}
+		var info ProcessInfo
+		if err == nil {
+			err = json.Unmarshal(raw, &info)
+		}
+		if err != nil {
+			report.Corrupted = append(report.Corrupted, key)
+			if repair {
+				if err := m.store.Delete(key); err != nil {
+					return report, fmt.Errorf("deleting %s: %w", key, err)
+				}
+			}
+			continue
+		}
+
+		if info.ID != id {
+			report.Mismatched = append(report.Mismatched, key)
+			if repair {
+				info.ID = id
+				if err := m.persist(info); err != nil {
+					return report, fmt.Errorf("rewriting %s: %w", key, err)
+				}
+			}
+		}
+
+		if _, err := os.Stat(info.LogPath); errors.Is(err, os.ErrNotExist) && !info.LogEvicted {
+			report.MissingLogs = append(report.MissingLogs, id)
+		}
+	}
+
+	return report, nil
+}
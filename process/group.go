@@ -0,0 +1,64 @@
+package process
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// groupByTagPrefix starts a group_by value that groups by a tag's value.
+const groupByTagPrefix = "tag:"
+
+// ProcessGroup is the processes sharing one value of the tag that a list
+// was grouped by, with counts for rendering a summary row.
+type ProcessGroup struct {
+	Tag string `json:"tag"`
+	// Value is the tag's value, or "" for processes without the tag.
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	// Statuses counts the processes by status.
+	Statuses  map[ProcessStatus]int `json:"statuses"`
+	Processes []ProcessView         `json:"processes"`
+}
+
+// ParseGroupBy returns the tag key of a group_by value of the form
+// "tag:KEY", e.g. "tag:branch".
+func ParseGroupBy(groupBy string) (string, error) {
+	key, ok := strings.CutPrefix(groupBy, groupByTagPrefix)
+	if !ok || key == "" {
+		return "", fmt.Errorf("invalid group_by %q: use tag:KEY, e.g. tag:branch", groupBy)
+	}
+	return key, nil
+}
+
+// GroupByTag groups views by the value of the tag key, keeping their order
+// within each group. Groups are sorted by value, with processes lacking the
+// tag last.
+func GroupByTag(views []ProcessView, key string) []ProcessGroup {
+	index := make(map[string]int)
+	groups := []ProcessGroup{}
+	for _, v := range views {
+		value := v.Tags[key]
+		i, ok := index[value]
+		if !ok {
+			i = len(groups)
+			index[value] = i
+			groups = append(groups, ProcessGroup{Tag: key, Value: value, Statuses: make(map[ProcessStatus]int)})
+		}
+		g := &groups[i]
+		g.Count++
+		g.Statuses[v.Status]++
+		g.Processes = append(g.Processes, v)
+	}
+	slices.SortFunc(groups, func(a, b ProcessGroup) int {
+		if (a.Value == "") != (b.Value == "") {
+			if a.Value == "" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	return groups
+}
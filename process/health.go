@@ -0,0 +1,150 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// HealthStatus is the result of a process's health checks.
+type HealthStatus string
+
+const (
+	HealthStarting  HealthStatus = "starting"
+	HealthHealthy   HealthStatus = "healthy"
+	HealthUnhealthy HealthStatus = "unhealthy"
+)
+
+const (
+	defaultHealthInterval = 10 * time.Second
+
+	// healthFailureThreshold is the number of consecutive failed checks before
+	// a process is reported unhealthy.
+	healthFailureThreshold = 3
+)
+
+// HealthCheck describes a probe the Manager runs periodically against a
+// process. Exactly one of HTTP, TCP and Command must be set. $PORT and other
+// variables from the process env are expanded in all three; $PORT defaults to
+// the first declared port.
+type HealthCheck struct {
+	// HTTP is a URL that must answer GET with a 2xx or 3xx status.
+	HTTP string `json:"http,omitempty"`
+	// TCP is a host:port that must accept connections.
+	TCP string `json:"tcp,omitempty"`
+	// Command is a shell command that must exit 0. It runs in the process cwd.
+	Command string `json:"command,omitempty"`
+	// IntervalSecs is the time between checks (default 10).
+	IntervalSecs int `json:"interval_secs,omitempty"`
+}
+
+func (hc *HealthCheck) validate() error {
+	set := 0
+	for _, v := range []string{hc.HTTP, hc.TCP, hc.Command} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("health check must set exactly one of http, tcp or command")
+	}
+	if hc.IntervalSecs < 0 {
+		return errors.New("health check interval_secs must not be negative")
+	}
+	return nil
+}
+
+func (hc *HealthCheck) interval() time.Duration {
+	if hc.IntervalSecs > 0 {
+		return time.Duration(hc.IntervalSecs) * time.Second
+	}
+	return defaultHealthInterval
+}
+
+// probe builds the Probe for info, expanding variables from its environment.
+func (hc *HealthCheck) probe(info ProcessInfo) Probe {
+	expand := func(s string) string {
+		return os.Expand(s, func(key string) string {
+			if v, ok := info.Env[key]; ok {
+				return v
+			}
+			if key == "PORT" && len(info.Ports) > 0 {
+				return strconv.Itoa(info.Ports[0])
+			}
+			return os.Getenv(key)
+		})
+	}
+	switch {
+	case hc.HTTP != "":
+		return HTTPProbe(expand(hc.HTTP))
+	case hc.TCP != "":
+		return TCPProbe(expand(hc.TCP))
+	default:
+		return CommandProbe(expand(hc.Command), info.Cwd)
+	}
+}
+
+// CommandProbe returns a Probe that succeeds when command exits 0 when run
+// through the user's shell in dir.
+func CommandProbe(command, dir string) Probe {
+	return func(ctx context.Context) error {
+		cmd := exec.CommandContext(ctx, userShell(), "-c", command)
+		cmd.Dir = dir
+		return cmd.Run()
+	}
+}
+
+// watchHealth runs the health check for rp until it exits for good, and
+// right away when the system wakes from sleep.
+func (m *Manager) watchHealth(info ProcessInfo, rp *runningProc) {
+	probe := info.HealthCheck.probe(info)
+	ticker := time.NewTicker(info.HealthCheck.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rp.done:
+			return
+		case <-ticker.C:
+		case <-m.wokeChan():
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := probe(ctx)
+		cancel()
+
+		m.mu.Lock()
+		prev := rp.health
+		if err == nil {
+			rp.health = HealthHealthy
+			rp.healthFailures = 0
+		} else {
+			rp.healthFailures++
+			if rp.healthFailures >= healthFailureThreshold {
+				rp.health = HealthUnhealthy
+			}
+		}
+		changed := rp.health != prev
+		m.mu.Unlock()
+
+		if changed {
+			if cur, err := m.load(info.ID); err == nil {
+				m.publish(EventHealthChanged, cur)
+			}
+		}
+	}
+}
+
+// health returns the current health of a process, or "" if it has no health
+// check or isn't running under this Manager.
+func (m *Manager) health(id string) HealthStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rp, ok := m.running[id]; ok {
+		return rp.health
+	}
+	return ""
+}
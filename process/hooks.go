@@ -0,0 +1,142 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultHookTimeout bounds a pre_stop or on_exit hook that doesn't set
+	// its own timeout.
+	defaultHookTimeout = 30 * time.Second
+	// maxHookTimeoutSecs bounds hook timeouts.
+	maxHookTimeoutSecs = 600
+)
+
+// ExitHook is a command run each time a process exits.
+type ExitHook struct {
+	Command string `json:"command"`
+	// OnFailureOnly skips exits that aren't failures: exit code 0, exits
+	// after Kill and exits of adopted processes, whose code is unknown.
+	OnFailureOnly bool `json:"on_failure_only,omitempty"`
+	// TimeoutSecs bounds the hook (default 30).
+	TimeoutSecs int `json:"timeout_secs,omitempty"`
+}
+
+func (h *ExitHook) validate() error {
+	if h.Command == "" {
+		return errors.New("on_exit command is required")
+	}
+	if h.TimeoutSecs < 0 || h.TimeoutSecs > maxHookTimeoutSecs {
+		return fmt.Errorf("on_exit timeout_secs must be between 0 and %d", maxHookTimeoutSecs)
+	}
+	return nil
+}
+
+// validatePreStop checks the pre_stop hook options.
+func validatePreStop(command string, timeoutSecs int) error {
+	if timeoutSecs < 0 || timeoutSecs > maxHookTimeoutSecs {
+		return fmt.Errorf("pre_stop_timeout_secs must be between 0 and %d", maxHookTimeoutSecs)
+	}
+	if timeoutSecs > 0 && command == "" {
+		return errors.New("pre_stop_timeout_secs requires pre_stop")
+	}
+	return nil
+}
+
+// hookTimeout returns secs as a hook timeout, with 0 meaning the default.
+func hookTimeout(secs int) time.Duration {
+	if secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return defaultHookTimeout
+}
+
+// logWriter returns where hooks of a process write: the log file it shares
+// with the process, or io.Discard for processes without one.
+func (m *Manager) logWriter(id string) io.Writer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rp, ok := m.running[id]; ok && rp.logFile != nil {
+		return rp.logFile
+	}
+	return io.Discard
+}
+
+// runPreStop runs info's pre_stop hook, if it has one, and waits for it to
+// exit or time out. Failures are logged: the process is stopped either way.
+func (m *Manager) runPreStop(info ProcessInfo) {
+	if info.PreStop != "" {
+		m.runHook(info, "pre_stop", info.PreStop, nil, hookTimeout(info.PreStopTimeoutSecs), m.logWriter(info.ID))
+	}
+}
+
+// runOnExit runs info's on_exit hook, if it applies to an exit of kind ev
+// with code (nil if unknown), writing to out, and waits for it. The hook
+// gets the process's ID, name, exit code, kind of exit and log path in
+// THOUGHT_PROCESS_* variables.
+func (m *Manager) runOnExit(info ProcessInfo, ev EventType, code *int, out io.Writer) {
+	hook := info.OnExit
+	if hook == nil || (hook.OnFailureOnly && (ev == EventExited || code == nil)) {
+		return
+	}
+	env := []string{
+		"THOUGHT_PROCESS_ID=" + info.ID,
+		"THOUGHT_PROCESS_NAME=" + info.Name,
+		"THOUGHT_PROCESS_EXIT=" + string(ev),
+		"THOUGHT_PROCESS_EXIT_CODE=",
+	}
+	if code != nil {
+		env[3] += strconv.Itoa(*code)
+	}
+	if path, err := m.logPath(info); err == nil {
+		env = append(env, "THOUGHT_PROCESS_LOG="+path)
+	}
+	m.runHook(info, "on_exit", hook.Command, env, hookTimeout(hook.TimeoutSecs), out)
+}
+
+// runHook runs command through the user's shell in info's cwd, with its
+// environment plus env and its placeholders expanded, and waits up to
+// timeout for it. Output and failures go to out.
+func (m *Manager) runHook(info ProcessInfo, name, command string, env []string, timeout time.Duration, out io.Writer) {
+	logf := func(format string, a ...any) {
+		fmt.Fprintf(out, "thought-process: "+name+": "+format+"\n", a...)
+	}
+
+	tmpl := newExpander(&info)
+	command, err := tmpl.expand(command)
+	if err != nil {
+		logf("%v", err)
+		return
+	}
+	environ, _, err := m.environ(&info, tmpl)
+	if err != nil {
+		logf("%v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, userShell(), "-c", command)
+	cmd.Dir = info.Cwd
+	cmd.Env = append(environ, env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	// Kill whatever the hook started along with it on timeout.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return signalGroup(cmd.Process.Pid, syscall.SIGKILL) }
+
+	logf("running %s", command)
+	switch err := cmd.Run(); {
+	case ctx.Err() != nil:
+		logf("timed out after %s", timeout)
+	case err != nil:
+		logf("%v", err)
+	}
+}
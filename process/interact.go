@@ -0,0 +1,145 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	defaultInteractTimeout = 5 * time.Second
+	maxInteractTimeout     = 60 * time.Second
+	defaultInteractSettle  = 500 * time.Millisecond
+	// maxInteractOutput bounds the output returned by Interact; only the
+	// end is kept beyond it.
+	maxInteractOutput = 64 * 1024
+)
+
+// InteractOptions controls how long Interact collects output after sending
+// its input.
+type InteractOptions struct {
+	// Timeout is the longest Interact waits; 0 means 5s. At most 60s.
+	Timeout time.Duration
+	// Settle ends collection once output has started and then stayed quiet
+	// this long; 0 means 500ms. It is ignored when Until is set.
+	Settle time.Duration
+	// Until, if set, ends collection as soon as the output so far matches,
+	// e.g. the REPL's prompt.
+	Until *regexp.Regexp
+}
+
+// InteractResult is the output a process wrote in response to Interact.
+type InteractResult struct {
+	// Output is what the process wrote after the input was sent, with ANSI
+	// escape codes and carriage returns removed.
+	Output string `json:"output"`
+	// Stop says why collection ended: "matched", "settled", "timeout" or
+	// "exited".
+	Stop string `json:"stop"`
+	// Truncated is set when only the end of the output was kept.
+	Truncated bool         `json:"truncated,omitempty"`
+	Process   *ProcessView `json:"process"`
+}
+
+// Interact sends input to a running process's stdin, as SendInput does, and
+// returns the output it writes to its log until opts says to stop.
+func (m *Manager) Interact(ctx context.Context, processID, input string, opts InteractOptions) (*InteractResult, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultInteractTimeout
+	}
+	if opts.Timeout > maxInteractTimeout {
+		return nil, fmt.Errorf("timeout must be at most %s", maxInteractTimeout)
+	}
+	if opts.Settle <= 0 {
+		opts.Settle = defaultInteractSettle
+	}
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	path, err := m.logPath(info)
+	if err != nil {
+		return nil, err
+	}
+	// Only output written after the input counts.
+	tail, err := OpenLogTail(path, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening log file: %w", err)
+	}
+	defer tail.Close()
+
+	if _, err := m.SendInput(info.ID, input); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	// A PTY echoes the input back; that alone doesn't start the settle
+	// period, so a slow statement isn't cut off after its echo.
+	echo := strings.TrimSuffix(input, "\n") + "\n"
+	var out []byte
+	truncated := false
+	var lastOutput time.Time
+	buf := make([]byte, 32*1024)
+	result := &InteractResult{}
+	for result.Stop == "" {
+		for {
+			n, err := tail.Read(buf)
+			out = append(out, buf[:n]...)
+			if n > 0 {
+				lastOutput = time.Now()
+			}
+			if err != nil || n == 0 {
+				if err != nil && !errors.Is(err, io.EOF) {
+					return nil, fmt.Errorf("reading log file: %w", err)
+				}
+				break
+			}
+		}
+		if len(out) > maxInteractOutput {
+			out = out[len(out)-maxInteractOutput:]
+			truncated = true
+		}
+		result.Output = strings.ReplaceAll(ansiEscape.ReplaceAllString(string(out), ""), "\r", "")
+
+		switch {
+		case opts.Until != nil && opts.Until.MatchString(result.Output):
+			result.Stop = "matched"
+		case opts.Until == nil && strings.TrimPrefix(result.Output, echo) != "" && time.Since(lastOutput) >= opts.Settle:
+			result.Stop = "settled"
+		case !m.alive(info.ID):
+			result.Stop = "exited"
+		default:
+			select {
+			case <-ctx.Done():
+				result.Stop = "timeout"
+			case <-ticker.C:
+			}
+		}
+	}
+
+	if latest, err := m.load(info.ID); err == nil {
+		info = latest
+	}
+	view := m.view(info)
+	result.Truncated = truncated
+	result.Process = &view
+	return result, nil
+}
+
+// alive reports whether the process id is running or paused.
+func (m *Manager) alive(id string) bool {
+	info, err := m.load(id)
+	if err != nil {
+		return false
+	}
+	st := m.status(info)
+	return st == StatusRunning || st == StatusPaused
+}
@@ -1,11 +1,16 @@
 package process
 
+import (
+	"context"
+	"time"
+)
+
 // ProcessManager defines the interface for managing long-running processes.
 // This abstraction allows the MCP tools and HTTP dashboard to share the same
 // process management logic.
 type ProcessManager interface {
 	// Start launches a subprocess and returns its ProcessView.
-	Start(command string, args []string, cwd string, env map[string]string, tags map[string]string, ports []int) (*ProcessView, error)
+	Start(opts StartOptions) (*ProcessView, error)
 
 	// List returns tracked processes with their current status, filtered by f.
 	List(f ListFilter) ([]ProcessView, error)
@@ -16,11 +21,187 @@ type ProcessManager interface {
 	// GetLogPath returns the path to a process's log file for streaming.
 	GetLogPath(processID string) (string, error)
 
-	// Kill sends SIGTERM to a tracked process, waits up to 5 seconds, then
-	// SIGKILLs it if still alive. Returns the final ProcessView.
+	// WaitReady blocks until the process satisfies cond, stops running, or
+	// ctx is done.
+	WaitReady(ctx context.Context, processID string, cond ReadyCondition) (*ProcessView, error)
+
+	// Report records what a running process tells about itself,
+	// authenticated by its report token.
+	Report(processID, token string, r Report) (*ProcessView, error)
+
+	// Kill runs a tracked process's pre_stop hook, if any, sends it its stop
+	// signal (SIGTERM by default), waits up to its grace period (5 seconds
+	// by default), then SIGKILLs it if still alive. Returns the final
+	// ProcessView.
 	Kill(processID string) (*ProcessView, error)
 
-	// Shutdown sends SIGTERM to all running processes, waits up to 5 seconds,
-	// then SIGKILLs any remaining. Safe to call multiple times.
+	// KillWith is Kill with the process's stop signal and grace period
+	// overridden by opts.
+	KillWith(processID string, opts KillOptions) (*ProcessView, error)
+
+	// Delete removes an exited process's record, error fingerprints and
+	// log. A process still running is refused with ErrStillRunning unless
+	// force is set, which kills it first.
+	Delete(processID string, force bool) (*ProcessView, error)
+
+	// KillMatching kills every running or paused process whose tags include
+	// all of tags. At least one tag is required.
+	KillMatching(tags map[string]string) ([]ProcessView, error)
+
+	// CleanupWorktrees kills, or with dryRun only reports, the running
+	// processes whose worktree directory or branch no longer exists.
+	CleanupWorktrees(dryRun bool) ([]StaleProcess, error)
+
+	// Restart kills a process and starts it again with the same options,
+	// under a new ID.
+	Restart(processID string) (*ProcessView, error)
+
+	// RestartMatching restarts every running or paused process whose tags
+	// include all of tags. At least one tag is required.
+	RestartMatching(tags map[string]string) ([]RestartResult, error)
+
+	// UpdateEnv records an env change for a running process, applied when
+	// it next restarts, or right away by restarting it if restart is set.
+	UpdateEnv(processID string, set map[string]string, unset []string, restart bool) (*ProcessView, error)
+
+	// SetPriority renices a running process group and/or changes its I/O
+	// scheduling class.
+	SetPriority(processID string, nice *int, ioClass string) (*ProcessView, error)
+
+	// Pause stops a running process group with SIGSTOP.
+	Pause(processID string) (*ProcessView, error)
+
+	// Resume continues a paused process group with SIGCONT.
+	Resume(processID string) (*ProcessView, error)
+
+	// SendInput writes a line to the process's stdin.
+	SendInput(processID, input string) (*ProcessView, error)
+
+	// Interact writes a line to the process's stdin and returns the output
+	// it writes in response.
+	Interact(ctx context.Context, processID, input string, opts InteractOptions) (*InteractResult, error)
+
+	// FindByPort reports which process, tracked or not, is listening on
+	// a TCP port.
+	FindByPort(port int) (*PortOwner, error)
+
+	// PortMap returns the ports live processes hold and pending schedule
+	// runs and stopped stack definitions declare.
+	PortMap() (*PortMap, error)
+
+	// StaleReport cross-references all tracked processes with their
+	// repositories' worktrees and branches and, with cleanup, kills or
+	// deletes the stale ones.
+	StaleReport(cleanup bool) (*StaleReport, error)
+
+	// RegisterProject records path as the root of the project name, so that
+	// a cwd of "project:NAME/sub/dir" resolves inside it.
+	RegisterProject(name, path string) (*Project, error)
+
+	// Projects returns the registered projects sorted by name.
+	Projects() ([]Project, error)
+
+	// Alerts returns the high-memory and OOM-kill alerts recorded on all
+	// processes, newest first.
+	Alerts() ([]ProcessAlert, error)
+
+	// Summary counts running, paused, failing and unhealthy processes.
+	Summary() (*Summary, error)
+
+	// AcquireLock takes a named lock, waiting up to opts.Wait while someone
+	// else holds it.
+	AcquireLock(ctx context.Context, opts LockOptions) (*Lock, error)
+
+	// ReleaseLock releases a lock held with token.
+	ReleaseLock(name, token string) error
+
+	// KVSet, KVGet, KVList and KVDelete manage the agents' shared
+	// scratchpad of small values.
+	KVSet(key, value string) (*KVEntry, error)
+	KVGet(key string) (*KVEntry, error)
+	KVList(prefix string) ([]KVEntry, error)
+	KVDelete(key string) error
+
+	// DefineStack, Stacks and DeleteStack manage named sets of process
+	// definitions; StartStack, StopStack and RestartStack run them together.
+	DefineStack(name string, defs []StartOptions) (*Stack, error)
+	Stacks() ([]StackView, error)
+	DeleteStack(name string) error
+	StartStack(name string) ([]StackResult, error)
+	StopStack(name string) ([]ProcessView, error)
+	RestartStack(name string) ([]StackResult, error)
+
+	// StartProcfile starts every entry of a Procfile, tagged with its name
+	// as role.
+	StartProcfile(opts ProcfileOptions) ([]StackResult, error)
+
+	// StartCompose runs docker compose up and tracks each service as a
+	// process following its logs.
+	StartCompose(opts ComposeOptions) ([]StackResult, error)
+
+	// ProjectTasks lists the named tasks in a project's manifest, and RunTask
+	// starts one as a tracked process.
+	ProjectTasks(project string) ([]Task, error)
+	RunTask(project, name string, tags, env map[string]string) (*ProcessView, error)
+
+	// RunMigrations and ResetDatabase start a role=db process's configured
+	// migrate or reset command as a tracked task against its database.
+	RunMigrations(processID string) (*ProcessView, error)
+	ResetDatabase(processID string) (*ProcessView, error)
+
+	// LoadTest sends a bounded burst of GET requests to a running process's
+	// port and reports latency percentiles and errors.
+	LoadTest(ctx context.Context, processID string, opts LoadTestOptions) (*LoadTestResult, error)
+
+	// AppendThought and Thoughts manage per-project journals.
+	AppendThought(project, text string, tags map[string]string) (*Thought, error)
+	Thoughts(filter ThoughtFilter) ([]Thought, error)
+
+	// RecordToolCall and ToolCalls manage the access log of MCP tool calls.
+	RecordToolCall(c ToolCall) error
+	ToolCalls(f ToolCallFilter) ([]ToolCall, error)
+
+	// Adopt tracks processes left running by an earlier server again and
+	// records the exits of those that died unobserved.
+	Adopt() (int, error)
+
+	// Subscribe returns a channel of lifecycle events and a function that
+	// ends the subscription.
+	Subscribe() (<-chan Event, func())
+
+	// AddLogWatch registers a regex on a process or tag selector whose
+	// matches are published as log_match events.
+	AddLogWatch(pattern, processID string, tags map[string]string) (*LogWatch, error)
+
+	// LogWatches returns the registered log watches.
+	LogWatches() ([]LogWatch, error)
+
+	// RemoveLogWatch deletes a log watch.
+	RemoveLogWatch(id string) error
+
+	// AddSchedule registers a delayed or cron-driven start of opts.
+	AddSchedule(opts StartOptions, delay time.Duration, cron string) (*Schedule, error)
+
+	// AddRestartSchedule registers a cron-driven restart of a process.
+	AddRestartSchedule(processID, cron string) (*Schedule, error)
+
+	// Schedules returns the registered schedules.
+	Schedules() ([]Schedule, error)
+
+	// CancelPending cancels the next run of a schedule; one-shot schedules
+	// are deleted.
+	CancelPending(id string) (*Schedule, error)
+
+	// CancelSchedule deletes a schedule.
+	CancelSchedule(id string) error
+
+	// ErrorFingerprints returns the distinct errors a process has printed,
+	// with counts and first/last seen times.
+	ErrorFingerprints(processID string) ([]ErrorFingerprint, error)
+
+	// Shutdown runs the pre_stop hooks of all running processes, sends them
+	// their stop signals, waits up to the longest of their grace periods,
+	// then SIGKILLs any remaining, or under ShutdownKeep leaves them
+	// running. Safe to call multiple times.
 	Shutdown()
 }
@@ -0,0 +1,101 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"thought-process/store"
+)
+
+const (
+	kvKeyPrefix = "kv:"
+	// maxKVValue bounds a scratchpad value; the store is for small notes,
+	// not files.
+	maxKVValue = 64 * 1024
+)
+
+var validKVKey = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/-]{0,127}$`)
+
+// KVEntry is a value in the agents' shared scratchpad, stored under kv: keys
+// next to the process records.
+type KVEntry struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KVSet stores value under key, replacing any previous value.
+func (m *Manager) KVSet(key, value string) (*KVEntry, error) {
+	if err := validateKVKey(key); err != nil {
+		return nil, err
+	}
+	if len(value) > maxKVValue {
+		return nil, fmt.Errorf("value is %d bytes; the limit is %d", len(value), maxKVValue)
+	}
+	e := KVEntry{Key: key, Value: value, UpdatedAt: time.Now().UTC()}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Set(kvKeyPrefix+key, data); err != nil {
+		return nil, fmt.Errorf("persisting value: %w", err)
+	}
+	return &e, nil
+}
+
+// KVGet returns the entry for key.
+func (m *Manager) KVGet(key string) (*KVEntry, error) {
+	if err := validateKVKey(key); err != nil {
+		return nil, err
+	}
+	data, err := m.store.Get(kvKeyPrefix + key)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("key %q not found", key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading value: %w", err)
+	}
+	var e KVEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("decoding value: %w", err)
+	}
+	return &e, nil
+}
+
+// KVList returns the entries whose keys start with prefix, sorted by key.
+func (m *Manager) KVList(prefix string) ([]KVEntry, error) {
+	keys, err := m.store.List(kvKeyPrefix+prefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing keys: %w", err)
+	}
+	entries := make([]KVEntry, 0, len(keys))
+	for _, key := range keys {
+		e, err := m.KVGet(strings.TrimPrefix(key, kvKeyPrefix))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// KVDelete removes key. Deleting a missing key is not an error.
+func (m *Manager) KVDelete(key string) error {
+	if err := validateKVKey(key); err != nil {
+		return err
+	}
+	return m.store.Delete(kvKeyPrefix + key)
+}
+
+func validateKVKey(key string) error {
+	if !validKVKey.MatchString(key) {
+		return fmt.Errorf("invalid key %q: use up to 128 letters, digits, '.', '_', ':', '/' and '-'", key)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package process
+
+import (
+	"slices"
+	"time"
+)
+
+// maxLineageHistory is how many of the processes a lineage's latest
+// process replaced are listed in its Lineage.
+const maxLineageHistory = 10
+
+// Lineage summarizes the restarts of one logical service: the processes
+// that Restart replaced, one after another, with the latest.
+type Lineage struct {
+	ID string `json:"id"`
+	// Runs counts the processes of the lineage, the latest included.
+	Runs int `json:"runs"`
+	// Restarts counts both Restarts and restart-policy relaunches.
+	Restarts int `json:"restarts"`
+	// Previous are the most recent of the processes replaced, oldest
+	// first.
+	Previous []PreviousRun `json:"previous,omitempty"`
+}
+
+// PreviousRun is a process that was replaced by a later one of its
+// lineage.
+type PreviousRun struct {
+	ID       string     `json:"id"`
+	ExitCode *int       `json:"exit_code,omitempty"`
+	ExitedAt *time.Time `json:"exited_at,omitempty"`
+}
+
+// lineage returns info's lineage ID. Processes recorded before lineages
+// were are their own.
+func (info ProcessInfo) lineage() string {
+	if info.LineageID != "" {
+		return info.LineageID
+	}
+	return info.ID
+}
+
+// collapseLineages replaces the views of each lineage with the one started
+// last, in its place, with a Lineage drawn from infos, all records,
+// including ones the list filtered out.
+func collapseLineages(views []ProcessView, infos []ProcessInfo) []ProcessView {
+	members := make(map[string][]ProcessInfo)
+	for _, info := range infos {
+		members[info.lineage()] = append(members[info.lineage()], info)
+	}
+
+	collapsed := make([]ProcessView, 0, len(views))
+	latest := make(map[string]int)
+	for _, v := range views {
+		id := v.lineage()
+		i, ok := latest[id]
+		switch {
+		case !ok:
+			latest[id] = len(collapsed)
+			collapsed = append(collapsed, v)
+		case v.StartedAt.After(collapsed[i].StartedAt):
+			collapsed[i] = v
+		}
+	}
+
+	for _, i := range latest {
+		v := &collapsed[i]
+		runs := members[v.lineage()]
+		slices.SortFunc(runs, func(a, b ProcessInfo) int { return a.StartedAt.Compare(b.StartedAt) })
+		l := &Lineage{ID: v.lineage()}
+		var previous []PreviousRun
+		for _, run := range runs {
+			if run.StartedAt.After(v.StartedAt) {
+				break
+			}
+			l.Runs++
+			l.Restarts += run.Restarts
+			if run.ID != v.ID {
+				previous = append(previous, PreviousRun{ID: run.ID, ExitCode: run.ExitCode, ExitedAt: run.ExitedAt})
+			}
+		}
+		l.Restarts += l.Runs - 1
+		if len(previous) > maxLineageHistory {
+			previous = previous[len(previous)-maxLineageHistory:]
+		}
+		l.Previous = previous
+		v.Lineage = l
+	}
+	return collapsed
+}
@@ -0,0 +1,205 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Defaults and bounds for LoadTest; the point is a quick sanity check,
+	// not a benchmark that takes the machine down.
+	defaultLoadRequests    = 100
+	maxLoadRequests        = 10000
+	defaultLoadConcurrency = 10
+	maxLoadConcurrency     = 100
+	loadRequestTimeout     = 10 * time.Second
+	// maxLoadErrors caps the distinct error messages reported.
+	maxLoadErrors = 5
+)
+
+// LoadTestOptions describes a LoadTest run.
+type LoadTestOptions struct {
+	// Port is the process port to send requests to; its first detected or
+	// declared port if zero.
+	Port int
+	// Path is the request path, "/" if empty.
+	Path string
+	// Requests is the total number of GET requests to send.
+	Requests int
+	// Concurrency is how many requests are in flight at once.
+	Concurrency int
+}
+
+// LoadTestResult summarizes a LoadTest run. Latencies include reading the
+// response body and are only taken from requests that got a response.
+type LoadTestResult struct {
+	URL         string `json:"url"`
+	Requests    int    `json:"requests"`
+	Concurrency int    `json:"concurrency"`
+	// Errors counts requests that failed or got a 5xx response.
+	Errors         int            `json:"errors"`
+	StatusCounts   map[int]int    `json:"status_counts,omitempty"`
+	ErrorMessages  map[string]int `json:"error_messages,omitempty"`
+	DurationMS     float64        `json:"duration_ms"`
+	RequestsPerSec float64        `json:"requests_per_sec"`
+	MinMS          float64        `json:"min_ms,omitempty"`
+	MeanMS         float64        `json:"mean_ms,omitempty"`
+	P50MS          float64        `json:"p50_ms,omitempty"`
+	P90MS          float64        `json:"p90_ms,omitempty"`
+	P99MS          float64        `json:"p99_ms,omitempty"`
+	MaxMS          float64        `json:"max_ms,omitempty"`
+}
+
+// LoadTest sends opts.Requests GET requests, opts.Concurrency at a time, to a
+// running process's HTTP port and reports latency percentiles and errors.
+// Only ports the process declared or listens on can be targeted. If ctx is
+// done, the requests sent so far are reported.
+func (m *Manager) LoadTest(ctx context.Context, processID string, opts LoadTestOptions) (*LoadTestResult, error) {
+	if opts.Requests == 0 {
+		opts.Requests = defaultLoadRequests
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = defaultLoadConcurrency
+	}
+	if opts.Requests < 0 || opts.Requests > maxLoadRequests {
+		return nil, fmt.Errorf("requests must be between 1 and %d", maxLoadRequests)
+	}
+	if opts.Concurrency < 0 || opts.Concurrency > maxLoadConcurrency {
+		return nil, fmt.Errorf("concurrency must be between 1 and %d", maxLoadConcurrency)
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if !strings.HasPrefix(opts.Path, "/") {
+		return nil, errors.New("path must start with /")
+	}
+
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	view := m.view(info)
+	if view.Status != StatusRunning {
+		return nil, fmt.Errorf("process %s is %s", info.ID, view.Status)
+	}
+	ports := slices.Concat(view.ReportedPorts, view.DetectedPorts, view.Ports)
+	switch {
+	case opts.Port == 0 && len(ports) == 0:
+		return nil, fmt.Errorf("process %s has no known ports; pass port", info.ID)
+	case opts.Port == 0:
+		opts.Port = ports[0]
+	case !slices.Contains(ports, opts.Port):
+		return nil, fmt.Errorf("port %d is not used by process %s", opts.Port, info.ID)
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", opts.Port, opts.Path)
+	res := &LoadTestResult{
+		URL:           url,
+		Concurrency:   min(opts.Concurrency, opts.Requests),
+		StatusCounts:  make(map[int]int),
+		ErrorMessages: make(map[string]int),
+	}
+	client := &http.Client{
+		Timeout: loadRequestTimeout,
+		Transport: &http.Transport{
+			MaxIdleConnsPerHost: res.Concurrency,
+			DisableCompression:  true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	defer client.CloseIdleConnections()
+
+	var (
+		mu        sync.Mutex
+		latencies []float64
+		wg        sync.WaitGroup
+	)
+	jobs := make(chan struct{})
+	start := time.Now()
+	for range res.Concurrency {
+		wg.Go(func() {
+			for range jobs {
+				ms, status, err := loadRequest(ctx, client, url)
+				mu.Lock()
+				res.Requests++
+				switch {
+				case err != nil:
+					res.Errors++
+					if _, ok := res.ErrorMessages[err.Error()]; ok || len(res.ErrorMessages) < maxLoadErrors {
+						res.ErrorMessages[err.Error()]++
+					}
+				default:
+					res.StatusCounts[status]++
+					latencies = append(latencies, ms)
+					if status >= 500 {
+						res.Errors++
+					}
+				}
+				mu.Unlock()
+			}
+		})
+	}
+send:
+	for range opts.Requests {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	res.DurationMS = float64(elapsed.Microseconds()) / 1000
+	if elapsed > 0 {
+		res.RequestsPerSec = math.Round(float64(res.Requests)/elapsed.Seconds()*10) / 10
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		var sum float64
+		for _, l := range latencies {
+			sum += l
+		}
+		res.MinMS = latencies[0]
+		res.MeanMS = math.Round(sum/float64(len(latencies))*1000) / 1000
+		res.P50MS = percentile(latencies, 50)
+		res.P90MS = percentile(latencies, 90)
+		res.P99MS = percentile(latencies, 99)
+		res.MaxMS = latencies[len(latencies)-1]
+	}
+	return res, nil
+}
+
+// loadRequest sends one GET and returns its latency in milliseconds.
+func loadRequest(ctx context.Context, client *http.Client, url string) (float64, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+	return float64(time.Since(start).Microseconds()) / 1000, resp.StatusCode, nil
+}
+
+// percentile returns the p-th percentile of sorted values (nearest rank).
+func percentile(sorted []float64, p int) float64 {
+	i := (len(sorted)*p+99)/100 - 1
+	return sorted[max(i, 0)]
+}
@@ -0,0 +1,204 @@
+package process
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"thought-process/store"
+)
+
+const (
+	lockKeyPrefix = "lock:"
+	// DefaultLockTTL is how long a lock is held unless renewed or released.
+	DefaultLockTTL = 5 * time.Minute
+	// lockPoll is how often a waiting AcquireLock checks the lock again.
+	lockPoll = 250 * time.Millisecond
+)
+
+// Lock is a named lock held until its TTL runs out or it is released. Locks
+// live in the store, so every server sharing a data directory sees them.
+type Lock struct {
+	Name  string `json:"name"`
+	Owner string `json:"owner,omitempty"`
+	// Token proves ownership: it is needed to renew or release the lock.
+	Token      string    `json:"token"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LockOptions describes an AcquireLock request.
+type LockOptions struct {
+	Name string
+	// Owner says who holds the lock, for whoever finds it taken.
+	Owner string
+	// TTL is how long the lock is held; DefaultLockTTL if zero.
+	TTL time.Duration
+	// Wait is how long to wait for a held lock to be released or expire.
+	// Zero fails right away.
+	Wait time.Duration
+	// Token, if it is the current holder's, renews the lock for another TTL
+	// instead of failing.
+	Token string
+}
+
+// LockHeldError is returned by AcquireLock when someone else holds the lock.
+type LockHeldError struct {
+	Name      string
+	Owner     string
+	ExpiresAt time.Time
+}
+
+func (e *LockHeldError) Error() string {
+	owner := e.Owner
+	if owner == "" {
+		owner = "someone else"
+	}
+	return fmt.Sprintf("lock %q is held by %s until %s", e.Name, owner, e.ExpiresAt.Format(time.RFC3339))
+}
+
+// SetLockFile sets the file whose flock serializes lock updates between
+// servers sharing the store. Without one, updates are only serialized within
+// this Manager.
+func (m *Manager) SetLockFile(path string) {
+	m.lockFile = path
+}
+
+// AcquireLock takes the lock opts.Name, waiting up to opts.Wait while someone
+// else holds it. An expired lock counts as free.
+func (m *Manager) AcquireLock(ctx context.Context, opts LockOptions) (*Lock, error) {
+	if err := validateName(opts.Name); err != nil {
+		return nil, err
+	}
+	if opts.TTL < 0 || opts.Wait < 0 {
+		return nil, errors.New("ttl and wait must not be negative")
+	}
+	if opts.TTL == 0 {
+		opts.TTL = DefaultLockTTL
+	}
+
+	deadline := time.Now().Add(opts.Wait)
+	for {
+		lock, err := m.tryLock(opts)
+		var held *LockHeldError
+		if !errors.As(err, &held) || !time.Now().Before(deadline) {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(min(lockPoll, time.Until(deadline))):
+		}
+	}
+}
+
+func (m *Manager) tryLock(opts LockOptions) (*Lock, error) {
+	var lock *Lock
+	err := m.withLockFile(func() error {
+		now := time.Now().UTC()
+		current, err := m.loadLock(opts.Name)
+		if err != nil {
+			return err
+		}
+		renew := current != nil && opts.Token != "" && opts.Token == current.Token
+		if current != nil && now.Before(current.ExpiresAt) && !renew {
+			return &LockHeldError{Name: current.Name, Owner: current.Owner, ExpiresAt: current.ExpiresAt}
+		}
+
+		if renew {
+			lock = current
+			if opts.Owner != "" {
+				lock.Owner = opts.Owner
+			}
+		} else {
+			token, err := lockToken()
+			if err != nil {
+				return fmt.Errorf("generating lock token: %w", err)
+			}
+			lock = &Lock{Name: opts.Name, Owner: opts.Owner, Token: token, AcquiredAt: now}
+		}
+		lock.ExpiresAt = now.Add(opts.TTL)
+		data, err := json.Marshal(lock)
+		if err != nil {
+			return err
+		}
+		if err := m.store.Set(lockKeyPrefix+opts.Name, data); err != nil {
+			return fmt.Errorf("persisting lock: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// ReleaseLock releases the lock name held with token.
+func (m *Manager) ReleaseLock(name, token string) error {
+	return m.withLockFile(func() error {
+		current, err := m.loadLock(name)
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return fmt.Errorf("lock %q is not held", name)
+		}
+		if current.Token != token {
+			if time.Now().Before(current.ExpiresAt) {
+				return fmt.Errorf("lock %q is held by someone else", name)
+			}
+			return fmt.Errorf("lock %q expired and was taken over", name)
+		}
+		return m.store.Delete(lockKeyPrefix + name)
+	})
+}
+
+// loadLock returns the stored lock name, or nil if there is none.
+func (m *Manager) loadLock(name string) (*Lock, error) {
+	data, err := m.store.Get(lockKeyPrefix + name)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading lock: %w", err)
+	}
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("decoding lock: %w", err)
+	}
+	return &lock, nil
+}
+
+// withLockFile runs fn holding lockMu and, if set, an exclusive flock on the
+// lock file.
+func (m *Manager) withLockFile(fn func() error) error {
+	m.lockMu.Lock()
+	defer m.lockMu.Unlock()
+	if m.lockFile == "" {
+		return fn()
+	}
+	f, err := os.OpenFile(m.lockFile, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return fn()
+}
+
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,121 @@
+package process
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// logLinksRefresh is how often RunLogLinks updates the links even without
+// events, to pick up processes of other servers.
+const logLinksRefresh = 10 * time.Second
+
+// RunLogLinks keeps dir/ROLE-BRANCH.log symlinks pointing at the log of the
+// latest process with each role and branch tag, until ctx is done, so a
+// terminal can tail a stable path across restarts. Processes without a
+// role tag are linked by name; without a branch tag the link is ROLE.log.
+// Links whose processes are gone are removed.
+func (m *Manager) RunLogLinks(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	ticker := time.NewTicker(logLinksRefresh)
+	defer ticker.Stop()
+	for {
+		if err := m.writeLogLinks(dir); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-events:
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeLogLinks points each link at its latest process's log.
+func (m *Manager) writeLogLinks(dir string) error {
+	views, err := m.List(ListFilter{})
+	if err != nil {
+		return err
+	}
+	latest := make(map[string]ProcessView)
+	for _, v := range views {
+		name := logLinkName(v.ProcessInfo)
+		if name == "" || v.LogEvicted {
+			continue
+		}
+		if prev, ok := latest[name]; !ok || v.StartedAt.After(prev.StartedAt) {
+			latest[name] = v
+		}
+	}
+
+	// Log paths are resolved, so links are made relative to the resolved
+	// directory.
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	for name, v := range latest {
+		path, err := m.logPath(v.ProcessInfo)
+		if err != nil {
+			// Deleted by the process, or not a log.
+			delete(latest, name)
+			continue
+		}
+		target, err := filepath.Rel(realDir, path)
+		if err != nil {
+			target = path
+		}
+		if err := replaceSymlink(target, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, ok := latest[name]; !ok && entry.Type()&os.ModeSymlink != 0 && strings.HasSuffix(name, ".log") {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+	return nil
+}
+
+// logLinkName returns the file name of info's log link, or "" if it has
+// neither a role tag nor a name.
+func logLinkName(info ProcessInfo) string {
+	name := info.Tags["role"]
+	if name == "" {
+		name = info.Name
+	}
+	if name == "" {
+		return ""
+	}
+	if branch := info.Tags[TagBranch]; branch != "" {
+		name += "-" + branch
+	}
+	return unsafeFileChar.ReplaceAllString(name, "_") + ".log"
+}
+
+// replaceSymlink makes link a symlink to target, atomically replacing what
+// was there, unless it already is one.
+func replaceSymlink(target, link string) error {
+	if current, err := os.Readlink(link); err == nil && current == target {
+		return nil
+	}
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
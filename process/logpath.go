@@ -0,0 +1,28 @@
+package process
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// logPath returns the real path of info's log file after checking that it
+// lies inside the Manager's log directory. Records in the store are not
+// trusted: a tampered LogPath must not expose arbitrary files through
+// get_process_logs or the dashboard.
+func (m *Manager) logPath(info ProcessInfo) (string, error) {
+	dir, err := filepath.EvalSymlinks(m.logDir)
+	if err != nil {
+		return "", fmt.Errorf("resolving log directory: %w", err)
+	}
+	path, err := filepath.Abs(info.LogPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving log file: %w", err)
+	}
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return "", fmt.Errorf("resolving log file: %w", err)
+	}
+	if !within(dir, path) || path == dir {
+		return "", fmt.Errorf("log file %q is outside the log directory", info.LogPath)
+	}
+	return path, nil
+}
@@ -0,0 +1,77 @@
+package process
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"time"
+)
+
+const (
+	// logScanInterval is how often a running process's new output is read
+	// for log watches, error fingerprints and log spikes.
+	logScanInterval = time.Second
+	// maxScanLine bounds how much of an unterminated line is kept between
+	// reads; longer lines are handled in pieces.
+	maxScanLine = 64 * 1024
+)
+
+// scanLogs reads each line info's process writes, checking it against the
+// log watches that apply, extracting error fingerprints and watching the
+// rate of output for spikes, until the process exits. Output from before the
+// call is not read.
+func (m *Manager) scanLogs(info ProcessInfo, rp *runningProc) {
+	path, err := m.logPath(info)
+	if err != nil {
+		return
+	}
+	tail, err := OpenLogTail(path, 0)
+	if err != nil {
+		return
+	}
+	defer tail.Close()
+
+	ticker := time.NewTicker(logScanInterval)
+	defer ticker.Stop()
+	compiled := make(map[string]*regexp.Regexp)
+	errs := &errorExtractor{}
+	rate := newLogRate(time.Now())
+	var partial []byte
+	for {
+		exited := false
+		select {
+		case <-rp.done:
+			// Read the output written just before the exit too.
+			exited = true
+		case <-ticker.C:
+		}
+
+		data, _ := io.ReadAll(tail)
+		data = append(partial, data...)
+		lines := bytes.Split(data, []byte("\n"))
+		partial = lines[len(lines)-1]
+		lines = lines[:len(lines)-1]
+		if exited || len(partial) > maxScanLine {
+			lines = append(lines, partial)
+			partial = nil
+		}
+
+		if len(lines) > 0 {
+			m.checkWatches(info, lines, compiled)
+		}
+		if !exited {
+			m.checkLogRate(info.ID, rate, len(lines))
+			m.checkPrecursors(info.ID, rp, lines)
+		}
+		blocks := errs.feed(lines)
+		if exited {
+			blocks = append(blocks, errs.flush()...)
+		}
+		if len(blocks) > 0 {
+			m.recordErrors(info, blocks)
+		}
+		if exited {
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// DefaultLogSpikeFactor is how many times its usual rate of output a
+	// process must write to raise a log_spike alert, unless
+	// SetLogSpikeFactor says otherwise.
+	DefaultLogSpikeFactor = 10
+	// minLogSpikeRate is the fewest lines per minute that count as a spike,
+	// so a quiet process printing a few more lines doesn't raise one.
+	minLogSpikeRate = 600
+	// minLogBaseline is the lowest usual rate, in lines per minute, a spike
+	// is measured against; a silent process's baseline would otherwise be
+	// zero.
+	minLogBaseline = 10
+	// logSpikeWarmup is how long a process runs before spikes are looked
+	// for, since startup output is bursty.
+	logSpikeWarmup = time.Minute
+	// logBaselineWeight is how much each finished minute moves the
+	// baseline, as an exponentially weighted moving average.
+	logBaselineWeight = 0.2
+)
+
+// SetLogSpikeFactor sets how many times its usual lines per minute a
+// process must write to raise a log_spike alert. Zero turns the alerts off.
+func (m *Manager) SetLogSpikeFactor(factor int) error {
+	if factor < 0 {
+		return errors.New("log spike factor must not be negative")
+	}
+	if factor == 1 {
+		return errors.New("log spike factor must be 0 or at least 2")
+	}
+	m.mu.Lock()
+	m.logSpikeFactor = factor
+	m.mu.Unlock()
+	return nil
+}
+
+// logRate tracks how many lines a process writes per minute, against a
+// baseline of its usual rate.
+type logRate struct {
+	started time.Time
+	// buckets holds the lines counted in each of the last 60 scans, about a
+	// second apart; total is their sum.
+	buckets [60]int
+	next    int
+	total   int
+	// minute counts the lines since minuteStart, for the baseline.
+	minute      int
+	minuteStart time.Time
+	baseline    float64
+	warm        bool
+	// spiking is set while the rate is over the alert threshold.
+	spiking bool
+}
+
+func newLogRate(now time.Time) *logRate {
+	return &logRate{started: now, minuteStart: now}
+}
+
+// add records n lines read at now and returns the rate over the last
+// minute, the baseline, and whether a spike has just begun.
+func (r *logRate) add(n int, now time.Time, factor int) (rate int, baseline int, spike bool) {
+	r.total += n - r.buckets[r.next]
+	r.buckets[r.next] = n
+	r.next = (r.next + 1) % len(r.buckets)
+	r.minute += n
+
+	base := max(r.baseline, minLogBaseline)
+	over := factor > 0 && r.warm && r.total >= minLogSpikeRate && float64(r.total) >= float64(factor)*base
+	spike = over && !r.spiking
+	r.spiking = over
+
+	if now.Sub(r.minuteStart) >= time.Minute {
+		// The baseline doesn't learn from a spike, so it can end.
+		if !r.spiking {
+			if r.warm {
+				r.baseline += logBaselineWeight * (float64(r.minute) - r.baseline)
+			} else {
+				r.baseline = float64(r.minute)
+			}
+		}
+		r.warm = r.warm || now.Sub(r.started) >= logSpikeWarmup
+		r.minute = 0
+		r.minuteStart = now
+	}
+	return r.total, int(base), spike
+}
+
+// checkLogRate counts n more lines from the process id and raises a
+// log_spike alert when its rate jumps to the spike factor times its usual
+// rate. It fires once per spike: the rate must fall back below the
+// threshold before it can fire another.
+func (m *Manager) checkLogRate(id string, r *logRate, n int) {
+	m.mu.Lock()
+	factor := m.logSpikeFactor
+	m.mu.Unlock()
+
+	rate, baseline, spike := r.add(n, time.Now(), factor)
+	if !spike {
+		return
+	}
+	m.recordAlert(id, Alert{
+		Kind:             AlertLogSpike,
+		Time:             time.Now().UTC(),
+		Message:          fmt.Sprintf("writing %d lines/min, %d× its usual %d lines/min", rate, rate/baseline, baseline),
+		LinesPerMin:      rate,
+		UsualLinesPerMin: baseline,
+	})
+}
@@ -0,0 +1,91 @@
+package process
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// LogTail reads a process's log as it is written. Some tools truncate or
+// rotate their own output: after a truncation the tail starts over at the
+// beginning of the file, and once a new file has replaced the log at its
+// path, the tail finishes reading the old one and moves on to it.
+type LogTail struct {
+	path   string
+	f      *os.File
+	offset int64
+}
+
+// OpenLogTail opens the log at path for tailing, starting with the last
+// bytes of what it already holds: 0 for none of it, -1 for all of it.
+func OpenLogTail(path string, last int64) (*LogTail, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &LogTail{path: path, f: f}
+	if last < 0 {
+		return t, nil
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	t.offset = max(stat.Size()-last, 0)
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// Read reads output written since the last Read, returning io.EOF when
+// there is none yet.
+func (t *LogTail) Read(p []byte) (int, error) {
+	for {
+		n, err := t.f.Read(p)
+		t.offset += int64(n)
+		if n > 0 || (err != nil && !errors.Is(err, io.EOF)) {
+			return n, err
+		}
+		if !t.follow() {
+			return 0, io.EOF
+		}
+	}
+}
+
+// follow checks, once the file has been read to its end, whether it was
+// truncated or replaced, and if so repositions the tail. It reports whether
+// there may be more to read.
+func (t *LogTail) follow() bool {
+	stat, err := t.f.Stat()
+	if err != nil {
+		return false
+	}
+	if stat.Size() < t.offset {
+		if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+			return false
+		}
+		t.offset = 0
+		return true
+	}
+	// A log deleted without a replacement is still written to by the
+	// process, so keep reading it.
+	current, err := os.Stat(t.path)
+	if err != nil || os.SameFile(stat, current) {
+		return false
+	}
+	f, err := os.Open(t.path)
+	if err != nil {
+		return false
+	}
+	t.f.Close()
+	t.f, t.offset = f, 0
+	return true
+}
+
+// Close closes the file being read.
+func (t *LogTail) Close() error {
+	return t.f.Close()
+}
@@ -1,19 +1,26 @@
 package process
 
 import (
+	"cmp"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"thought-process/notifiers"
+	"thought-process/secrets"
 	"thought-process/store"
 )
 
@@ -25,141 +32,654 @@ const (
 // Manager manages subprocesses, persisting metadata in a Store and capturing
 // output to log files.
 type Manager struct {
-	store  store.Store
-	logDir string
+	store   store.Store
+	logDir  string
+	secrets atomic.Pointer[secrets.Resolver]
+	// defaults are merged into every Start's tags and env.
+	defaults atomic.Pointer[Defaults]
+	// retention is what RunRetention keeps; nil means DefaultRetention.
+	retention atomic.Pointer[Retention]
+	// webhooks are what RunWebhooks delivers events to.
+	webhooks atomic.Pointer[[]Webhook]
+	// notifiers are what RunNotifiers sends to.
+	notifiers atomic.Pointer[notifiers.Set]
+	// desktopNotify turns on RunDesktopNotifications.
+	desktopNotify atomic.Bool
+	// portRange is where AllocatePorts draws from. Guarded by storeMu.
+	portRange PortRange
+	// memoryAlert is the RSS above which a process raises a high_memory
+	// alert; zero disables it. Guarded by mu.
+	memoryAlert int64
+	// logSpikeFactor is how many times its usual output rate a process must
+	// write to raise a log_spike alert; zero disables it. Guarded by mu.
+	logSpikeFactor int
+	// shutdownPolicy is what Shutdown does with running processes. Guarded
+	// by mu.
+	shutdownPolicy ShutdownPolicy
 
-	mu      sync.Mutex
-	running map[string]*exec.Cmd // id -> cmd for live processes
+	mu       sync.Mutex
+	running  map[string]*runningProc // id -> live (or restarting) process
+	shutdown bool
+	// woke is closed, and replaced, when the system wakes from sleep.
+	woke chan struct{}
+
+	// storeMu serializes read-modify-write updates of process records.
+	storeMu sync.Mutex
+	// schedMu does the same for schedules.
+	schedMu sync.Mutex
+	// lockMu serializes lock updates; lockFile, if set, is flocked around
+	// them so other servers are serialized too.
+	lockMu   sync.Mutex
+	lockFile string
+	// controlSocket is passed to processes so they can Report; see
+	// SetControlSocket.
+	controlSocket string
+
+	subsMu sync.Mutex
+	subs   map[chan Event]struct{}
 
 	once sync.Once
 }
 
+// runningProc tracks a process owned by this Manager.
+type runningProc struct {
+	cmd *exec.Cmd
+	// stdin is the write end of the current process's stdin pipe.
+	stdin io.WriteCloser
+	// logFile is the process's log, shared with it so writes by the
+	// Manager land after its output. Nil for adopted processes.
+	logFile *os.File
+	// stopped suppresses the restart policy once Kill or Shutdown is called.
+	stopped bool
+	// reloading is set while a file watch stops the process, so that the
+	// wait loop starts it again whatever the restart policy.
+	reloading bool
+	// exitReason is set when the Manager itself stops the process, e.g.
+	// ExitReasonMaxRuntime.
+	exitReason string
+	// adopted marks a process started by an earlier server, which this one
+	// only watches (see Adopt).
+	adopted bool
+	// done is closed when the process has exited and will not be restarted.
+	done chan struct{}
+
+	health         HealthStatus
+	healthFailures int
+	// rssBytes and cpuPercent are the process group's last sampled memory
+	// and CPU use.
+	rssBytes   int64
+	cpuPercent float64
+	// memoryAlerted is set while rssBytes is over the memory alert
+	// threshold, so each crossing alerts once.
+	memoryAlerted bool
+	// exitWarned holds the precursor reasons already warned about in the
+	// current run.
+	exitWarned map[string]bool
+	// detectedPorts are the TCP ports the process group was last seen
+	// listening on.
+	detectedPorts []int
+	// container is the last read state of a compose service's container.
+	container *ContainerState
+}
+
+const (
+	// restartDelay is how long the Manager waits before restarting a process.
+	restartDelay = time.Second
+
+	// A process that exits crashLoopThreshold times within crashLoopWindow is
+	// considered crash looping and is not restarted again.
+	crashLoopThreshold = 5
+	crashLoopWindow    = time.Minute
+
+	// maxRecentExitCodes bounds ProcessInfo.RecentExitCodes.
+	maxRecentExitCodes = 5
+)
+
 // NewManager creates a Manager that persists process metadata in store and
 // writes log files to logDir.
 func NewManager(store store.Store, logDir string) *Manager {
-	return &Manager{
-		store:   store,
-		logDir:  logDir,
-		running: make(map[string]*exec.Cmd),
+	m := &Manager{
+		store:     store,
+		logDir:    logDir,
+		portRange: DefaultPortRange,
+		running:   make(map[string]*runningProc),
+		subs:      make(map[chan Event]struct{}),
+		woke:      make(chan struct{}),
+
+		memoryAlert:    DefaultMemoryAlert,
+		logSpikeFactor: DefaultLogSpikeFactor,
+		shutdownPolicy: ShutdownStop,
 	}
+	m.secrets.Store(secrets.NewResolver(secrets.Config{}))
+	return m
 }
 
-// Start launches a subprocess and returns its ProcessView.
-func (m *Manager) Start(command string, args []string, cwd string, env map[string]string, tags map[string]string, ports []int) (*ProcessView, error) {
+// Start launches a subprocess and returns its ProcessView. If opts.Name is
+// taken by a running process, it returns that process's view and an error
+// wrapping ErrNameTaken. If a declared port is already used by another
+// running process, it returns a *PortConflictError. Unless opts.Force is set,
+// a running process with the same command, args, cwd and tags is returned
+// with Duplicate set instead of starting another copy.
+func (m *Manager) Start(opts StartOptions) (*ProcessView, error) {
+	switch opts.Restart {
+	case "", RestartNever, RestartOnFailure, RestartAlways:
+	default:
+		return nil, fmt.Errorf("unknown restart policy %q", opts.Restart)
+	}
+	if opts.HealthCheck != nil {
+		if err := opts.HealthCheck.validate(); err != nil {
+			return nil, err
+		}
+	}
+	cwd, err := m.resolveCwd(opts.Cwd)
+	if err != nil {
+		return nil, err
+	}
+	if cwd, err = checkCwd(cwd, opts.CreateCwd); err != nil {
+		return nil, err
+	}
+	if d := m.defaults.Load(); d != nil {
+		opts.Tags = withDefaults(d.Tags, opts.Tags)
+		opts.Env = withDefaults(d.Env, opts.Env)
+	}
+	var autoTagged []string
+	opts.Tags, autoTagged = autoTags(opts.Tags, cwd)
+	if opts.Name != "" {
+		if err := validateName(opts.Name); err != nil {
+			return nil, err
+		}
+	}
+	if opts.MaxRuntimeSecs < 0 {
+		return nil, fmt.Errorf("max_runtime_secs must not be negative")
+	}
+	if opts.IdleTimeoutSecs < 0 {
+		return nil, fmt.Errorf("idle_timeout_secs must not be negative")
+	}
+	stopSignal, err := normalizeSignal(opts.StopSignal)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateStopGrace(opts.StopGraceSecs); err != nil {
+		return nil, err
+	}
+	if err := validatePreStop(opts.PreStop, opts.PreStopTimeoutSecs); err != nil {
+		return nil, err
+	}
+	if opts.OnExit != nil {
+		if err := opts.OnExit.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Watch != nil {
+		if err := opts.Watch.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := validatePriority(opts.Nice, opts.IOClass); err != nil {
+		return nil, err
+	}
+	envFiles, err := resolveEnvFiles(opts.EnvFiles, cwd)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Database != nil {
+		if err := opts.Database.validate(opts.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if opts.AllocatePorts < 0 || opts.AllocatePorts > maxAllocatePorts {
+		return nil, fmt.Errorf("allocate_ports must be between 0 and %d", maxAllocatePorts)
+	}
+	if opts.InContainer != nil {
+		spec, err := opts.InContainer.validate(cwd)
+		if err != nil {
+			return nil, err
+		}
+		hostPorts, _ := spec.hostPorts()
+		for _, p := range hostPorts {
+			if !slices.Contains(opts.Ports, p) {
+				opts.Ports = append(slices.Clone(opts.Ports), p)
+			}
+		}
+		opts.InContainer = spec
+	}
+	if opts.DependsTimeoutSecs < 0 {
+		return nil, fmt.Errorf("depends_timeout_secs must not be negative")
+	}
+	if opts.Name != "" && slices.Contains(opts.DependsOn, opts.Name) {
+		return nil, fmt.Errorf("process %q can't depend on itself", opts.Name)
+	}
+	dependsTimeout := defaultDependsTimeout
+	if opts.DependsTimeoutSecs > 0 {
+		dependsTimeout = time.Duration(opts.DependsTimeoutSecs) * time.Second
+	}
+	// Wait before taking storeMu so other Starts, including the
+	// dependencies' own restarts, aren't blocked meanwhile.
+	if err := m.waitForDependencies(opts.DependsOn, dependsTimeout); err != nil {
+		return nil, err
+	}
+	// Likewise run git for ${BRANCH} and ${WORKTREE}, and look up secrets,
+	// now.
+	vars, err := gitVars(cwd, slices.Concat([]string{opts.Command}, opts.Args, slices.Collect(maps.Values(opts.Env)))...)
+	if err != nil {
+		return nil, fmt.Errorf("starting process: %w", err)
+	}
+	resolved, err := m.resolveSecrets(&expander{vars: vars}, opts.Env, envFiles)
+	if err != nil {
+		return nil, fmt.Errorf("starting process: %w", err)
+	}
+	if !opts.Force || opts.Name != "" || len(opts.Ports) > 0 || opts.AllocatePorts > 0 {
+		// Hold storeMu until the new record is persisted so two Starts can't
+		// both claim the same name or port, or both miss a duplicate.
+		m.storeMu.Lock()
+		defer m.storeMu.Unlock()
+	}
+	if !opts.Force {
+		existing, ok, err := m.findDuplicate(opts, cwd)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			view := m.view(existing)
+			view.Duplicate = true
+			return &view, nil
+		}
+	}
+	if opts.Name != "" {
+		existing, ok, err := m.findByName(opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if view := m.view(existing); view.Status == StatusRunning || view.Status == StatusPaused {
+				return &view, fmt.Errorf("%w: %q is process %s", ErrNameTaken, opts.Name, existing.ID)
+			}
+		}
+	}
+	if err := m.checkPorts(opts.Ports); err != nil {
+		return nil, err
+	}
+	var allocated []int
+	if opts.AllocatePorts > 0 {
+		if allocated, err = m.allocatePorts(opts.AllocatePorts, opts.Ports); err != nil {
+			return nil, err
+		}
+	}
+
 	id, err := generateID()
 	if err != nil {
 		return nil, fmt.Errorf("generating process ID: %w", err)
 	}
+	token, err := generateReportToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating report token: %w", err)
+	}
 
+	// Appending keeps writes at the end of the file after the process
+	// truncates it, instead of leaving a gap of zeros.
 	logPath := filepath.Join(m.logDir, id+".log")
-	logFile, err := os.Create(logPath)
+	logFile, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o666)
 	if err != nil {
 		return nil, fmt.Errorf("creating log file: %w", err)
 	}
 
-	shell := userShell()
-	shellCmd := command
-	if len(args) > 0 {
-		for _, a := range args {
-			shellCmd += " " + shellQuote(a)
-		}
-	}
+	info := ProcessInfo{
+		ID:       id,
+		Name:     opts.Name,
+		Command:  opts.Command,
+		Args:     opts.Args,
+		Cwd:      cwd,
+		Env:      opts.Env,
+		Tags:     opts.Tags,
+		AutoTags: autoTagged,
 
-	cmd := exec.Command(shell, "-c", shellCmd)
-	cmd.Stdout = logFile
-	cmd.Stderr = logFile
-	cmd.Dir = cwd
-	// Start with the current environment and add any custom env vars.
-	if len(env) > 0 {
-		cmd.Env = os.Environ()
-		for k, v := range env {
-			cmd.Env = append(cmd.Env, k+"="+v)
-		}
+		ReportToken: token,
+		Ports:       slices.Concat(opts.Ports, allocated),
+		LogPath:     logPath,
+		Restart:     opts.Restart,
+		PTY:         opts.PTY,
+
+		AllocatedPorts: allocated,
+		HealthCheck:    opts.HealthCheck,
+
+		MaxRuntimeSecs:  opts.MaxRuntimeSecs,
+		IdleTimeoutSecs: opts.IdleTimeoutSecs,
+		StopSignal:      stopSignal,
+		StopGraceSecs:   opts.StopGraceSecs,
+		ScheduleID:      opts.scheduleID,
+		PreviousID:      opts.previousID,
+		LineageID:       cmp.Or(opts.lineageID, id),
+
+		PreStop:            opts.PreStop,
+		PreStopTimeoutSecs: opts.PreStopTimeoutSecs,
+		OnExit:             opts.OnExit,
+		Watch:              opts.Watch,
+
+		Nice:     opts.Nice,
+		IOClass:  opts.IOClass,
+		CleanEnv: opts.CleanEnv,
+		EnvFiles: envFiles,
+		Exec:     opts.Exec,
+
+		DependsOn:   opts.DependsOn,
+		Stack:       opts.stack,
+		Compose:     opts.compose,
+		InContainer: opts.InContainer,
+		Database:    opts.Database,
+		DatabaseID:  opts.databaseID,
 	}
-	// Detach the child into its own process group so it isn't killed when the
-	// MCP server's stdin is closed.
-	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	if err := cmd.Start(); err != nil {
+	tmpl := newExpander(&info)
+	maps.Copy(tmpl.vars, vars)
+	tmpl.secrets = resolved
+	cmd, stdin, err := m.spawn(&info, tmpl, logFile)
+	if err != nil {
 		logFile.Close()
 		return nil, fmt.Errorf("starting process: %w", err)
 	}
 
-	info := ProcessInfo{
-		ID:        id,
-		Command:   command,
-		Args:      args,
-		Cwd:       cwd,
-		Env:       env,
-		Tags:      tags,
-		Ports:     ports,
-		PID:       cmd.Process.Pid,
-		StartedAt: time.Now().UTC(),
-		LogPath:   logPath,
-	}
-
 	if err := m.persist(info); err != nil {
 		cmd.Process.Kill()
 		logFile.Close()
 		return nil, fmt.Errorf("persisting process info: %w", err)
 	}
 
+	rp := &runningProc{cmd: cmd, stdin: stdin, logFile: logFile, done: make(chan struct{})}
+	if info.HealthCheck != nil {
+		rp.health = HealthStarting
+	}
 	m.mu.Lock()
-	m.running[id] = cmd
+	m.running[id] = rp
 	m.mu.Unlock()
 
 	// Wait for the process to exit in the background and record the result.
-	go func() {
-		defer logFile.Close()
-		waitErr := cmd.Wait()
+	go m.wait(info, rp, logFile)
+	go m.watchPorts(rp)
+	go m.watchUsage(info.ID, rp)
+	go m.scanLogs(info, rp)
+	if info.HealthCheck != nil {
+		go m.watchHealth(info, rp)
+	}
+	if info.MaxRuntimeSecs > 0 {
+		go m.watchRuntime(info.ID, rp, time.Duration(info.MaxRuntimeSecs)*time.Second)
+	}
+	if info.IdleTimeoutSecs > 0 {
+		go m.watchIdle(info, rp, time.Duration(info.IdleTimeoutSecs)*time.Second)
+	}
+	if info.Compose != nil || info.InContainer != nil {
+		go m.watchContainer(info, rp)
+	}
+	if info.Watch != nil {
+		go m.watchFiles(info, rp)
+	}
+	m.publish(EventStarted, info)
 
-		m.mu.Lock()
-		delete(m.running, id)
-		m.mu.Unlock()
+	view := m.view(info)
+	return &view, nil
+}
+
+// spawn starts the command described by info with output going to logFile;
+// see command and start.
+func (m *Manager) spawn(info *ProcessInfo, tmpl *expander, logFile *os.File) (*exec.Cmd, io.WriteCloser, error) {
+	cmd, err := m.command(info, tmpl)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m.start(info, cmd, logFile)
+}
+
+// command builds the command that runs info, with its placeholders expanded
+// by tmpl and its secrets resolved. Both may run other programs (git, op)
+// that take a while, so it must not be called with m.mu held.
+func (m *Manager) command(info *ProcessInfo, tmpl *expander) (*exec.Cmd, error) {
+	command, err := tmpl.expand(info.Command)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(info.Args))
+	for i, a := range info.Args {
+		if args[i], err = tmpl.expand(a); err != nil {
+			return nil, err
+		}
+	}
+
+	environ, added, err := m.environ(info, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	shellCmd := command
+	for _, a := range args {
+		shellCmd += " " + shellQuote(a)
+	}
+	var cmd *exec.Cmd
+	switch {
+	case info.InContainer != nil:
+		// The runtime's CLI runs on the host and passes the added variables
+		// on to the container.
+		var inner []string
+		switch {
+		case command == "":
+		case info.Exec:
+			inner = append([]string{command}, args...)
+		default:
+			inner = []string{"sh", "-c", shellCmd}
+		}
+		keys := make([]string, len(added))
+		for i, kv := range added {
+			keys[i], _, _ = strings.Cut(kv, "=")
+		}
+		cmd = exec.Command(info.InContainer.Runtime, info.InContainer.runArgs(info, inner, keys)...)
+	case info.Exec:
+		cmd = exec.Command(command, args...)
+	default:
+		cmd = exec.Command(userShell(), "-c", shellCmd)
+	}
+	cmd.Dir = info.Cwd
+	cmd.Env = environ
+	// Detach the child into its own process group so it isn't killed when the
+	// MCP server's stdin is closed.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd, nil
+}
+
+// start starts cmd, built by command, with output going to logFile,
+// recording the new PID and start time in info. It returns the write end of
+// the process's stdin, which is closed once the process exits, or the pty
+// master in PTY mode.
+func (m *Manager) start(info *ProcessInfo, cmd *exec.Cmd, logFile *os.File) (*exec.Cmd, io.WriteCloser, error) {
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	var stdin io.WriteCloser
+	if info.PTY {
+		p, err := startPTY(cmd, logFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		stdin = p
+	} else {
+		pipe, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, nil, err
+		}
+		stdin = pipe
+	}
+
+	info.PID = cmd.Process.Pid
+	info.PIDStart, _ = processStartTime(info.PID)
+	info.StartedAt = time.Now().UTC()
+	info.StartedClock = clockNow()
+
+	if info.Nice != 0 || info.IOClass != "" {
+		if err := setGroupPriority(info.PID, info.Nice, info.IOClass); err != nil {
+			_ = signalGroup(info.PID, syscall.SIGKILL)
+			stdin.Close()
+			cmd.Wait()
+			return nil, nil, fmt.Errorf("setting priority: %w", err)
+		}
+	}
+	return cmd, stdin, nil
+}
+
+// wait records each exit of the process and applies its restart policy until
+// the process exits for good.
+func (m *Manager) wait(info ProcessInfo, rp *runningProc, logFile *os.File) {
+	defer logFile.Close()
+	defer close(rp.done)
+
+	var exits []time.Time
+	cmd := rp.cmd
+	for {
+		_ = cmd.Wait()
+		if p, ok := rp.stdin.(*ptyConn); ok {
+			p.drain()
+		}
 
 		now := time.Now().UTC()
-		info.ExitedAt = &now
+		exitedClock := clockNow()
 		code := cmd.ProcessState.ExitCode()
-		info.ExitCode = &code
+
+		m.mu.Lock()
+		stopped := rp.stopped || m.shutdown
+		reloading := rp.reloading && !stopped
+		rp.reloading = false
+		reason := rp.exitReason
+		var oom *Alert
+		if !stopped && oomKilled(cmd.ProcessState) {
+			a := oomAlert(rp.rssBytes)
+			oom = &a
+		}
+		m.mu.Unlock()
+
+		// Only count exits inside the crash-loop window; reloads aren't
+		// crashes.
+		if !reloading {
+			exits = append(exits, now)
+		}
+		for len(exits) > 0 && now.Sub(exits[0]) > crashLoopWindow {
+			exits = exits[1:]
+		}
+
+		restart := reloading || info.Restart == RestartAlways || (info.Restart == RestartOnFailure && code != 0)
+		crashLooping := restart && !reloading && len(exits) >= crashLoopThreshold
+		if crashLooping {
+			restart = false
+		}
 
 		// Best-effort update; ignore store errors.
-		_ = m.persist(info)
-		_ = waitErr
-	}()
-
-	return &ProcessView{
-		ProcessInfo: info,
-		Status:      StatusRunning,
-	}, nil
+		if updated, err := m.update(info.ID, func(p *ProcessInfo) {
+			p.ExitedAt = &now
+			p.ExitedClock = exitedClock
+			p.ExitCode = &code
+			p.Killed = (stopped || reloading) && reason == ""
+			p.ExitReason = reason
+			p.Paused = false
+			p.RecentExitCodes = append(p.RecentExitCodes, code)
+			if len(p.RecentExitCodes) > maxRecentExitCodes {
+				p.RecentExitCodes = p.RecentExitCodes[len(p.RecentExitCodes)-maxRecentExitCodes:]
+			}
+			p.CrashLooping = crashLooping
+			p.ReadyAt = nil
+			p.ReportedPorts = nil
+			p.Warning = nil
+			if oom != nil {
+				p.Alerts = appendAlert(p.Alerts, *oom)
+			}
+			if restart && !stopped {
+				// The relaunch below picks up a pending env change.
+				p.Env = p.PendingEnv.apply(p.Env)
+				p.PendingEnv = nil
+			}
+		}); err == nil {
+			info = updated
+		}
+
+		ev := exitEvent(code, stopped || reloading, crashLooping, reason)
+		m.publish(ev, info)
+		if oom != nil {
+			m.publishEvent(Event{Type: EventOOMKilled, Time: oom.Time, Process: m.view(info), Alert: oom})
+		}
+		m.runOnExit(info, ev, &code, logFile)
+
+		if restart && !reloading {
+			time.Sleep(restartDelay)
+		}
+
+		m.mu.Lock()
+		if !restart || rp.stopped || m.shutdown {
+			delete(m.running, info.ID)
+			m.mu.Unlock()
+			return
+		}
+		m.mu.Unlock()
+
+		// Build the command without m.mu, then check again that Kill or
+		// Shutdown didn't come in meanwhile and start it under the lock, so
+		// they always see the incarnation they have to stop.
+		next, err := m.command(&info, newExpander(&info))
+		m.mu.Lock()
+		if rp.stopped || m.shutdown {
+			delete(m.running, info.ID)
+			m.mu.Unlock()
+			return
+		}
+		var stdin io.WriteCloser
+		if err == nil {
+			next, stdin, err = m.start(&info, next, logFile)
+		}
+		if err != nil {
+			delete(m.running, info.ID)
+			m.mu.Unlock()
+			fmt.Fprintf(logFile, "thought-process: restart failed: %v\n", err)
+			return
+		}
+		rp.cmd = next
+		rp.stdin = stdin
+		rp.detectedPorts = nil
+		rp.memoryAlerted = false
+		rp.exitWarned = nil
+		if info.HealthCheck != nil {
+			rp.health = HealthStarting
+			rp.healthFailures = 0
+		}
+		m.mu.Unlock()
+
+		cmd = next
+		if updated, err := m.update(info.ID, func(p *ProcessInfo) {
+			p.PID = info.PID
+			p.PIDStart = info.PIDStart
+			p.StartedAt = info.StartedAt
+			p.StartedClock = info.StartedClock
+			p.Restarts++
+			p.ExitCode = nil
+			p.ExitedAt = nil
+			p.ExitedClock = nil
+			p.Killed = false
+		}); err == nil {
+			info = updated
+		}
+		m.publish(EventRestarted, info)
+	}
 }
 
 // List returns tracked processes with their current status, filtered by f.
 func (m *Manager) List(f ListFilter) ([]ProcessView, error) {
-	keys, err := m.store.List(keyPrefix, 0)
+	infos, err := m.records()
 	if err != nil {
-		return nil, fmt.Errorf("listing process keys: %w", err)
-	}
-
-	var cutoff time.Time
-	if f.ExitedSinceSecs > 0 {
-		cutoff = time.Now().UTC().Add(-time.Duration(f.ExitedSinceSecs) * time.Second)
+		return nil, err
 	}
 
-	views := make([]ProcessView, 0, len(keys))
-	for _, key := range keys {
-		raw, err := m.store.Get(key)
-		if err != nil {
-			continue
-		}
-		var info ProcessInfo
-		if err := json.Unmarshal([]byte(raw), &info); err != nil {
-			continue
-		}
-		status := m.status(info)
+	views := make([]ProcessView, 0, len(infos))
+	for _, info := range infos {
+		view := m.view(info)
 
 		// Filter out exited/failed processes older than the cutoff.
-		if !cutoff.IsZero() && (status == StatusExited || status == StatusFailed) {
-			if info.ExitedAt != nil && info.ExitedAt.Before(cutoff) {
+		if f.ExitedSinceSecs > 0 && (view.Status == StatusExited || view.Status == StatusFailed) {
+			if info.ExitedAt != nil && view.ExitedSecsAgo > int64(f.ExitedSinceSecs) {
 				continue
 			}
 		}
@@ -178,149 +698,339 @@ func (m *Manager) List(f ListFilter) ([]ProcessView, error) {
 			}
 		}
 
-		views = append(views, ProcessView{
-			ProcessInfo: info,
-			Status:      status,
-		})
+		if f.IncludeTree && view.Status == StatusRunning {
+			view.Descendants = descendants(info.PID)
+		}
+
+		views = append(views, view)
+	}
+	if f.CollapseLineage {
+		views = collapseLineages(views, infos)
+	}
+
+	if f.IncludePending {
+		pending, err := m.pendingViews()
+		if err != nil {
+			return nil, err
+		}
+		for _, view := range pending {
+			if matchTags(view.Tags, f.Tags) {
+				views = append(views, view)
+			}
+		}
 	}
 	return views, nil
 }
 
 // GetLogs returns the last ~100KB of a process's log file.
 func (m *Manager) GetLogs(processID string) (string, error) {
-	raw, err := m.store.Get(keyPrefix + processID)
+	info, err := m.lookup(processID)
 	if err != nil {
-		return "", fmt.Errorf("process %q not found", processID)
+		return "", err
 	}
-	var info ProcessInfo
-	if err := json.Unmarshal([]byte(raw), &info); err != nil {
-		return "", fmt.Errorf("decoding process info: %w", err)
+	if info.LogEvicted {
+		return "", fmt.Errorf("the log of process %s was deleted to stay within the storage quota", info.ID)
 	}
 
-	f, err := os.Open(info.LogPath)
-	if err != nil {
-		return "", fmt.Errorf("opening log file: %w", err)
+	var f *os.File
+	path, err := m.logPath(info)
+	if err == nil {
+		f, err = os.Open(path)
+		if err != nil {
+			err = fmt.Errorf("opening log file: %w", err)
+		}
+	}
+	switch {
+	case err == nil:
+		defer f.Close()
+	case errors.Is(err, os.ErrNotExist) && m.liveLogFile(info.ID) != nil:
+		// The process deleted its log, but still writes to the file.
+		f = m.liveLogFile(info.ID)
+	default:
+		return "", err
 	}
-	defer f.Close()
 
 	stat, err := f.Stat()
 	if err != nil {
 		return "", fmt.Errorf("stat log file: %w", err)
 	}
 
-	offset := int64(0)
-	if stat.Size() > maxLogRead {
-		offset = stat.Size() - maxLogRead
-	}
-	if offset > 0 {
-		if _, err := f.Seek(offset, io.SeekStart); err != nil {
-			return "", fmt.Errorf("seeking log file: %w", err)
-		}
-	}
-
-	data, err := io.ReadAll(f)
+	// Read at an offset rather than seeking, which would move a live log
+	// file's position.
+	offset := max(stat.Size()-maxLogRead, 0)
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, stat.Size()-offset))
 	if err != nil {
 		return "", fmt.Errorf("reading log file: %w", err)
 	}
 	return string(data), nil
 }
 
+// liveLogFile returns the log file a running process writes to, or nil.
+func (m *Manager) liveLogFile(id string) *os.File {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rp, ok := m.running[id]; ok {
+		return rp.logFile
+	}
+	return nil
+}
+
 // GetLogPath returns the path to a process's log file for streaming.
 func (m *Manager) GetLogPath(processID string) (string, error) {
-	raw, err := m.store.Get(keyPrefix + processID)
+	info, err := m.lookup(processID)
 	if err != nil {
-		return "", fmt.Errorf("process %q not found", processID)
-	}
-	var info ProcessInfo
-	if err := json.Unmarshal([]byte(raw), &info); err != nil {
-		return "", fmt.Errorf("decoding process info: %w", err)
+		return "", err
 	}
-	return info.LogPath, nil
+	return m.logPath(info)
 }
 
-// Kill sends SIGTERM to a tracked process, waits up to 5 seconds, then
-// SIGKILLs it if still alive. Returns the final ProcessView.
+// Kill runs a tracked process's pre_stop hook, if any, sends it its stop
+// signal (SIGTERM by default), waits up to its grace period (5 seconds by
+// default), then SIGKILLs it if still alive. Returns the final ProcessView.
 func (m *Manager) Kill(processID string) (*ProcessView, error) {
-	raw, err := m.store.Get(keyPrefix + processID)
+	return m.KillWith(processID, KillOptions{})
+}
+
+// KillWith is Kill with the process's stop signal and grace period
+// overridden by opts.
+func (m *Manager) KillWith(processID string, opts KillOptions) (*ProcessView, error) {
+	signal, err := normalizeSignal(opts.Signal)
 	if err != nil {
-		return nil, fmt.Errorf("process %q not found", processID)
+		return nil, err
 	}
-	var info ProcessInfo
-	if err := json.Unmarshal([]byte(raw), &info); err != nil {
-		return nil, fmt.Errorf("decoding process info: %w", err)
+	if err := validateStopGrace(opts.GraceSecs); err != nil {
+		return nil, err
+	}
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	sig, grace := info.stopSignal(), info.stopGrace()
+	if signal != "" {
+		sig = stopSignals[signal]
 	}
+	if opts.GraceSecs > 0 {
+		grace = time.Duration(opts.GraceSecs) * time.Second
+	}
+
+	dependents := m.dependents(info)
 
-	status := m.status(info)
-	if status != StatusRunning {
-		return &ProcessView{ProcessInfo: info, Status: status}, nil
+	// Stop the restart policy from relaunching the process.
+	m.mu.Lock()
+	if rp, ok := m.running[info.ID]; ok {
+		rp.stopped = true
 	}
+	m.mu.Unlock()
 
-	proc, err := os.FindProcess(info.PID)
-	if err != nil {
-		return nil, fmt.Errorf("finding process: %w", err)
+	if view := m.view(info); view.Status != StatusRunning && view.Status != StatusPaused {
+		view.Dependents = dependents
+		return &view, nil
+	}
+
+	m.runPreStop(info)
+
+	switch {
+	case info.Compose != nil:
+		// Following the logs stops with the container.
+		stopComposeService(info, grace)
+	case info.InContainer != nil:
+		// The runtime's CLI may not pass signals on to the container, which
+		// is stopped with the stop signal it was run with.
+		stopContainer(info, grace)
 	}
 
-	_ = proc.Signal(syscall.SIGTERM)
+	// Signal the whole process group so children of the shell (e.g. node
+	// spawned by npm) are terminated too.
+	before := descendants(info.PID)
+	_ = signalGroup(info.PID, sig)
+	if info.Paused {
+		// Stopped processes only act on the signal once continued.
+		_ = signalGroup(info.PID, syscall.SIGCONT)
+	}
 
-	// Wait for the background goroutine to record the exit.
-	deadline := time.After(5 * time.Second)
+	// Wait for the background goroutine to record the exit and for the rest
+	// of the group to go away.
+	deadline := time.After(grace)
 	for {
 		select {
 		case <-deadline:
-			_ = proc.Kill()
+			_ = signalGroup(info.PID, syscall.SIGKILL)
 			time.Sleep(100 * time.Millisecond)
 			// Re-read from store after kill.
-			if raw, err = m.store.Get(keyPrefix + processID); err == nil {
-				_ = json.Unmarshal([]byte(raw), &info)
+			if latest, err := m.load(info.ID); err == nil {
+				info = latest
 			}
-			return &ProcessView{ProcessInfo: info, Status: m.status(info)}, nil
+			view := m.view(info)
+			view.TerminatedDescendants = countTerminated(before)
+			view.Dependents = dependents
+			return &view, nil
 		case <-time.After(100 * time.Millisecond):
 			// Re-read to check if the wait goroutine recorded the exit.
-			if raw, err = m.store.Get(keyPrefix + processID); err == nil {
-				_ = json.Unmarshal([]byte(raw), &info)
+			if latest, err := m.load(info.ID); err == nil {
+				info = latest
 			}
-			if m.status(info) != StatusRunning {
-				return &ProcessView{ProcessInfo: info, Status: m.status(info)}, nil
+			if view := m.view(info); view.Status != StatusRunning && view.Status != StatusPaused && !groupAlive(info.PID) {
+				view.TerminatedDescendants = countTerminated(before)
+				view.Dependents = dependents
+				return &view, nil
 			}
 		}
 	}
 }
 
-// Shutdown sends SIGTERM to all running processes, waits up to 5 seconds, then
-// SIGKILLs any remaining. Safe to call multiple times.
+// Shutdown runs the pre_stop hooks of all running processes, sends them
+// their stop signals (SIGTERM by default), waits up to the longest of their
+// grace periods (5 seconds by default), then SIGKILLs any remaining. Under
+// ShutdownKeep it leaves them running instead. Safe to call multiple times.
 func (m *Manager) Shutdown() {
 	m.once.Do(func() {
 		m.mu.Lock()
-		cmds := make(map[string]*exec.Cmd, len(m.running))
-		for id, cmd := range m.running {
-			cmds[id] = cmd
+		m.shutdown = true
+		if m.shutdownPolicy == ShutdownKeep {
+			// Restart policies are off; the next server adopts the rest.
+			m.mu.Unlock()
+			return
+		}
+		ids := make([]string, 0, len(m.running))
+		procs := make([]*runningProc, 0, len(m.running))
+		for id, rp := range m.running {
+			if rp.adopted {
+				continue
+			}
+			rp.stopped = true
+			ids = append(ids, id)
+			procs = append(procs, rp)
 		}
 		m.mu.Unlock()
 
-		for _, cmd := range cmds {
-			_ = cmd.Process.Signal(syscall.SIGTERM)
+		// Run the pre_stop hooks side by side, so they only delay shutdown
+		// by the slowest.
+		infos := make([]*ProcessInfo, len(ids))
+		var wg sync.WaitGroup
+		for i, id := range ids {
+			if info, err := m.load(id); err == nil {
+				infos[i] = &info
+				wg.Go(func() { m.runPreStop(info) })
+			}
+		}
+		wg.Wait()
+
+		grace := DefaultStopGrace
+		for i, rp := range procs {
+			sig := syscall.SIGTERM
+			if info := infos[i]; info != nil {
+				sig = info.stopSignal()
+				grace = max(grace, info.stopGrace())
+			}
+			m.signal(rp, sig)
+			// Paused processes only act on the signal once continued.
+			m.signal(rp, syscall.SIGCONT)
 		}
 
 		done := make(chan struct{})
 		go func() {
-			for _, cmd := range cmds {
-				_ = cmd.Wait()
+			for _, rp := range procs {
+				<-rp.done
+				for groupAlive(rp.cmd.Process.Pid) {
+					time.Sleep(100 * time.Millisecond)
+				}
 			}
 			close(done)
 		}()
 
 		select {
 		case <-done:
-		case <-time.After(5 * time.Second):
-			for _, cmd := range cmds {
-				_ = cmd.Process.Kill()
+		case <-time.After(grace):
+			for _, rp := range procs {
+				m.signal(rp, syscall.SIGKILL)
 			}
 		}
 	})
 }
 
+// environ returns the environment info runs with and the variables it adds
+// to the base environment.
+func (m *Manager) environ(info *ProcessInfo, tmpl *expander) (environ, added []string, err error) {
+	// Start with the current environment, or just its basics in clean mode,
+	// and add any env files, custom env vars, allocated ports and the
+	// process's identity, which custom env vars can't override.
+	custom, err := tmpl.expandEnv(info.Env)
+	if err != nil {
+		return nil, nil, err
+	}
+	vars, err := loadEnvFiles(info.EnvFiles, custom)
+	if err != nil {
+		return nil, nil, err
+	}
+	env, err := m.resolveEnv(vars, tmpl.secrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The server's own TERM describes whatever it runs in, not the pty, so
+	// it is overridden unless the process's env, defaults included, or env
+	// files set one.
+	if _, ok := vars["TERM"]; info.PTY && !ok {
+		env = append(env, "TERM="+ptyTerm)
+	}
+	base := os.Environ()
+	if info.CleanEnv {
+		base = cleanEnv()
+	}
+	added = slices.Concat(portEnv(info.AllocatedPorts), env, m.identityEnv(info))
+	return slices.Concat([]string{}, base, added), added, nil
+}
+
+// signal sends sig to the process group of the current incarnation of rp.
+func (m *Manager) signal(rp *runningProc, sig syscall.Signal) {
+	m.mu.Lock()
+	cmd := rp.cmd
+	m.mu.Unlock()
+	_ = signalGroup(cmd.Process.Pid, sig)
+}
+
+// signalGroup sends sig to every process in the group led by pid. Processes
+// are started with Setpgid, so the group ID equals the leader's PID.
+func signalGroup(pid int, sig syscall.Signal) error {
+	return syscall.Kill(-pid, sig)
+}
+
+// groupAlive reports whether any process in the group led by pid still exists.
+func groupAlive(pid int) bool {
+	return syscall.Kill(-pid, 0) == nil
+}
+
+// view builds the ProcessView for info.
+func (m *Manager) view(info ProcessInfo) ProcessView {
+	v := ProcessView{ProcessInfo: info, Status: m.status(info)}
+	v.setDurations()
+	v.Database = info.Database.redacted()
+	v.ReportToken = ""
+	if url, ok := info.Env["DATABASE_URL"]; ok && info.DatabaseID != "" {
+		v.Env = maps.Clone(info.Env)
+		v.Env["DATABASE_URL"] = redactDSN(url)
+	}
+	if v.Status == StatusRunning {
+		v.Health = m.health(info.ID)
+	}
+	if v.Status == StatusRunning || v.Status == StatusPaused {
+		v.DetectedPorts = m.detectedPorts(info.ID)
+		v.RSSBytes, v.CPUPercent = m.usage(info.ID)
+		if info.Compose != nil || info.InContainer != nil {
+			v.Container = m.container(info.ID)
+		}
+	}
+	return v
+}
+
 // status determines the ProcessStatus for a ProcessInfo.
 func (m *Manager) status(info ProcessInfo) ProcessStatus {
+	if info.CrashLooping {
+		return StatusCrashLooping
+	}
+	if info.ExitCode != nil && timedOut(info.ExitReason) {
+		return StatusTimedOut
+	}
+
 	// Already recorded an exit.
 	if info.ExitCode != nil {
 		if *info.ExitCode == 0 {
@@ -328,33 +1038,71 @@ func (m *Manager) status(info ProcessInfo) ProcessStatus {
 		}
 		return StatusFailed
 	}
+	// An adopted process's exit is recorded without a code.
+	if info.ExitedAt != nil {
+		return StatusExited
+	}
 
 	// Check in-memory running map first.
 	m.mu.Lock()
 	_, live := m.running[info.ID]
 	m.mu.Unlock()
 	if live {
+		if info.Paused {
+			return StatusPaused
+		}
 		return StatusRunning
 	}
 
-	// Fallback: signal-0 check for orphaned PIDs.
-	proc, err := os.FindProcess(info.PID)
-	if err != nil {
-		return StatusUnknown
-	}
-	if err := proc.Signal(syscall.Signal(0)); err == nil {
+	// Fallback for processes not watched by this server: is the PID still
+	// alive and not recycled?
+	if sameProcess(info.PID, info.PIDStart) {
+		if info.Paused {
+			return StatusPaused
+		}
 		return StatusRunning
 	}
 
 	return StatusUnknown
 }
 
+// load reads the ProcessInfo for processID from the store.
+func (m *Manager) load(processID string) (ProcessInfo, error) {
+	var info ProcessInfo
+	raw, err := m.store.Get(keyPrefix + processID)
+	if errors.Is(err, store.ErrNotFound) {
+		return info, fmt.Errorf("process %q not found", processID)
+	}
+	if err != nil {
+		return info, fmt.Errorf("reading process info: %w", err)
+	}
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return info, fmt.Errorf("decoding process info: %w", err)
+	}
+	return info, nil
+}
+
+// update applies fn to the stored ProcessInfo for id and persists the result.
+// Updates from this Manager are serialized so concurrent writers (the wait
+// goroutine, Pause, ...) don't clobber each other's fields.
+func (m *Manager) update(id string, fn func(*ProcessInfo)) (ProcessInfo, error) {
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+
+	info, err := m.load(id)
+	if err != nil {
+		return info, err
+	}
+	fn(&info)
+	return info, m.persist(info)
+}
+
 func (m *Manager) persist(info ProcessInfo) error {
 	data, err := json.Marshal(info)
 	if err != nil {
 		return err
 	}
-	return m.store.Set(keyPrefix+info.ID, string(data))
+	return m.store.Set(keyPrefix+info.ID, data)
 }
 
 func generateID() (string, error) {
@@ -0,0 +1,87 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrNameTaken is returned by Start, along with the existing process's view,
+// when a running process already has the requested name.
+var ErrNameTaken = errors.New("name already in use")
+
+var (
+	validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	// idLike matches generated process IDs, which names must not shadow.
+	idLike = regexp.MustCompile(`^[0-9a-f]{8}$`)
+)
+
+func validateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if idLike.MatchString(name) {
+		return fmt.Errorf("invalid name %q: looks like a process ID", name)
+	}
+	return nil
+}
+
+// lookup resolves ref, a process ID or name, to its ProcessInfo. A name
+// refers to the running process with that name, or else to the most recently
+// started one.
+func (m *Manager) lookup(ref string) (ProcessInfo, error) {
+	info, err := m.load(ref)
+	if err == nil || !validName.MatchString(ref) {
+		return info, err
+	}
+	found, ok, ferr := m.findByName(ref)
+	if ferr != nil || !ok {
+		return info, err
+	}
+	return found, nil
+}
+
+// findByName returns the process named name, preferring a running one over
+// the most recently started.
+func (m *Manager) findByName(name string) (ProcessInfo, bool, error) {
+	infos, err := m.records()
+	if err != nil {
+		return ProcessInfo{}, false, err
+	}
+	var best ProcessInfo
+	found := false
+	for _, info := range infos {
+		if info.Name != name {
+			continue
+		}
+		if st := m.status(info); st == StatusRunning || st == StatusPaused {
+			return info, true, nil
+		}
+		if !found || info.StartedAt.After(best.StartedAt) {
+			best, found = info, true
+		}
+	}
+	return best, found, nil
+}
+
+// records returns every decodable process record in the store.
+func (m *Manager) records() ([]ProcessInfo, error) {
+	keys, err := m.store.List(keyPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing process keys: %w", err)
+	}
+	infos := make([]ProcessInfo, 0, len(keys))
+	for _, key := range keys {
+		raw, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var info ProcessInfo
+		if err := json.Unmarshal(raw, &info); err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"thought-process/notifiers"
+)
+
+// notifyLogLines is how many of the last log lines a chat notification
+// carries.
+const notifyLogLines = 20
+
+// SetNotifiers replaces the chat notifiers RunNotifiers sends to.
+func (m *Manager) SetNotifiers(n *notifiers.Set) {
+	m.notifiers.Store(n)
+}
+
+// RunNotifiers sends a message with the process's name, tags, exit code and
+// last log lines to the notifiers set with SetNotifiers for each crashed
+// and crash_looping event, until ctx is done. Failed sends are passed to
+// onError.
+func (m *Manager) RunNotifiers(ctx context.Context, onError func(error)) error {
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-events:
+			n := m.notifiers.Load()
+			if n == nil || n.Empty() {
+				continue
+			}
+			var what string
+			switch e.Type {
+			case EventCrashed:
+				what = "crashed"
+			case EventCrashLooping:
+				what = fmt.Sprintf("is crash looping after %d restarts", e.Process.Restarts)
+			default:
+				continue
+			}
+			msg := notifiers.Message{
+				Title:     notifyLabel(e.Process) + " " + what,
+				ProcessID: e.Process.ID,
+				Tags:      e.Process.Tags,
+				ExitCode:  e.Process.ExitCode,
+				LogLines:  m.lastLogLines(e.Process.ID, notifyLogLines),
+			}
+			go func() {
+				if err := n.Notify(ctx, msg); err != nil {
+					onError(fmt.Errorf("notifying of %s: %w", e.Process.ID, err))
+				}
+			}()
+		}
+	}
+}
+
+// lastLogLines returns up to n of the last lines of a process's log, or
+// none if it can't be read.
+func (m *Manager) lastLogLines(id string, n int) []string {
+	logs, err := m.GetLogs(id)
+	if err != nil {
+		return nil
+	}
+	logs = strings.TrimRight(logs, "\n")
+	if logs == "" {
+		return nil
+	}
+	lines := strings.Split(logs, "\n")
+	return lines[max(len(lines)-n, 0):]
+}
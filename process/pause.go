@@ -0,0 +1,58 @@
+package process
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// Pause stops a running process group with SIGSTOP. The process keeps its
+// memory but uses no CPU until Resume is called.
+func (m *Manager) Pause(processID string) (*ProcessView, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	if view := m.view(info); view.Status != StatusRunning {
+		return nil, fmt.Errorf("process %q is %s, not running", processID, view.Status)
+	}
+
+	if err := signalGroup(info.PID, syscall.SIGSTOP); err != nil {
+		return nil, fmt.Errorf("stopping process group: %w", err)
+	}
+	info, err = m.update(info.ID, func(p *ProcessInfo) { p.Paused = true })
+	if err != nil {
+		return nil, fmt.Errorf("persisting process info: %w", err)
+	}
+	view := m.view(info)
+	return &view, nil
+}
+
+// Resume continues a paused process group with SIGCONT.
+func (m *Manager) Resume(processID string) (*ProcessView, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	if view := m.view(info); view.Status != StatusPaused {
+		return nil, fmt.Errorf("process %q is %s, not paused", processID, view.Status)
+	}
+
+	if err := signalGroup(info.PID, syscall.SIGCONT); err != nil {
+		return nil, fmt.Errorf("continuing process group: %w", err)
+	}
+	info, err = m.update(info.ID, func(p *ProcessInfo) { p.Paused = false })
+	if err != nil {
+		return nil, fmt.Errorf("persisting process info: %w", err)
+	}
+
+	// Health checks failed while the process was stopped; start over.
+	m.mu.Lock()
+	if rp, ok := m.running[info.ID]; ok && info.HealthCheck != nil {
+		rp.health = HealthStarting
+		rp.healthFailures = 0
+	}
+	m.mu.Unlock()
+
+	view := m.view(info)
+	return &view, nil
+}
@@ -0,0 +1,101 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"thought-process/store"
+)
+
+// pendingViews returns a view for each schedule with a run still to come:
+// StatusScheduled for starts, StatusRestartScheduled for restarts. Their ID
+// and ScheduleID are the schedule's ID and RunAt is the next run.
+func (m *Manager) pendingViews() ([]ProcessView, error) {
+	schedules, err := m.Schedules()
+	if err != nil {
+		return nil, err
+	}
+	var views []ProcessView
+	for _, s := range schedules {
+		if s.NextRun == nil {
+			continue
+		}
+		opts := s.Process
+		status := StatusScheduled
+		if s.RestartProcess != "" {
+			status = StatusRestartScheduled
+			opts = StartOptions{Name: s.RestartProcess}
+			if info, err := m.lookup(s.RestartProcess); err == nil {
+				opts = info.startOptions()
+			}
+		}
+		views = append(views, ProcessView{
+			ProcessInfo: ProcessInfo{
+				ID:         s.ID,
+				Name:       opts.Name,
+				Command:    opts.Command,
+				Args:       opts.Args,
+				Cwd:        opts.Cwd,
+				Env:        opts.Env,
+				Tags:       opts.Tags,
+				Ports:      opts.Ports,
+				Restart:    opts.Restart,
+				ScheduleID: s.ID,
+			},
+			Status: status,
+			RunAt:  s.NextRun,
+		})
+	}
+	return views, nil
+}
+
+// CancelPending cancels the next run of the schedule id, as listed by List
+// with IncludePending. A one-shot schedule is deleted; a recurring one skips
+// to the run after and is returned.
+func (m *Manager) CancelPending(id string) (*Schedule, error) {
+	m.schedMu.Lock()
+	defer m.schedMu.Unlock()
+	data, err := m.store.Get(scheduleKeyPrefix + id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("no pending run %q", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.NextRun == nil {
+		return nil, fmt.Errorf("schedule %s has no pending run", id)
+	}
+	if s.Cron == "" {
+		return nil, m.store.Delete(scheduleKeyPrefix + id)
+	}
+
+	c, err := parseCron(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+	skipped := *s.NextRun
+	s.NextRun = nil
+	if t := c.next(skipped.Local()); !t.IsZero() {
+		t = t.UTC()
+		s.NextRun = &t
+	}
+	if err := m.saveSchedule(s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// matchTags reports whether tags include every key-value pair of selector.
+func matchTags(tags, selector map[string]string) bool {
+	for k, v := range selector {
+		if tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Sources of a PortClaim.
+const (
+	PortAllocated = "allocated"
+	PortDeclared  = "declared"
+	PortReported  = "reported"
+	PortDetected  = "detected"
+	// PortScheduled is a port declared by a schedule's next run.
+	PortScheduled = "schedule"
+	// PortStack is a port declared by a stack definition with no live
+	// process.
+	PortStack = "stack"
+)
+
+// PortClaim is a process that holds, or is set to take, a port.
+type PortClaim struct {
+	// ProcessID is the process, or for a schedule claim the schedule.
+	ProcessID string        `json:"process_id,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	Role      string        `json:"role,omitempty"`
+	Branch    string        `json:"branch,omitempty"`
+	Status    ProcessStatus `json:"status,omitempty"`
+	// URL is set for live processes.
+	URL string `json:"url,omitempty"`
+	// Source is how the port is known, e.g. PortAllocated or PortStack.
+	Source string `json:"source"`
+	// Stack is the stack of a PortStack claim.
+	Stack string `json:"stack,omitempty"`
+}
+
+// PortMap is every port tracked processes hold or are set to take.
+type PortMap struct {
+	// Ports maps each port to its claims; more than one is a conflict
+	// waiting to happen.
+	Ports map[int][]PortClaim `json:"ports"`
+	// AllocationRange is where StartOptions.AllocatePorts draws from.
+	AllocationRange PortRange `json:"allocation_range"`
+}
+
+// PortMap returns the ports of running and paused processes, and the ports
+// declared by pending schedule runs and by stack definitions without a live
+// process, which will be wanted when those start.
+func (m *Manager) PortMap() (*PortMap, error) {
+	pm := &PortMap{Ports: make(map[int][]PortClaim)}
+	m.storeMu.Lock()
+	pm.AllocationRange = m.portRange
+	m.storeMu.Unlock()
+
+	live, err := m.liveViews()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range live {
+		sources := []struct {
+			source string
+			ports  []int
+		}{
+			{PortAllocated, v.AllocatedPorts},
+			{PortDeclared, v.Ports},
+			{PortReported, v.ReportedPorts},
+			{PortDetected, v.DetectedPorts},
+		}
+		var seen []int
+		for _, s := range sources {
+			for _, port := range s.ports {
+				if slices.Contains(seen, port) {
+					continue
+				}
+				seen = append(seen, port)
+				c := portClaim(v, s.source)
+				c.URL = fmt.Sprintf("http://localhost:%d", port)
+				pm.Ports[port] = append(pm.Ports[port], c)
+			}
+		}
+	}
+
+	pending, err := m.pendingViews()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range pending {
+		for _, port := range v.Ports {
+			pm.Ports[port] = append(pm.Ports[port], portClaim(v, PortScheduled))
+		}
+	}
+
+	stacks, err := m.Stacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range stacks {
+		for _, def := range st.Processes {
+			member := slices.IndexFunc(st.Members, func(v ProcessView) bool { return v.Name == def.Name })
+			var status ProcessStatus
+			if member >= 0 {
+				if status = st.Members[member].Status; status == StatusRunning || status == StatusPaused {
+					continue
+				}
+			}
+			for _, port := range def.Ports {
+				pm.Ports[port] = append(pm.Ports[port], PortClaim{
+					Name:   def.Name,
+					Role:   def.Tags["role"],
+					Branch: def.Tags[TagBranch],
+					Status: status,
+					Source: PortStack,
+					Stack:  st.Name,
+				})
+			}
+		}
+	}
+	return pm, nil
+}
+
+func portClaim(v ProcessView, source string) PortClaim {
+	return PortClaim{
+		ProcessID: v.ID,
+		Name:      v.Name,
+		Role:      v.Tags["role"],
+		Branch:    v.Tags[TagBranch],
+		Status:    v.Status,
+		Source:    source,
+	}
+}
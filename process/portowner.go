@@ -0,0 +1,94 @@
+package process
+
+import (
+	"fmt"
+	"slices"
+	"syscall"
+)
+
+// portListener is an OS process listening on a port.
+type portListener struct {
+	PID     int    `json:"pid"`
+	Command string `json:"command,omitempty"`
+}
+
+// PortOwner describes who is listening on a TCP port.
+type PortOwner struct {
+	Port      int  `json:"port"`
+	Listening bool `json:"listening"`
+	// Process is the tracked process whose group owns the port, if any.
+	Process *ProcessView `json:"process,omitempty"`
+	// PID and Command identify the listening OS process. For an untracked
+	// process they are all there is to go on.
+	PID     int    `json:"pid,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// FindByPort reports which process, tracked or not, is listening on port.
+func (m *Manager) FindByPort(port int) (*PortOwner, error) {
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	live, err := m.liveViews()
+	if err != nil {
+		return nil, err
+	}
+
+	owner := &PortOwner{Port: port}
+	listening, listeners, err := portListeners(port)
+	if err != nil {
+		return nil, fmt.Errorf("finding listeners on port %d: %w", port, err)
+	}
+	owner.Listening = listening
+
+	if v, l := trackedListener(listeners, live); v != nil {
+		owner.Process = v
+		owner.PID, owner.Command = l.PID, l.Command
+		return owner, nil
+	}
+	if len(listeners) > 0 {
+		owner.PID, owner.Command = listeners[0].PID, listeners[0].Command
+		return owner, nil
+	}
+
+	// The listener couldn't be identified; fall back to what the processes
+	// reported and the last scan.
+	if i := slices.IndexFunc(live, func(v ProcessView) bool {
+		return slices.Contains(v.ReportedPorts, port) || slices.Contains(v.DetectedPorts, port)
+	}); i >= 0 {
+		owner.Process = &live[i]
+		owner.Listening = true
+	}
+	return owner, nil
+}
+
+// liveViews returns the running and paused processes.
+func (m *Manager) liveViews() ([]ProcessView, error) {
+	views, err := m.List(ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+	var live []ProcessView
+	for _, v := range views {
+		if v.Status == StatusRunning || v.Status == StatusPaused {
+			live = append(live, v)
+		}
+	}
+	return live, nil
+}
+
+// trackedListener returns the first of listeners that belongs to one of the
+// live processes, and that process. Listeners are usually children of the
+// tracked process (a shell, npm), so they are matched by process group.
+func trackedListener(listeners []portListener, live []ProcessView) (*ProcessView, portListener) {
+	for _, l := range listeners {
+		pgid, err := syscall.Getpgid(l.PID)
+		if err != nil {
+			continue
+		}
+		if i := slices.IndexFunc(live, func(v ProcessView) bool { return v.PID == pgid }); i >= 0 {
+			return &live[i], l
+		}
+	}
+	return nil, portListener{}
+}
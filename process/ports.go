@@ -0,0 +1,52 @@
+package process
+
+import (
+	"slices"
+	"time"
+)
+
+// portScanInterval is how often the Manager looks for ports its processes
+// are listening on.
+const portScanInterval = 5 * time.Second
+
+// watchPorts records the TCP ports rp's process group listens on until it
+// exits for good.
+func (m *Manager) watchPorts(rp *runningProc) {
+	// Scan soon after start so servers show up quickly, then settle into
+	// the regular interval.
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-rp.done:
+			return
+		case <-timer.C:
+		}
+
+		m.mu.Lock()
+		pid := rp.cmd.Process.Pid
+		m.mu.Unlock()
+
+		ports, err := listeningPorts(pid)
+		if err == nil {
+			slices.Sort(ports)
+			ports = slices.Compact(ports)
+			m.mu.Lock()
+			rp.detectedPorts = ports
+			m.mu.Unlock()
+		}
+		timer.Reset(portScanInterval)
+	}
+}
+
+// detectedPorts returns the ports last detected for a process, or nil if it
+// isn't running under this Manager.
+func (m *Manager) detectedPorts(id string) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rp, ok := m.running[id]; ok {
+		return rp.detectedPorts
+	}
+	return nil
+}
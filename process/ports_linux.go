@@ -0,0 +1,131 @@
+package process
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the st column value of a listening socket in /proc/net/tcp.
+const tcpListen = "0A"
+
+// listeningPorts returns the TCP ports that members of group pgid are
+// listening on, by matching their socket inodes against /proc/net/tcp{,6}.
+func listeningPorts(pgid int) ([]int, error) {
+	members, err := groupMembers(pgid)
+	if err != nil {
+		return nil, err
+	}
+
+	inodes := make(map[string]bool)
+	for _, n := range members {
+		for _, inode := range socketInodes(n.PID) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	var ports []int
+	for inode, port := range listenSockets() {
+		if inodes[inode] {
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// portListeners returns the processes listening on TCP port. The port may
+// be in use with no listeners returned if their /proc entries can't be read.
+func portListeners(port int) (bool, []portListener, error) {
+	inodes := make(map[string]bool)
+	for inode, p := range listenSockets() {
+		if p == port {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return false, nil, nil
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return true, nil, err
+	}
+	var listeners []portListener
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		for _, inode := range socketInodes(pid) {
+			if inodes[inode] {
+				listeners = append(listeners, portListener{PID: pid, Command: procCommand(pid)})
+				break
+			}
+		}
+	}
+	return true, listeners, nil
+}
+
+// socketInodes returns the inodes of the sockets pid has open.
+func socketInodes(pid int) []string {
+	fdDir := filepath.Join("/proc", strconv.Itoa(pid), "fd")
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return nil
+	}
+	var inodes []string
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil {
+			continue
+		}
+		if inode, ok := strings.CutPrefix(link, "socket:["); ok {
+			inodes = append(inodes, strings.TrimSuffix(inode, "]"))
+		}
+	}
+	return inodes
+}
+
+// listenSockets maps the inode of every listening TCP socket to its port.
+func listenSockets() map[string]int {
+	sockets := make(map[string]int)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(table)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			// sl local_address rem_address st tx_queue:rx_queue tr:tm->when
+			// retrnsmt uid timeout inode ...
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListen {
+				continue
+			}
+			_, hexPort, ok := strings.Cut(fields[1], ":")
+			if !ok {
+				continue
+			}
+			if port, err := strconv.ParseUint(hexPort, 16, 16); err == nil {
+				sockets[fields[9]] = int(port)
+			}
+		}
+		f.Close()
+	}
+	return sockets
+}
+
+// procCommand returns pid's command line, or "" if it can't be read.
+func procCommand(pid int) string {
+	raw, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(raw), "\x00", " "))
+}
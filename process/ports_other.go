@@ -0,0 +1,69 @@
+//go:build !linux
+
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// listeningPorts returns the TCP ports that members of group pgid are
+// listening on, using lsof.
+func listeningPorts(pgid int) ([]int, error) {
+	out, err := exec.Command("lsof", "-nP", "-a", "-g", strconv.Itoa(pgid), "-iTCP", "-sTCP:LISTEN", "-Fn").Output()
+	if err != nil && len(out) == 0 {
+		// lsof exits 1 when nothing matches.
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ports []int
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// Name lines look like "n*:3000" or "n[::1]:5432".
+		line, ok := strings.CutPrefix(scanner.Text(), "n")
+		if !ok {
+			continue
+		}
+		i := strings.LastIndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		if port, err := strconv.Atoi(line[i+1:]); err == nil {
+			ports = append(ports, port)
+		}
+	}
+	return ports, nil
+}
+
+// portListeners returns the processes listening on TCP port, using lsof.
+func portListeners(port int) (bool, []portListener, error) {
+	out, err := exec.Command("lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil && len(out) == 0 {
+		if _, ok := err.(*exec.ExitError); ok {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	var listeners []portListener
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		// Each process is a "p<pid>" line followed by "c<command>".
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "p"):
+			if pid, err := strconv.Atoi(line[1:]); err == nil {
+				listeners = append(listeners, portListener{PID: pid})
+			}
+		case strings.HasPrefix(line, "c") && len(listeners) > 0:
+			listeners[len(listeners)-1].Command = line[1:]
+		}
+	}
+	return len(listeners) > 0, listeners, nil
+}
@@ -0,0 +1,81 @@
+package process
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// maxWarningLine bounds the log line kept with an exit warning.
+const maxWarningLine = 500
+
+// precursor is log output that usually comes shortly before a process dies.
+type precursor struct {
+	// reason is the Alert's Reason.
+	reason  string
+	pattern *regexp.Regexp
+	message string
+}
+
+// precursors are checked against every line a running process writes.
+var precursors = []precursor{
+	{
+		reason:  "port_in_use",
+		pattern: regexp.MustCompile(`EADDRINUSE|(?i:address already in use)`),
+		message: "its port is already in use, so it will likely fail to listen",
+	},
+	{
+		reason:  "database_unreachable",
+		pattern: regexp.MustCompile(`(?i)(ECONNREFUSED|connection refused).*(:(5432|3306|6379|27017)\b|postgres|mysql|redis|mongo)|(postgres|mysql|redis|mongo).*(ECONNREFUSED|connection refused)`),
+		message: "its database refused the connection",
+	},
+	{
+		reason:  "heap_exhausted",
+		pattern: regexp.MustCompile(`JavaScript heap out of memory|Reached heap limit|Ineffective mark-compacts near heap limit|java\.lang\.OutOfMemoryError`),
+		message: "it is running out of heap",
+	},
+}
+
+// checkPrecursors raises an exit_warning alert, and sets the process's
+// Warning, for each kind of precursor in lines. Each kind warns once per
+// run of the process.
+func (m *Manager) checkPrecursors(id string, rp *runningProc, lines [][]byte) {
+	for _, p := range precursors {
+		m.mu.Lock()
+		warned := rp.exitWarned[p.reason]
+		m.mu.Unlock()
+		if warned {
+			continue
+		}
+		match := matchLines(p.pattern, lines)
+		if match == nil {
+			continue
+		}
+		m.mu.Lock()
+		if rp.exitWarned == nil {
+			rp.exitWarned = make(map[string]bool)
+		}
+		rp.exitWarned[p.reason] = true
+		m.mu.Unlock()
+
+		line := match.Line
+		if len(line) > maxWarningLine {
+			line = line[:maxWarningLine]
+		}
+		a := Alert{
+			Kind:    AlertExitWarning,
+			Time:    time.Now().UTC(),
+			Message: fmt.Sprintf("likely to exit soon: %s", p.message),
+			Reason:  p.reason,
+			Line:    line,
+		}
+		info, err := m.update(id, func(p *ProcessInfo) {
+			p.Alerts = appendAlert(p.Alerts, a)
+			p.Warning = &a
+		})
+		if err != nil {
+			return
+		}
+		m.publishEvent(Event{Type: EventExitWarning, Time: a.Time, Process: m.view(info), Alert: &a})
+	}
+}
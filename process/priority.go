@@ -0,0 +1,91 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// I/O scheduling classes for IOClass. Only Linux supports them.
+const (
+	IOClassBestEffort = "best-effort"
+	IOClassIdle       = "idle"
+)
+
+// validatePriority checks a niceness and I/O class.
+func validatePriority(nice int, ioClass string) error {
+	if nice < -20 || nice > 19 {
+		return fmt.Errorf("nice must be between -20 and 19, got %d", nice)
+	}
+	switch ioClass {
+	case "", IOClassBestEffort, IOClassIdle:
+		return nil
+	}
+	return fmt.Errorf("unknown io_class %q (want %q or %q)", ioClass, IOClassBestEffort, IOClassIdle)
+}
+
+// SetPriority renices a running or paused process group and, if ioClass is
+// set, changes its I/O scheduling class. A nil nice leaves the niceness
+// alone. The new values are recorded on the process and kept across
+// restarts. Lowering the niceness below its current value usually needs
+// privileges.
+func (m *Manager) SetPriority(processID string, nice *int, ioClass string) (*ProcessView, error) {
+	if nice == nil && ioClass == "" {
+		return nil, errors.New("nothing to change: give nice or io_class")
+	}
+	n := 0
+	if nice != nil {
+		n = *nice
+	}
+	if err := validatePriority(n, ioClass); err != nil {
+		return nil, err
+	}
+
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	if status := m.status(info); status != StatusRunning && status != StatusPaused {
+		return nil, fmt.Errorf("process %s is %s", info.ID, status)
+	}
+	if nice != nil {
+		if err := syscall.Setpriority(syscall.PRIO_PGRP, info.PID, n); err != nil {
+			return nil, fmt.Errorf("setting nice: %w", err)
+		}
+	}
+	if ioClass != "" {
+		if err := setIOClass(info.PID, ioClass); err != nil {
+			return nil, fmt.Errorf("setting io_class: %w", err)
+		}
+	}
+
+	info, err = m.update(info.ID, func(p *ProcessInfo) {
+		if nice != nil {
+			p.Nice = n
+		}
+		if ioClass != "" {
+			p.IOClass = ioClass
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("persisting priority: %w", err)
+	}
+	view := m.view(info)
+	return &view, nil
+}
+
+// setGroupPriority applies a niceness and I/O class to every member of the
+// process group pgid. Members forked later inherit both.
+func setGroupPriority(pgid, nice int, ioClass string) error {
+	if nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PGRP, pgid, nice); err != nil {
+			return fmt.Errorf("nice: %w", err)
+		}
+	}
+	if ioClass != "" {
+		if err := setIOClass(pgid, ioClass); err != nil {
+			return fmt.Errorf("io_class: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package process
+
+import "syscall"
+
+// ioprio_set(2) arguments.
+const (
+	ioprioWhoPgrp    = 2
+	ioprioClassShift = 13
+
+	ioprioClassBE   = 2
+	ioprioClassIdle = 3
+	// ioprioBELevel is the default best-effort level (0 highest, 7 lowest).
+	ioprioBELevel = 4
+)
+
+// setIOClass sets the I/O scheduling class of every member of the process
+// group pgid.
+func setIOClass(pgid int, class string) error {
+	prio := ioprioClassBE<<ioprioClassShift | ioprioBELevel
+	if class == IOClassIdle {
+		prio = ioprioClassIdle << ioprioClassShift
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoPgrp, uintptr(pgid), uintptr(prio)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package process
+
+import "errors"
+
+// setIOClass is unsupported: I/O scheduling classes are Linux only.
+func setIOClass(pgid int, class string) error {
+	return errors.New("I/O scheduling classes are only supported on Linux")
+}
@@ -0,0 +1,77 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Probe checks whether a dependency is ready. It returns nil when ready and a
+// descriptive error otherwise.
+type Probe func(ctx context.Context) error
+
+// TCPProbe returns a Probe that succeeds when addr accepts TCP connections.
+func TCPProbe(addr string) Probe {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+		return nil
+	}
+}
+
+// HTTPProbe returns a Probe that succeeds when a GET to url responds with a
+// 2xx or 3xx status.
+func HTTPProbe(url string) Probe {
+	client := &http.Client{
+		// Don't follow redirects; a redirect response already proves the
+		// server is up.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// WaitFor runs probe every interval until it succeeds or ctx is done. On
+// timeout it returns an error wrapping the last probe failure.
+func WaitFor(ctx context.Context, probe Probe, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Bound each attempt so a hanging connection can't eat the whole
+		// deadline.
+		attemptCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		err := probe(attemptCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
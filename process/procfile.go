@@ -0,0 +1,133 @@
+package process
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultProcfile is the file StartProcfile reads unless told otherwise.
+const DefaultProcfile = "Procfile"
+
+// procfileLine matches a "name: command" entry, as foreman parses them.
+var procfileLine = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+
+// usesPort matches a command that reads $PORT, which foreman would set.
+var usesPort = regexp.MustCompile(`\$(\{PORT\}|PORT\b)`)
+
+// ProcfileEntry is one process type of a Procfile.
+type ProcfileEntry struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// ProcfileOptions describes the Procfile StartProcfile starts.
+type ProcfileOptions struct {
+	// Cwd is the directory the processes run in; File is resolved against
+	// it. Project-relative cwds ("project:NAME/...") are accepted.
+	Cwd string
+	// File is the Procfile's path; empty means DefaultProcfile.
+	File string
+	// Tags are added to every process, besides role=NAME.
+	Tags map[string]string
+}
+
+// StartProcfile starts every entry of a Procfile as a tracked process
+// running its command through the shell in opts.Cwd, tagged role=NAME. As
+// with foreman, a .env file in the directory is loaded, and an entry that
+// refers to $PORT gets a port allocated for it. Results are in file order.
+func (m *Manager) StartProcfile(opts ProcfileOptions) ([]StackResult, error) {
+	if opts.Cwd == "" {
+		return nil, errors.New("cwd is required")
+	}
+	cwd, err := m.resolveCwd(opts.Cwd)
+	if err != nil {
+		return nil, err
+	}
+	if cwd, err = checkCwd(cwd, false); err != nil {
+		return nil, err
+	}
+	file := opts.File
+	if file == "" {
+		file = DefaultProcfile
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(cwd, file)
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading Procfile: %w", err)
+	}
+	defer f.Close()
+	entries, err := parseProcfile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	var envFiles []string
+	if _, err := os.Stat(filepath.Join(cwd, ".env")); err == nil {
+		envFiles = []string{".env"}
+	}
+	results := make([]StackResult, len(entries))
+	for i, e := range entries {
+		tags := maps.Clone(opts.Tags)
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags["role"] = e.Name
+		start := StartOptions{
+			Command:  e.Command,
+			Cwd:      cwd,
+			Tags:     tags,
+			EnvFiles: envFiles,
+		}
+		if usesPort.MatchString(e.Command) {
+			start.AllocatePorts = 1
+		}
+
+		results[i].Name = e.Name
+		view, err := m.Start(start)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Process = view
+	}
+	return results, nil
+}
+
+// parseProcfile reads "name: command" lines, skipping blank lines and
+// # comments. Names must be unique.
+func parseProcfile(r io.Reader) ([]ProcfileEntry, error) {
+	var entries []ProcfileEntry
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		match := procfileLine.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: expected \"name: command\"", n)
+		}
+		if seen[match[1]] {
+			return nil, fmt.Errorf("line %d: %q is defined twice", n, match[1])
+		}
+		seen[match[1]] = true
+		entries = append(entries, ProcfileEntry{Name: match[1], Command: match[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no entries")
+	}
+	return entries, nil
+}
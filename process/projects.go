@@ -0,0 +1,102 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"thought-process/store"
+)
+
+const (
+	projectKeyPrefix = "project:"
+	// projectCwdPrefix marks a cwd relative to a registered project root,
+	// e.g. "project:webapp/packages/api".
+	projectCwdPrefix = "project:"
+)
+
+// Project is a named root directory that process cwds can refer to.
+type Project struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// RegisterProject records path, an existing directory, as the root of the
+// project name, replacing any previous registration.
+func (m *Manager) RegisterProject(name, path string) (*Project, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid project name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("project path %q must be absolute", path)
+	}
+	if st, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("project path: %w", err)
+	} else if !st.IsDir() {
+		return nil, fmt.Errorf("project path %q is not a directory", path)
+	}
+
+	p := Project{Name: name, Path: filepath.Clean(path)}
+	if err := m.store.Set(projectKeyPrefix+name, []byte(p.Path)); err != nil {
+		return nil, fmt.Errorf("persisting project: %w", err)
+	}
+	return &p, nil
+}
+
+// Projects returns the registered projects sorted by name.
+func (m *Manager) Projects() ([]Project, error) {
+	keys, err := m.store.List(projectKeyPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing projects: %w", err)
+	}
+	projects := make([]Project, 0, len(keys))
+	for _, key := range keys {
+		path, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		projects = append(projects, Project{Name: strings.TrimPrefix(key, projectKeyPrefix), Path: string(path)})
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, nil
+}
+
+// resolveCwd expands a "project:NAME/REL" cwd to an absolute path inside the
+// project's root. Other values are returned unchanged.
+func (m *Manager) resolveCwd(cwd string) (string, error) {
+	ref, ok := strings.CutPrefix(cwd, projectCwdPrefix)
+	if !ok {
+		return cwd, nil
+	}
+	name, rel, _ := strings.Cut(ref, "/")
+	root, err := m.store.Get(projectKeyPrefix + name)
+	if errors.Is(err, store.ErrNotFound) {
+		return "", fmt.Errorf("unknown project %q (register it with register_project)", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading project: %w", err)
+	}
+
+	dir := filepath.Join(string(root), rel)
+	if !within(string(root), dir) {
+		return "", fmt.Errorf("cwd %q escapes project %q", cwd, name)
+	}
+	// Symlinks inside the project must not lead out of it either.
+	realRoot, err := filepath.EvalSymlinks(string(root))
+	if err != nil {
+		return "", fmt.Errorf("resolving project root: %w", err)
+	}
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil && !within(realRoot, realDir) {
+		return "", fmt.Errorf("cwd %q resolves outside project %q", cwd, name)
+	}
+	return dir, nil
+}
+
+// within reports whether path is root or inside it. Both must be clean.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ptyRows and ptyCols are the window size reported to processes run in a
+// pseudo-terminal. Some tools disable progress output on a 0x0 terminal.
+const (
+	ptyRows = 40
+	ptyCols = 120
+)
+
+// ptyTerm is the TERM processes run in a pseudo-terminal get unless they set
+// their own.
+const ptyTerm = "xterm-256color"
+
+// ptyConn is the master side of a process's pseudo-terminal. Output is copied
+// to the log in the background; writes go to the process as terminal input.
+type ptyConn struct {
+	master *os.File
+	copied chan struct{}
+}
+
+func (p *ptyConn) Write(b []byte) (int, error) { return p.master.Write(b) }
+
+func (p *ptyConn) Close() error { return p.master.Close() }
+
+// drain waits briefly for output still buffered in the terminal to reach the
+// log, then closes the master. Background children that keep the terminal
+// open must not hold up the restart policy.
+func (p *ptyConn) drain() {
+	select {
+	case <-p.copied:
+	case <-time.After(time.Second):
+	}
+	p.master.Close()
+}
+
+// startPTY starts cmd as the leader of a new session with a pseudo-terminal
+// as its controlling terminal and stdin/stdout/stderr, copying its output to
+// logFile. The new session is also a new process group led by the child.
+func startPTY(cmd *exec.Cmd, logFile *os.File) (*ptyConn, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+	defer slave.Close()
+
+	ws := struct{ row, col, xpixel, ypixel uint16 }{ptyRows, ptyCols, 0, 0}
+	if err := ioctl(slave, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws))); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	p := &ptyConn{master: master, copied: make(chan struct{})}
+	go func() {
+		defer close(p.copied)
+		// Reads fail with EIO once every process has closed the terminal.
+		io.Copy(logFile, master)
+	}()
+	return p, nil
+}
+
+// ioctl performs an ioctl on f without switching it to blocking mode, so
+// that closing the master still interrupts a pending read.
+func ioctl(f *os.File, req, arg uintptr) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg)
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package process
+
+import (
+	"errors"
+	"os"
+)
+
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, errors.New("pty mode is not supported on this platform")
+}
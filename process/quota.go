@@ -0,0 +1,210 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// quotaInterval is how often RunStorageQuota measures the log and data
+// directories.
+const quotaInterval = 5 * time.Minute
+
+// What an Eviction removed.
+const (
+	// EvictedLog is the log file of an exited process; its record stays.
+	EvictedLog = "log"
+	// EvictedRecord is an exited process's record, error fingerprints and
+	// log.
+	EvictedRecord = "record"
+)
+
+// Why an Eviction was made.
+const (
+	EvictedForQuota     = "quota"
+	EvictedForRetention = "retention"
+)
+
+// Eviction describes what the storage quota or the retention policy
+// removed for a process.
+type Eviction struct {
+	What   string `json:"what"`
+	Bytes  int64  `json:"bytes"`
+	Reason string `json:"reason"`
+}
+
+// RunStorageQuota keeps the log directory and dataDir together under quota
+// bytes, checking now and then every 5 minutes until ctx is done. Over the
+// quota, it deletes the log files of exited processes, oldest exit first,
+// and then, if that wasn't enough, their records. Running, paused and
+// unknown processes are never touched. Each eviction is published as an
+// evicted event.
+func (m *Manager) RunStorageQuota(ctx context.Context, dataDir string, quota int64) error {
+	if quota <= 0 {
+		return errors.New("storage quota must be positive")
+	}
+	ticker := time.NewTicker(quotaInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.enforceQuota(dataDir, quota); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// enforceQuota runs one check of RunStorageQuota.
+func (m *Manager) enforceQuota(dataDir string, quota int64) error {
+	logSize, err := dirSize(m.logDir)
+	if err != nil {
+		return fmt.Errorf("measuring log directory: %w", err)
+	}
+	dataSize, err := dirSize(dataDir)
+	if err != nil {
+		return fmt.Errorf("measuring data directory: %w", err)
+	}
+	total := logSize + dataSize
+	if total <= quota {
+		return nil
+	}
+
+	exited, err := m.exitedRecords()
+	if err != nil {
+		return err
+	}
+
+	for i := range exited {
+		if total <= quota {
+			return nil
+		}
+		if exited[i].LogEvicted {
+			continue
+		}
+		path, err := m.logPath(exited[i])
+		if err != nil {
+			continue
+		}
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("evicting log: %w", err)
+		}
+		total -= stat.Size()
+		if updated, err := m.update(exited[i].ID, func(p *ProcessInfo) { p.LogEvicted = true }); err == nil {
+			exited[i] = updated
+		}
+		m.publishEvent(Event{Type: EventEvicted, Time: time.Now().UTC(), Process: m.view(exited[i]),
+			Eviction: &Eviction{What: EvictedLog, Bytes: stat.Size(), Reason: EvictedForQuota}})
+	}
+
+	for _, info := range exited {
+		if total <= quota {
+			return nil
+		}
+		size, err := m.evictRecord(info, EvictedForQuota)
+		if err != nil {
+			return err
+		}
+		total -= size
+	}
+	return nil
+}
+
+// exitedRecords returns the processes that have exited for good, oldest
+// exit first. Ones the restart policy is about to relaunch aren't included.
+func (m *Manager) exitedRecords() ([]ProcessInfo, error) {
+	infos, err := m.records()
+	if err != nil {
+		return nil, err
+	}
+	var exited []ProcessInfo
+	for _, info := range infos {
+		m.mu.Lock()
+		_, live := m.running[info.ID]
+		m.mu.Unlock()
+		if live {
+			continue
+		}
+		switch m.status(info) {
+		case StatusExited, StatusFailed, StatusTimedOut, StatusCrashLooping:
+			exited = append(exited, info)
+		}
+	}
+	sort.Slice(exited, func(i, j int) bool { return exitTime(exited[i]).Before(exitTime(exited[j])) })
+	return exited, nil
+}
+
+// evictRecord deletes an exited process's record, error fingerprints and
+// log, publishes an evicted event for reason, and returns how many bytes
+// it freed.
+func (m *Manager) evictRecord(info ProcessInfo, reason string) (int64, error) {
+	size, err := m.deleteRecord(info)
+	if err != nil {
+		return size, fmt.Errorf("evicting record: %w", err)
+	}
+	m.publishEvent(Event{Type: EventEvicted, Time: time.Now().UTC(), Process: m.view(info),
+		Eviction: &Eviction{What: EvictedRecord, Bytes: size, Reason: reason}})
+	return size, nil
+}
+
+// deleteRecord deletes a process's record, error fingerprints and log, and
+// returns how many bytes it freed.
+func (m *Manager) deleteRecord(info ProcessInfo) (int64, error) {
+	var size int64
+	for _, key := range []string{keyPrefix + info.ID, errorsKeyPrefix + info.ID} {
+		if data, err := m.store.Get(key); err == nil {
+			size += int64(len(data))
+		}
+		if err := m.store.Delete(key); err != nil {
+			return size, err
+		}
+	}
+	if !info.LogEvicted {
+		// The log may have gone missing rather than been evicted.
+		if path, err := m.logPath(info); err == nil {
+			if stat, err := os.Stat(path); err == nil && os.Remove(path) == nil {
+				size += stat.Size()
+			}
+		}
+	}
+	return size, nil
+}
+
+// exitTime is when info exited, or else when it started.
+func exitTime(info ProcessInfo) time.Time {
+	if info.ExitedAt != nil {
+		return *info.ExitedAt
+	}
+	return info.StartedAt
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,116 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ReadyCondition describes what WaitReady waits for. If none of Port,
+// LogPattern and Reported is set, WaitReady waits for the process's health check to pass,
+// or for its first declared port if it has no health check.
+type ReadyCondition struct {
+	// Port is a local TCP port that must accept connections.
+	Port int
+	// LogPattern must match a line of the process's log output.
+	LogPattern *regexp.Regexp
+	// Reported waits for the process to Report that it is ready.
+	Reported bool
+}
+
+// WaitReady blocks until the process satisfies cond, the process stops
+// running, or ctx is done. It returns the process's latest ProcessView.
+func (m *Manager) WaitReady(ctx context.Context, processID string, cond ReadyCondition) (*ProcessView, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+
+	var check func(ctx context.Context, view ProcessView) bool
+	switch {
+	case cond.Port > 0:
+		check = portReady(cond.Port)
+	case cond.Reported:
+		check = func(_ context.Context, view ProcessView) bool { return view.ReadyAt != nil }
+	case cond.LogPattern != nil:
+		path, err := m.logPath(info)
+		if err != nil {
+			return nil, err
+		}
+		tail, err := OpenLogTail(path, -1)
+		if err != nil {
+			return nil, fmt.Errorf("opening log file: %w", err)
+		}
+		defer tail.Close()
+		scan := &logScanner{tail: tail, pattern: cond.LogPattern}
+		check = func(context.Context, ProcessView) bool { return scan.matched() }
+	case info.HealthCheck != nil:
+		check = func(_ context.Context, view ProcessView) bool { return view.Health == HealthHealthy }
+	case len(info.Ports) > 0:
+		check = portReady(info.Ports[0])
+	default:
+		return nil, errors.New("process has no health check or declared ports; specify a port or log pattern")
+	}
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if latest, err := m.load(info.ID); err == nil {
+			info = latest
+		}
+		view := m.view(info)
+		if view.Status != StatusRunning {
+			return &view, fmt.Errorf("process is %s, not running", view.Status)
+		}
+		if check(ctx, view) {
+			return &view, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return &view, errors.New("timed out waiting for process to become ready")
+		case <-ticker.C:
+		}
+	}
+}
+
+func portReady(port int) func(context.Context, ProcessView) bool {
+	probe := TCPProbe(net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	return func(ctx context.Context, _ ProcessView) bool {
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		return probe(ctx) == nil
+	}
+}
+
+// logScanner incrementally reads a log file looking for a line matching
+// pattern.
+type logScanner struct {
+	tail    *LogTail
+	pattern *regexp.Regexp
+	partial []byte // trailing bytes of an incomplete line
+}
+
+func (s *logScanner) matched() bool {
+	data, err := io.ReadAll(io.LimitReader(s.tail, maxLogRead))
+	if err != nil || len(data) == 0 {
+		return false
+	}
+
+	data = append(s.partial, data...)
+	lines := bytes.Split(data, []byte("\n"))
+	s.partial = append([]byte(nil), lines[len(lines)-1]...)
+	for _, line := range lines[:len(lines)-1] {
+		if s.pattern.Match(line) {
+			return true
+		}
+	}
+	// A prompt-style ready message may never be followed by a newline.
+	return s.pattern.Match(s.partial)
+}
@@ -0,0 +1,107 @@
+package process
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrBadReportToken is returned by Report when the token isn't the one the
+// process was given.
+var ErrBadReportToken = errors.New("invalid report token")
+
+// Report is what a cooperative process tells the Manager about itself, as
+// an alternative to matching its log output. Unset fields are left alone.
+type Report struct {
+	// Ready marks the process as ready; see ReadyCondition.Reported.
+	Ready bool `json:"ready,omitempty"`
+	// Health is HealthHealthy or HealthUnhealthy. A health check, if the
+	// process has one, overrides it at its next probe.
+	Health HealthStatus `json:"health,omitempty"`
+	// Ports are ports the process chose to listen on, replacing any it
+	// reported before.
+	Ports []int `json:"ports,omitempty"`
+}
+
+func (r Report) validate() error {
+	switch r.Health {
+	case "", HealthHealthy, HealthUnhealthy:
+	default:
+		return fmt.Errorf("health must be %q or %q", HealthHealthy, HealthUnhealthy)
+	}
+	for _, p := range r.Ports {
+		if p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port %d", p)
+		}
+	}
+	return nil
+}
+
+// SetControlSocket sets the control socket path passed to processes as
+// THOUGHT_PROCESS_CONTROL_SOCKET, for them to Report through.
+func (m *Manager) SetControlSocket(path string) {
+	m.controlSocket = path
+}
+
+// generateReportToken returns the secret a process proves its identity
+// with when it reports, passed to it as THOUGHT_PROCESS_TOKEN.
+func generateReportToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Report records r for a running process, which authenticates with the
+// token from its THOUGHT_PROCESS_TOKEN variable. Readiness and reported
+// ports last until the process exits.
+func (m *Manager) Report(processID, token string, r Report) (*ProcessView, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	if info.ReportToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(info.ReportToken)) != 1 {
+		return nil, ErrBadReportToken
+	}
+	if st := m.status(info); st != StatusRunning && st != StatusPaused {
+		return nil, fmt.Errorf("process %s is %s", info.ID, st)
+	}
+
+	healthChanged := false
+	if r.Health != "" {
+		m.mu.Lock()
+		if rp, ok := m.running[info.ID]; ok {
+			healthChanged = rp.health != r.Health
+			rp.health = r.Health
+			rp.healthFailures = 0
+		}
+		m.mu.Unlock()
+	}
+	if r.Ready || r.Ports != nil {
+		now := time.Now().UTC()
+		info, err = m.update(info.ID, func(p *ProcessInfo) {
+			if r.Ready && p.ReadyAt == nil {
+				p.ReadyAt = &now
+			}
+			if r.Ports != nil {
+				p.ReportedPorts = slices.Clone(r.Ports)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if healthChanged {
+		m.publish(EventHealthChanged, info)
+	}
+	view := m.view(info)
+	return &view, nil
+}
@@ -0,0 +1,137 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// RestartResult is the outcome of restarting one process with
+// RestartMatching.
+type RestartResult struct {
+	// PreviousID is the ID of the process that was stopped.
+	PreviousID string       `json:"previous_id"`
+	Process    *ProcessView `json:"process,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Restart kills a process and starts it again with the same options. The new
+// process gets a new ID; its view is returned.
+func (m *Manager) Restart(processID string) (*ProcessView, error) {
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.Kill(info.ID); err != nil {
+		return nil, fmt.Errorf("stopping process: %w", err)
+	}
+	// Force, so that restarting one of several identical processes doesn't
+	// return a sibling as a duplicate.
+	opts := info.startOptions()
+	opts.Force = true
+	opts.previousID = info.ID
+	opts.lineageID = info.lineage()
+	return m.Start(opts)
+}
+
+// RestartMatching restarts every running or paused process whose tags
+// include all of tags, in parallel. At least one tag is required. Each
+// matched process gets a result, in list order.
+func (m *Manager) RestartMatching(tags map[string]string) ([]RestartResult, error) {
+	if len(tags) == 0 {
+		return nil, errors.New("at least one tag is required")
+	}
+	views, err := m.List(ListFilter{Tags: tags})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RestartResult, 0, len(views))
+	for _, v := range views {
+		if v.Status == StatusRunning || v.Status == StatusPaused {
+			results = append(results, RestartResult{PreviousID: v.ID})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Go(func() {
+			view, err := m.Restart(results[i].PreviousID)
+			results[i].Process = view
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		})
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// startOptions returns the options info was started with, with any pending
+// env change applied. Allocated ports are requested again rather than reused.
+func (info ProcessInfo) startOptions() StartOptions {
+	return StartOptions{
+		Name:        info.Name,
+		Command:     info.Command,
+		Args:        info.Args,
+		Cwd:         info.Cwd,
+		Env:         info.PendingEnv.apply(info.Env),
+		Tags:        explicitTags(info),
+		Ports:       declaredPorts(info),
+		Restart:     info.Restart,
+		HealthCheck: info.HealthCheck,
+		PTY:         info.PTY,
+
+		MaxRuntimeSecs:  info.MaxRuntimeSecs,
+		IdleTimeoutSecs: info.IdleTimeoutSecs,
+		StopSignal:      info.StopSignal,
+		StopGraceSecs:   info.StopGraceSecs,
+		AllocatePorts:   len(info.AllocatedPorts),
+
+		PreStop:            info.PreStop,
+		PreStopTimeoutSecs: info.PreStopTimeoutSecs,
+		OnExit:             info.OnExit,
+		Watch:              info.Watch,
+
+		Nice:     info.Nice,
+		IOClass:  info.IOClass,
+		CleanEnv: info.CleanEnv,
+		EnvFiles: info.EnvFiles,
+		Exec:     info.Exec,
+		Database: info.Database,
+
+		DependsOn:   info.DependsOn,
+		InContainer: info.InContainer,
+
+		scheduleID: info.ScheduleID,
+		databaseID: info.DatabaseID,
+		stack:      info.Stack,
+		compose:    info.Compose,
+	}
+}
+
+// explicitTags returns info's tags without the ones Start filled in from
+// git, so that a restart picks up a branch switched to since.
+func explicitTags(info ProcessInfo) map[string]string {
+	if len(info.AutoTags) == 0 {
+		return info.Tags
+	}
+	tags := maps.Clone(info.Tags)
+	for _, k := range info.AutoTags {
+		delete(tags, k)
+	}
+	return tags
+}
+
+// declaredPorts returns info's ports that weren't allocated by Start.
+func declaredPorts(info ProcessInfo) []int {
+	var ports []int
+	for _, p := range info.Ports {
+		if !slices.Contains(info.AllocatedPorts, p) {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
@@ -0,0 +1,79 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// retentionInterval is how often RunRetention sweeps exited processes.
+const retentionInterval = time.Hour
+
+// Retention bounds how long and how many exited processes are kept, with
+// their logs. Zero fields are no limit.
+type Retention struct {
+	// MaxAgeDays deletes processes that exited more than this many days
+	// ago.
+	MaxAgeDays int `json:"max_age_days,omitempty"`
+	// MaxCount keeps at most this many exited processes, the most recently
+	// exited.
+	MaxCount int `json:"max_count,omitempty"`
+}
+
+// DefaultRetention keeps exited processes for 30 days.
+var DefaultRetention = Retention{MaxAgeDays: 30}
+
+// SetRetention sets the policy RunRetention applies from its next sweep.
+func (m *Manager) SetRetention(r Retention) error {
+	if r.MaxAgeDays < 0 || r.MaxCount < 0 {
+		return errors.New("retention limits must not be negative")
+	}
+	m.retention.Store(&r)
+	return nil
+}
+
+// RunRetention deletes exited processes' records and logs beyond the
+// retention policy now and then every hour until ctx is done. Each deletion
+// is published as an evicted event.
+func (m *Manager) RunRetention(ctx context.Context) error {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.applyRetention(time.Now()); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyRetention runs one sweep of RunRetention.
+func (m *Manager) applyRetention(now time.Time) error {
+	r := DefaultRetention
+	if p := m.retention.Load(); p != nil {
+		r = *p
+	}
+	if r.MaxAgeDays == 0 && r.MaxCount == 0 {
+		return nil
+	}
+	exited, err := m.exitedRecords()
+	if err != nil {
+		return err
+	}
+	cutoff := now.Add(-time.Duration(r.MaxAgeDays) * 24 * time.Hour)
+	for i, info := range exited {
+		tooMany := r.MaxCount > 0 && len(exited)-i > r.MaxCount
+		tooOld := r.MaxAgeDays > 0 && exitTime(info).Before(cutoff)
+		if !tooMany && !tooOld {
+			// The rest exited later.
+			break
+		}
+		if _, err := m.evictRecord(info, EvictedForRetention); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"syscall"
+	"time"
+
+	"thought-process/store"
+)
+
+const (
+	scheduleKeyPrefix = "schedule:"
+	// scheduleTick is how often due schedules are looked for.
+	scheduleTick = time.Second
+	// schedulerLockRetry is how often a server without the scheduler lock
+	// tries to take it over.
+	schedulerLockRetry = 30 * time.Second
+)
+
+// Schedule starts a process after a delay, once, or whenever a cron
+// expression fires. Each run is a separate process whose ScheduleID is the
+// schedule's ID. A restart schedule instead restarts an existing process
+// whenever its cron expression fires.
+type Schedule struct {
+	ID string `json:"id"`
+	// Cron is set for recurring schedules; see parseCron for the syntax.
+	Cron    string       `json:"cron,omitempty"`
+	Process StartOptions `json:"process,omitzero"`
+	// RestartProcess is the name, or else the current ID, of the process a
+	// restart schedule restarts. An ID is replaced by the new process's ID
+	// after each restart.
+	RestartProcess string `json:"restart_process,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	// NextRun is unset once a one-shot schedule has run.
+	NextRun *time.Time `json:"next_run,omitempty"`
+	LastRun *time.Time `json:"last_run,omitempty"`
+	Runs    int        `json:"runs"`
+	// LastProcessID is the process the last run started; LastError is why
+	// the last run didn't start one, e.g. because the previous run is still
+	// going.
+	LastProcessID string `json:"last_process_id,omitempty"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// AddSchedule registers a schedule that starts opts after delay, or, if
+// cron is set, every time it fires. Runs never overlap: while the previous
+// run is still going, the next one is skipped as a duplicate.
+func (m *Manager) AddSchedule(opts StartOptions, delay time.Duration, cron string) (*Schedule, error) {
+	if opts.Command == "" {
+		return nil, errors.New("command is required")
+	}
+	if delay < 0 {
+		return nil, errors.New("delay must not be negative")
+	}
+	now := time.Now()
+	next := now.Add(delay)
+	if cron != "" {
+		if delay > 0 {
+			return nil, errors.New("use either a delay or a cron expression, not both")
+		}
+		c, err := parseCron(cron)
+		if err != nil {
+			return nil, err
+		}
+		if next = c.next(now); next.IsZero() {
+			return nil, fmt.Errorf("cron %q never fires", cron)
+		}
+	}
+	if opts.Cwd != "" {
+		cwd, err := m.resolveCwd(opts.Cwd)
+		if err != nil {
+			return nil, err
+		}
+		opts.Cwd = cwd
+	}
+	// Runs must not be rejected as duplicates of runs of other schedules;
+	// overlap with the schedule's own previous run is checked at run time.
+	opts.Force = false
+
+	id, err := generateID()
+	if err != nil {
+		return nil, fmt.Errorf("generating schedule ID: %w", err)
+	}
+	next = next.UTC()
+	s := Schedule{ID: id, Cron: cron, Process: opts, CreatedAt: now.UTC(), NextRun: &next}
+	if err := m.saveSchedule(s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// AddRestartSchedule registers a schedule that restarts the process
+// processID (an ID or name) every time cron fires, e.g. to bounce a server
+// that leaks memory every night. Each restart is recorded in the schedule's
+// run history and in the new process's PreviousID.
+func (m *Manager) AddRestartSchedule(processID, cron string) (*Schedule, error) {
+	if cron == "" {
+		return nil, errors.New("cron is required")
+	}
+	c, err := parseCron(cron)
+	if err != nil {
+		return nil, err
+	}
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+	target := info.ID
+	if info.Name != "" {
+		// Names survive restarts, so other restarts don't lose the target.
+		target = info.Name
+	}
+	now := time.Now()
+	next := c.next(now)
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron %q never fires", cron)
+	}
+
+	id, err := generateID()
+	if err != nil {
+		return nil, fmt.Errorf("generating schedule ID: %w", err)
+	}
+	next = next.UTC()
+	s := Schedule{ID: id, Cron: cron, RestartProcess: target, CreatedAt: now.UTC(), NextRun: &next}
+	if err := m.saveSchedule(s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Schedules returns the registered schedules, oldest first.
+func (m *Manager) Schedules() ([]Schedule, error) {
+	keys, err := m.store.List(scheduleKeyPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing schedules: %w", err)
+	}
+	schedules := make([]Schedule, 0, len(keys))
+	for _, key := range keys {
+		data, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var s Schedule
+		if json.Unmarshal(data, &s) == nil {
+			schedules = append(schedules, s)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].CreatedAt.Before(schedules[j].CreatedAt) })
+	return schedules, nil
+}
+
+// CancelSchedule deletes a schedule. Runs it already started keep running.
+func (m *Manager) CancelSchedule(id string) error {
+	m.schedMu.Lock()
+	defer m.schedMu.Unlock()
+	if _, err := m.store.Get(scheduleKeyPrefix + id); errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("schedule %q not found", id)
+	} else if err != nil {
+		return err
+	}
+	return m.store.Delete(scheduleKeyPrefix + id)
+}
+
+func (m *Manager) saveSchedule(s Schedule) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := m.store.Set(scheduleKeyPrefix+s.ID, data); err != nil {
+		return fmt.Errorf("persisting schedule: %w", err)
+	}
+	return nil
+}
+
+// updateSchedule applies fn to the stored schedule id under schedMu. It
+// reports false if the schedule no longer exists.
+func (m *Manager) updateSchedule(id string, fn func(*Schedule)) (bool, error) {
+	m.schedMu.Lock()
+	defer m.schedMu.Unlock()
+	data, err := m.store.Get(scheduleKeyPrefix + id)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return false, err
+	}
+	fn(&s)
+	return true, m.saveSchedule(s)
+}
+
+// RunScheduler starts due schedules until ctx is done. Only one server per
+// data directory runs schedules: the one holding an exclusive lock on
+// lockPath. The others keep trying to take the lock over, so schedules keep
+// running when that server exits.
+func (m *Manager) RunScheduler(ctx context.Context, lockPath string) error {
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) != nil {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(schedulerLockRetry):
+		}
+	}
+
+	ticker := time.NewTicker(scheduleTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		schedules, err := m.Schedules()
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		for _, s := range schedules {
+			if s.NextRun != nil && !s.NextRun.After(now) {
+				m.runSchedule(s, now)
+			}
+		}
+	}
+}
+
+// runSchedule starts one run of s and records the outcome. The next run
+// time is advanced before starting, so a slow start can't fire it twice.
+func (m *Manager) runSchedule(s Schedule, now time.Time) {
+	var next *time.Time
+	if s.Cron != "" {
+		if c, err := parseCron(s.Cron); err == nil {
+			// Runs missed while no server was up are not caught up on.
+			if t := c.next(now.Local()); !t.IsZero() {
+				t = t.UTC()
+				next = &t
+			}
+		}
+	}
+	ok, err := m.updateSchedule(s.ID, func(s *Schedule) { s.NextRun = next })
+	if !ok || err != nil {
+		return
+	}
+	if s.RestartProcess != "" {
+		m.runRestart(s, now)
+		return
+	}
+
+	opts := s.Process
+	opts.scheduleID = s.ID
+	view, err := m.Start(opts)
+	m.updateSchedule(s.ID, func(s *Schedule) {
+		ran := now.UTC()
+		s.LastRun = &ran
+		s.LastError = ""
+		switch {
+		case err != nil:
+			s.LastError = err.Error()
+		case view.Duplicate:
+			s.LastError = fmt.Sprintf("skipped: previous run %s is still running", view.ID)
+		default:
+			s.Runs++
+			s.LastProcessID = view.ID
+		}
+	})
+}
+
+// runRestart restarts the target of the restart schedule s and records the
+// outcome. A target that isn't running is left alone.
+func (m *Manager) runRestart(s Schedule, now time.Time) {
+	var view *ProcessView
+	info, err := m.lookup(s.RestartProcess)
+	if err == nil {
+		if status := m.status(info); status != StatusRunning && status != StatusPaused {
+			err = fmt.Errorf("skipped: process %s is %s", info.ID, status)
+		} else {
+			view, err = m.Restart(info.ID)
+		}
+	}
+	m.updateSchedule(s.ID, func(s *Schedule) {
+		ran := now.UTC()
+		s.LastRun = &ran
+		s.LastError = ""
+		if err != nil {
+			s.LastError = err.Error()
+			return
+		}
+		s.Runs++
+		s.LastProcessID = view.ID
+		if s.RestartProcess == info.ID {
+			s.RestartProcess = view.ID
+		}
+	})
+}
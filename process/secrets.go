@@ -0,0 +1,57 @@
+package process
+
+import (
+	"fmt"
+
+	"thought-process/secrets"
+)
+
+// SetSecretResolver replaces the resolver used for secret references in env
+// values. The default resolves keychain, op and vault references with no
+// provider configuration. Spawns after the call use r.
+func (m *Manager) SetSecretResolver(r *secrets.Resolver) {
+	m.secrets.Store(r)
+}
+
+// resolveEnv returns env as KEY=VALUE pairs with secret references such as
+// "keychain:NAME" resolved, taking those in resolved as they are. Only the
+// references are ever persisted.
+func (m *Manager) resolveEnv(env, resolved map[string]string) ([]string, error) {
+	pairs := make([]string, 0, len(env))
+	for k, v := range env {
+		secret, ok := resolved[v]
+		if !ok {
+			var err error
+			if secret, err = m.secrets.Load().Resolve(v); err != nil {
+				return nil, fmt.Errorf("resolving %s: %w", k, err)
+			}
+		}
+		pairs = append(pairs, k+"="+secret)
+	}
+	return pairs, nil
+}
+
+// resolveSecrets resolves the secret references in env and envFiles, as
+// environ would with tmpl, for an expander to reuse, so lookups that wait
+// on op or a keychain prompt can run before a lock is taken. Values whose
+// placeholders tmpl can't expand yet, such as ${PORT}, are left to environ.
+func (m *Manager) resolveSecrets(tmpl *expander, env map[string]string, envFiles []string) (map[string]string, error) {
+	custom := make(map[string]string, len(env))
+	for k, v := range env {
+		// An empty value still overrides the env files.
+		custom[k], _ = tmpl.expandKnown(v)
+	}
+	vars, err := loadEnvFiles(envFiles, custom)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]string)
+	for k, v := range vars {
+		secret, err := m.secrets.Load().Resolve(v)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", k, err)
+		}
+		resolved[v] = secret
+	}
+	return resolved, nil
+}
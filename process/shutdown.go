@@ -0,0 +1,28 @@
+package process
+
+import "fmt"
+
+// ShutdownPolicy is what Shutdown does with running processes.
+type ShutdownPolicy string
+
+const (
+	// ShutdownStop stops every process the server started.
+	ShutdownStop ShutdownPolicy = "stop"
+	// ShutdownKeep leaves processes running for the next server to adopt.
+	// Their restart policies and stdin are lost, and PTY processes get
+	// SIGHUP when their terminal closes.
+	ShutdownKeep ShutdownPolicy = "keep"
+)
+
+// SetShutdownPolicy sets what Shutdown does; the default is ShutdownStop.
+func (m *Manager) SetShutdownPolicy(p ShutdownPolicy) error {
+	switch p {
+	case ShutdownStop, ShutdownKeep:
+	default:
+		return fmt.Errorf("unknown shutdown policy %q: use %q or %q", p, ShutdownStop, ShutdownKeep)
+	}
+	m.mu.Lock()
+	m.shutdownPolicy = p
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,92 @@
+package process
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// sleepCheckInterval is how often RunSleepWatch compares the wall clock
+	// with the monotonic clock.
+	sleepCheckInterval = 5 * time.Second
+	// minSleep is how far the wall clock must get ahead of the monotonic
+	// clock between two checks to count as a sleep.
+	minSleep = 30 * time.Second
+	// wakeSettle is how long to give exits that happened during a sleep to
+	// be recorded before looking for them.
+	wakeSettle = 3 * time.Second
+)
+
+// Sleep is a period the system spent suspended.
+type Sleep struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Secs  int64     `json:"secs"`
+}
+
+// RunSleepWatch detects system sleeps until ctx is done. The monotonic
+// clock stands still while the system is suspended and the wall clock
+// doesn't, so a sleep shows up as the wall clock jumping ahead of it. On
+// waking, every health check runs at once, processes that died unobserved
+// are marked exited, and each process that exited during the sleep or
+// just after it gets a died_in_sleep event.
+func (m *Manager) RunSleepWatch(ctx context.Context) error {
+	ticker := time.NewTicker(sleepCheckInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		now := time.Now()
+		// Round(0) drops the monotonic reading, so Sub compares wall times.
+		slept := now.Round(0).Sub(last.Round(0)) - now.Sub(last)
+		if slept >= minSleep {
+			m.wake(Sleep{Start: last.UTC(), End: now.UTC(), Secs: int64(slept.Seconds())})
+		}
+		last = now
+	}
+}
+
+// wake re-verifies processes after sleep s.
+func (m *Manager) wake(s Sleep) {
+	// Wake the health checks.
+	m.mu.Lock()
+	close(m.woke)
+	m.woke = make(chan struct{})
+	m.mu.Unlock()
+
+	time.Sleep(wakeSettle)
+	infos, err := m.records()
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		if info.ExitCode == nil && info.ExitedAt == nil {
+			m.mu.Lock()
+			_, live := m.running[info.ID]
+			m.mu.Unlock()
+			// Processes this server doesn't watch aren't noticed exiting.
+			if live || sameProcess(info.PID, info.PIDStart) {
+				continue
+			}
+			m.recordLostExit(info, m.lastOutput(info))
+			if info, err = m.load(info.ID); err != nil {
+				continue
+			}
+		}
+		if info.ExitedAt == nil || info.ExitedAt.Before(s.Start) {
+			continue
+		}
+		m.publishEvent(Event{Type: EventDiedInSleep, Time: time.Now().UTC(), Process: m.view(info), Sleep: &s})
+	}
+}
+
+// wokeChan returns a channel closed at the next wake.
+func (m *Manager) wokeChan() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.woke
+}
@@ -0,0 +1,287 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"thought-process/store"
+)
+
+const stackKeyPrefix = "stack:"
+
+// Stack is a named set of process definitions that are started, stopped and
+// restarted together. Processes started for it record the stack's name, so
+// its members can be found again after restarts.
+type Stack struct {
+	Name string `json:"name"`
+	// Processes are the definitions, each with a unique Name. DependsOn
+	// entries naming other definitions decide the start order; others refer
+	// to processes outside the stack.
+	Processes []StartOptions `json:"processes"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// StackView is a Stack with its current members.
+type StackView struct {
+	Stack
+	// Members holds, in definition order, the running process for each
+	// definition, or else the last one started. Definitions never started
+	// are left out.
+	Members []ProcessView `json:"members"`
+}
+
+// StackResult is the outcome of starting one definition of a stack, one
+// entry of a Procfile or one compose service.
+type StackResult struct {
+	Name    string       `json:"name"`
+	Process *ProcessView `json:"process,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// DefineStack records the definitions of the stack name, replacing any
+// previous ones. Processes already started for the stack are left alone.
+func (m *Manager) DefineStack(name string, defs []StartOptions) (*Stack, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, errors.New("a stack needs at least one process")
+	}
+	ports := make(map[int]string)
+	for i, def := range defs {
+		if def.Command == "" && def.InContainer == nil {
+			return nil, fmt.Errorf("process %d: command is required", i)
+		}
+		if def.Name == "" {
+			return nil, fmt.Errorf("process %d: name is required", i)
+		}
+		if err := validateName(def.Name); err != nil {
+			return nil, fmt.Errorf("process %d: %w", i, err)
+		}
+		for _, port := range def.Ports {
+			if other, ok := ports[port]; ok {
+				return nil, fmt.Errorf("port %d is declared by both %q and %q", port, other, def.Name)
+			}
+			ports[port] = def.Name
+		}
+	}
+	if _, err := stackOrder(defs); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	st := Stack{Name: name, Processes: defs, CreatedAt: now, UpdatedAt: now}
+	if old, err := m.loadStack(name); err == nil {
+		st.CreatedAt = old.CreatedAt
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Set(stackKeyPrefix+name, data); err != nil {
+		return nil, fmt.Errorf("persisting stack: %w", err)
+	}
+	return &st, nil
+}
+
+// Stacks returns the defined stacks with their members, sorted by name.
+func (m *Manager) Stacks() ([]StackView, error) {
+	keys, err := m.store.List(stackKeyPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing stacks: %w", err)
+	}
+	infos, err := m.records()
+	if err != nil {
+		return nil, err
+	}
+	stacks := make([]StackView, 0, len(keys))
+	for _, key := range keys {
+		data, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var st Stack
+		if json.Unmarshal(data, &st) == nil {
+			stacks = append(stacks, StackView{Stack: st, Members: m.stackMembers(st, infos)})
+		}
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Name < stacks[j].Name })
+	return stacks, nil
+}
+
+// DeleteStack removes a stack's definition. Its processes keep running.
+func (m *Manager) DeleteStack(name string) error {
+	if _, err := m.loadStack(name); err != nil {
+		return err
+	}
+	return m.store.Delete(stackKeyPrefix + name)
+}
+
+// StartStack starts the stack's definitions in dependency order, each
+// waiting for its dependencies as Start does. Members already running are
+// returned as they are; if a definition fails to start, the ones depending
+// on it are skipped. Results are in definition order.
+func (m *Manager) StartStack(name string) ([]StackResult, error) {
+	st, err := m.loadStack(name)
+	if err != nil {
+		return nil, err
+	}
+	order, err := stackOrder(st.Processes)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]StackResult, len(st.Processes))
+	failed := make(map[string]bool)
+	for _, i := range order {
+		opts := st.Processes[i]
+		results[i].Name = opts.Name
+		if dep := slices.IndexFunc(opts.DependsOn, func(d string) bool { return failed[d] }); dep >= 0 {
+			results[i].Error = fmt.Sprintf("skipped: dependency %q failed to start", opts.DependsOn[dep])
+			failed[opts.Name] = true
+			continue
+		}
+
+		opts.stack = name
+		view, err := m.Start(opts)
+		if errors.Is(err, ErrNameTaken) && view != nil && view.Stack == name {
+			// Started earlier with a since-changed definition; keep it.
+			err = nil
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			failed[opts.Name] = true
+			continue
+		}
+		results[i].Process = view
+	}
+	return results, nil
+}
+
+// StopStack kills the stack's running and paused members, dependents before
+// their dependencies, and returns their final state.
+func (m *Manager) StopStack(name string) ([]ProcessView, error) {
+	st, err := m.loadStack(name)
+	if err != nil {
+		return nil, err
+	}
+	order, err := stackOrder(st.Processes)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := m.records()
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]ProcessView)
+	for _, v := range m.stackMembers(st, infos) {
+		members[v.Name] = v
+	}
+
+	var stopped []ProcessView
+	for _, i := range slices.Backward(order) {
+		v, ok := members[st.Processes[i].Name]
+		if !ok || (v.Status != StatusRunning && v.Status != StatusPaused) {
+			continue
+		}
+		view, err := m.Kill(v.ID)
+		if err != nil {
+			return stopped, fmt.Errorf("stopping %q: %w", v.Name, err)
+		}
+		stopped = append(stopped, *view)
+	}
+	return stopped, nil
+}
+
+// RestartStack stops the stack and starts it again from its definitions.
+func (m *Manager) RestartStack(name string) ([]StackResult, error) {
+	if _, err := m.StopStack(name); err != nil {
+		return nil, err
+	}
+	return m.StartStack(name)
+}
+
+func (m *Manager) loadStack(name string) (Stack, error) {
+	data, err := m.store.Get(stackKeyPrefix + name)
+	if errors.Is(err, store.ErrNotFound) {
+		return Stack{}, fmt.Errorf("stack %q not found", name)
+	}
+	if err != nil {
+		return Stack{}, fmt.Errorf("reading stack: %w", err)
+	}
+	var st Stack
+	if err := json.Unmarshal(data, &st); err != nil {
+		return Stack{}, fmt.Errorf("decoding stack: %w", err)
+	}
+	return st, nil
+}
+
+// stackMembers picks, for each of st's definitions, the running process
+// started for it from infos, or else the most recently started one.
+func (m *Manager) stackMembers(st Stack, infos []ProcessInfo) []ProcessView {
+	best := make(map[string]ProcessView)
+	for _, info := range infos {
+		if info.Stack != st.Name {
+			continue
+		}
+		view := m.view(info)
+		cur, ok := best[info.Name]
+		live := view.Status == StatusRunning || view.Status == StatusPaused
+		curLive := ok && (cur.Status == StatusRunning || cur.Status == StatusPaused)
+		if !ok || (live && !curLive) || (live == curLive && view.StartedAt.After(cur.StartedAt)) {
+			best[info.Name] = view
+		}
+	}
+	members := make([]ProcessView, 0, len(st.Processes))
+	for _, def := range st.Processes {
+		if v, ok := best[def.Name]; ok {
+			members = append(members, v)
+		}
+	}
+	return members
+}
+
+// stackOrder returns the indexes of defs in dependency order, keeping the
+// given order among definitions that are ready at the same time.
+// Dependencies on names outside defs don't affect the order.
+func stackOrder(defs []StartOptions) ([]int, error) {
+	byName := make(map[string]int, len(defs))
+	for i, def := range defs {
+		if _, dup := byName[def.Name]; dup {
+			return nil, fmt.Errorf("name %q is used by more than one process", def.Name)
+		}
+		byName[def.Name] = i
+	}
+
+	order := make([]int, 0, len(defs))
+	started := make([]bool, len(defs))
+	for len(order) < len(defs) {
+		progressed := false
+		for i, def := range defs {
+			ready := !started[i] && !slices.ContainsFunc(def.DependsOn, func(dep string) bool {
+				j, ok := byName[dep]
+				return ok && !started[j]
+			})
+			if ready {
+				started[i] = true
+				order = append(order, i)
+				progressed = true
+			}
+		}
+		if !progressed {
+			var stuck []string
+			for i, def := range defs {
+				if !started[i] {
+					stuck = append(stuck, def.Name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle between %v", stuck)
+		}
+	}
+	return order, nil
+}
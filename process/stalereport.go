@@ -0,0 +1,166 @@
+package process
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Repository is a git repository tracked processes run in, with its
+// worktrees and local branches as git reports them.
+type Repository struct {
+	// GitDir is the repository's common git directory, shared by all of its
+	// worktrees.
+	GitDir    string     `json:"git_dir"`
+	Worktrees []Worktree `json:"worktrees"`
+	Branches  []string   `json:"branches"`
+	// Processes counts the tracked processes in the repository.
+	Processes int `json:"processes"`
+}
+
+// Worktree is a working tree of a Repository.
+type Worktree struct {
+	Path string `json:"path"`
+	// Branch is the checked-out branch; empty for a detached HEAD.
+	Branch string `json:"branch,omitempty"`
+	// Processes counts the tracked processes in the worktree.
+	Processes int `json:"processes"`
+}
+
+// StaleReport cross-references tracked processes with the worktrees and
+// branches of their repositories.
+type StaleReport struct {
+	Repositories []Repository `json:"repositories"`
+	// Stale are the processes, running or not, whose worktree or branch is
+	// gone.
+	Stale []StaleProcess `json:"stale"`
+}
+
+// StaleReport checks every tracked process, running or exited, against the
+// worktrees and branches of the git repository at its worktree tag, else its
+// cwd. With cleanup, stale running and paused processes are killed and the
+// records and logs of stale exited ones are deleted, in parallel.
+func (m *Manager) StaleReport(cleanup bool) (*StaleReport, error) {
+	views, err := m.List(ListFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StaleReport{Repositories: []Repository{}, Stale: []StaleProcess{}}
+	// Processes mostly share a few worktrees and branches, so git is run
+	// once for each.
+	reasons := make(map[[3]string]string)
+	gitDirs := make(map[string]string)
+	repos := make(map[string]*Repository)
+	for _, v := range views {
+		key := [3]string{v.Tags[TagWorktree], v.Cwd, v.Tags[TagBranch]}
+		reason, ok := reasons[key]
+		if !ok {
+			reason = staleReason(v.ProcessInfo)
+			reasons[key] = reason
+		}
+		if reason != "" {
+			report.Stale = append(report.Stale, StaleProcess{Process: v, Reason: reason})
+		}
+
+		dir := cmp.Or(v.Tags[TagWorktree], v.Cwd)
+		gitDir, ok := gitDirs[dir]
+		if !ok {
+			gitDir = commonGitDir(dir)
+			gitDirs[dir] = gitDir
+		}
+		if gitDir == "" {
+			continue
+		}
+		repo := repos[gitDir]
+		if repo == nil {
+			repo = loadRepository(gitDir, dir)
+			repos[gitDir] = repo
+		}
+		repo.Processes++
+		// Worktrees may be nested in the main one; the innermost wins.
+		var in *Worktree
+		for i, wt := range repo.Worktrees {
+			if withinDir(dir, wt.Path) && (in == nil || len(wt.Path) > len(in.Path)) {
+				in = &repo.Worktrees[i]
+			}
+		}
+		if in != nil {
+			in.Processes++
+		}
+	}
+	for _, repo := range repos {
+		report.Repositories = append(report.Repositories, *repo)
+	}
+	slices.SortFunc(report.Repositories, func(a, b Repository) int { return strings.Compare(a.GitDir, b.GitDir) })
+	if !cleanup {
+		return report, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range report.Stale {
+		wg.Go(func() {
+			s := &report.Stale[i]
+			if s.Process.Status == StatusRunning || s.Process.Status == StatusPaused {
+				view, err := m.Kill(s.Process.ID)
+				if err != nil {
+					s.Error = err.Error()
+					return
+				}
+				s.Process = *view
+				s.Killed = true
+				return
+			}
+			if _, err := m.Delete(s.Process.ID, false); err != nil {
+				s.Error = err.Error()
+				return
+			}
+			s.Deleted = true
+		})
+	}
+	wg.Wait()
+	return report, nil
+}
+
+// commonGitDir returns the absolute common git directory of the repository
+// at dir, or "" if dir is gone or not in one.
+func commonGitDir(dir string) string {
+	if !inGitRepo(dir) {
+		return ""
+	}
+	out, err := git(dir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return ""
+	}
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+	return filepath.Clean(out)
+}
+
+// loadRepository lists the worktrees and local branches of the repository
+// at dir. Either is left empty if git fails.
+func loadRepository(gitDir, dir string) *Repository {
+	repo := &Repository{GitDir: gitDir, Worktrees: []Worktree{}, Branches: []string{}}
+	if out, err := git(dir, "worktree", "list", "--porcelain"); err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			if path, ok := strings.CutPrefix(line, "worktree "); ok {
+				repo.Worktrees = append(repo.Worktrees, Worktree{Path: path})
+			} else if ref, ok := strings.CutPrefix(line, "branch "); ok && len(repo.Worktrees) > 0 {
+				repo.Worktrees[len(repo.Worktrees)-1].Branch = strings.TrimPrefix(ref, "refs/heads/")
+			}
+		}
+	}
+	if out, err := git(dir, "for-each-ref", "--format=%(refname:short)", "refs/heads/"); err == nil && out != "" {
+		repo.Branches = strings.Split(out, "\n")
+	}
+	return repo
+}
+
+// withinDir reports whether path is dir or inside it.
+func withinDir(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
@@ -0,0 +1,17 @@
+package process
+
+// sameProcess reports whether pid still belongs to the process that was
+// recorded with start time start, rather than to a later process that was
+// given the recycled PID. A zero start (records from older versions, or a
+// start time that couldn't be read) only checks that the PID exists.
+func sameProcess(pid int, start int64) bool {
+	if !pidAlive(pid) {
+		return false
+	}
+	if start == 0 {
+		return true
+	}
+	current, err := processStartTime(pid)
+	// If the start time can't be read now, fall back to trusting the PID.
+	return err != nil || current == start
+}
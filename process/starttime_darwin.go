@@ -0,0 +1,15 @@
+package process
+
+import "golang.org/x/sys/unix"
+
+// processStartTime returns when pid started, in microseconds since the
+// epoch, from sysctl kern.proc.pid. It is only meant to be compared with
+// another value from this function.
+func processStartTime(pid int) (int64, error) {
+	kp, err := unix.SysctlKinfoProc("kern.proc.pid", pid)
+	if err != nil {
+		return 0, err
+	}
+	start := kp.Proc.P_starttime
+	return start.Sec*1e6 + int64(start.Usec), nil
+}
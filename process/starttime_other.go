@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package process
+
+import "errors"
+
+// processStartTime is not implemented here, so PIDs are trusted as they
+// are; see sameProcess.
+func processStartTime(pid int) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,64 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// maxInput bounds the input SendInput writes at once.
+	maxInput = 64 * 1024
+	// inputTimeout bounds how long SendInput waits for a process that isn't
+	// reading its stdin and whose pipe buffer is full.
+	inputTimeout = 5 * time.Second
+)
+
+// SendInput writes input to the process's stdin, followed by a newline if it
+// doesn't already end with one. Non-PTY processes get a pipe that stays open
+// while they run, so a program reading its stdin to EOF never sees it.
+func (m *Manager) SendInput(processID, input string) (*ProcessView, error) {
+	if len(input) > maxInput {
+		return nil, fmt.Errorf("input is %d bytes; at most %d can be sent at once", len(input), maxInput)
+	}
+	info, err := m.lookup(processID)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdin io.Writer
+	adopted := false
+	m.mu.Lock()
+	if rp, ok := m.running[info.ID]; ok {
+		stdin, adopted = rp.stdin, rp.adopted
+	}
+	m.mu.Unlock()
+	if adopted {
+		return nil, fmt.Errorf("process %q was started by an earlier server, which held its stdin", processID)
+	}
+	if stdin == nil {
+		return nil, fmt.Errorf("process %q is %s, not running", processID, m.status(info))
+	}
+
+	if !strings.HasSuffix(input, "\n") {
+		input += "\n"
+	}
+	// A blocked write is left to finish, or fail once the process exits and
+	// its stdin is closed.
+	written := make(chan error, 1)
+	go func() {
+		_, err := stdin.Write([]byte(input))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			return nil, fmt.Errorf("writing to stdin: %w", err)
+		}
+	case <-time.After(inputTimeout):
+		return nil, fmt.Errorf("writing to stdin: timed out after %s; process %q isn't reading its input", inputTimeout, processID)
+	}
+	view := m.view(info)
+	return &view, nil
+}
@@ -0,0 +1,76 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultStopGrace is how long Kill waits after the stop signal before
+// SIGKILLing a process that doesn't set its own grace period.
+const DefaultStopGrace = 5 * time.Second
+
+// maxStopGraceSecs bounds StopGraceSecs, so a typo can't make Kill hang.
+const maxStopGraceSecs = 600
+
+// stopSignals are the signals a process can be stopped with.
+var stopSignals = map[string]syscall.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// KillOptions override a process's stop signal and grace period for one
+// KillWith.
+type KillOptions struct {
+	// Signal is sent first, e.g. "SIGINT" or "INT"; empty means the
+	// process's stop signal.
+	Signal string
+	// GraceSecs is how long to wait for the process to exit before
+	// SIGKILLing it; 0 means the process's grace period.
+	GraceSecs int
+}
+
+// normalizeSignal returns the canonical name of a stop signal given with
+// or without the SIG prefix, in any case. Empty stays empty.
+func normalizeSignal(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	if _, ok := stopSignals[name]; !ok {
+		return "", fmt.Errorf("unsupported stop signal %q: use SIGTERM, SIGINT, SIGQUIT, SIGHUP, SIGUSR1 or SIGUSR2", name)
+	}
+	return name, nil
+}
+
+// validateStopGrace checks a grace period in seconds.
+func validateStopGrace(secs int) error {
+	if secs < 0 || secs > maxStopGraceSecs {
+		return fmt.Errorf("stop grace period must be between 0 and %d seconds", maxStopGraceSecs)
+	}
+	return nil
+}
+
+// stopSignal returns the signal info is stopped with.
+func (info ProcessInfo) stopSignal() syscall.Signal {
+	if sig, ok := stopSignals[info.StopSignal]; ok {
+		return sig
+	}
+	return syscall.SIGTERM
+}
+
+// stopGrace returns how long info gets to exit after its stop signal.
+func (info ProcessInfo) stopGrace() time.Duration {
+	if info.StopGraceSecs > 0 {
+		return time.Duration(info.StopGraceSecs) * time.Second
+	}
+	return DefaultStopGrace
+}
@@ -0,0 +1,58 @@
+package process
+
+import "fmt"
+
+// summaryWindow is how long, in seconds, a failed process counts as failing
+// in a Summary.
+const summaryWindow = 3600
+
+// Summary is a compact count of process states, for status bars.
+type Summary struct {
+	Running int `json:"running"`
+	Paused  int `json:"paused"`
+	// Failing counts processes that failed (other than by being killed),
+	// are crash looping or timed out, and exited within the last hour.
+	Failing int `json:"failing"`
+	// Unhealthy counts running processes whose health check is failing.
+	Unhealthy int `json:"unhealthy"`
+}
+
+// String formats s for a status line, e.g. "3 running, 1 failing".
+func (s Summary) String() string {
+	text := fmt.Sprintf("%d running", s.Running)
+	if s.Paused > 0 {
+		text += fmt.Sprintf(", %d paused", s.Paused)
+	}
+	if s.Unhealthy > 0 {
+		text += fmt.Sprintf(", %d unhealthy", s.Unhealthy)
+	}
+	if s.Failing > 0 {
+		text += fmt.Sprintf(", %d failing", s.Failing)
+	}
+	return text
+}
+
+// Summary counts running, paused, failing and unhealthy processes.
+func (m *Manager) Summary() (*Summary, error) {
+	views, err := m.List(ListFilter{ExitedSinceSecs: summaryWindow})
+	if err != nil {
+		return nil, err
+	}
+	var s Summary
+	for _, v := range views {
+		switch v.Status {
+		case StatusRunning:
+			s.Running++
+			if v.Health == HealthUnhealthy {
+				s.Unhealthy++
+			}
+		case StatusPaused:
+			s.Paused++
+		case StatusFailed, StatusCrashLooping, StatusTimedOut:
+			if !v.Killed {
+				s.Failing++
+			}
+		}
+	}
+	return &s, nil
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"thought-process/store"
+)
+
+// ManifestFile is the name of a project's manifest in its root directory.
+const ManifestFile = ".thought-process.json"
+
+// Manifest is a project's .thought-process.json.
+type Manifest struct {
+	// Tasks are the project's named commands, e.g. "seed" or
+	// "generate-types", run with RunTask.
+	Tasks map[string]Task `json:"tasks,omitempty"`
+}
+
+// Task is a named command in a project manifest.
+type Task struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Command     string   `json:"command"`
+	Args        []string `json:"args,omitempty"`
+	// Cwd is relative to the project root, which is also the default.
+	Cwd  string            `json:"cwd,omitempty"`
+	Env  map[string]string `json:"env,omitempty"`
+	Exec bool              `json:"exec,omitempty"`
+	// MaxRuntimeSecs stops the task when it runs too long.
+	MaxRuntimeSecs int `json:"max_runtime_secs,omitempty"`
+}
+
+// ProjectTasks returns the tasks in the manifest of project, a registered
+// project name or the absolute path of a project root such as a worktree,
+// sorted by name.
+func (m *Manager) ProjectTasks(project string) ([]Task, error) {
+	_, manifest, err := m.loadManifest(project)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]Task, 0, len(manifest.Tasks))
+	for name, t := range manifest.Tasks {
+		t.Name = name
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks, nil
+}
+
+// RunTask starts the task name from the manifest of project as a tracked
+// process tagged project=<project>, task=<name> and with tags, which should
+// carry the caller's branch. env is added to the task's own env.
+func (m *Manager) RunTask(project, name string, tags, env map[string]string) (*ProcessView, error) {
+	root, manifest, err := m.loadManifest(project)
+	if err != nil {
+		return nil, err
+	}
+	task, ok := manifest.Tasks[name]
+	if !ok {
+		return nil, fmt.Errorf("project %s has no task %q in %s", project, name, ManifestFile)
+	}
+	if task.Command == "" {
+		return nil, fmt.Errorf("task %q has no command", name)
+	}
+	cwd := filepath.Join(root, task.Cwd)
+	if !within(root, cwd) {
+		return nil, fmt.Errorf("task %q: cwd %q escapes the project", name, task.Cwd)
+	}
+
+	allTags := maps.Clone(tags)
+	if allTags == nil {
+		allTags = make(map[string]string)
+	}
+	allTags["project"] = project
+	if filepath.IsAbs(project) {
+		allTags["project"] = filepath.Base(root)
+	}
+	allTags["task"] = name
+	taskEnv := maps.Clone(task.Env)
+	if len(env) > 0 {
+		if taskEnv == nil {
+			taskEnv = make(map[string]string)
+		}
+		maps.Copy(taskEnv, env)
+	}
+	return m.Start(StartOptions{
+		Command:        task.Command,
+		Args:           task.Args,
+		Cwd:            cwd,
+		Env:            taskEnv,
+		Tags:           allTags,
+		Exec:           task.Exec,
+		MaxRuntimeSecs: task.MaxRuntimeSecs,
+	})
+}
+
+// loadManifest returns the root of project and its parsed manifest.
+func (m *Manager) loadManifest(project string) (string, *Manifest, error) {
+	root := project
+	if !filepath.IsAbs(project) {
+		path, err := m.store.Get(projectKeyPrefix + project)
+		if errors.Is(err, store.ErrNotFound) {
+			return "", nil, fmt.Errorf("unknown project %q (register it with register_project, or pass an absolute path)", project)
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("reading project: %w", err)
+		}
+		root = string(path)
+	}
+	root = filepath.Clean(root)
+
+	data, err := os.ReadFile(filepath.Join(root, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil, fmt.Errorf("%s has no %s", root, ManifestFile)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", nil, fmt.Errorf("parsing %s: %w", filepath.Join(root, ManifestFile), err)
+	}
+	return root, &manifest, nil
+}
@@ -0,0 +1,164 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// placeholder matches the ${...} placeholders expanded in a process's
+// command, args and env values. Others, like ${HOME}, are left for the
+// shell.
+var placeholder = regexp.MustCompile(`\$\{(PORT(?:_[0-9]+)?|BRANCH|WORKTREE|ID)\}`)
+
+// gitTimeout bounds the git commands run to expand ${BRANCH} and ${WORKTREE}.
+const gitTimeout = 5 * time.Second
+
+// expander expands placeholders for one spawn of a process, running git at
+// most once per value.
+type expander struct {
+	info *ProcessInfo
+	vars map[string]string
+	// secrets holds secret references resolved ahead of the spawn, by
+	// reference; see resolveSecrets.
+	secrets map[string]string
+}
+
+func newExpander(info *ProcessInfo) *expander {
+	return &expander{info: info, vars: map[string]string{"ID": info.ID}}
+}
+
+// gitVars resolves the ${BRANCH} and ${WORKTREE} placeholders in texts for
+// a process in cwd, for an expander to reuse, so git can run before a lock
+// is taken.
+func gitVars(cwd string, texts ...string) (map[string]string, error) {
+	e := newExpander(&ProcessInfo{Cwd: cwd})
+	delete(e.vars, "ID")
+	for _, s := range texts {
+		for _, match := range placeholder.FindAllStringSubmatch(s, -1) {
+			if name := match[1]; name == "BRANCH" || name == "WORKTREE" {
+				if _, err := e.value(name); err != nil {
+					return nil, fmt.Errorf("expanding ${%s}: %w", name, err)
+				}
+			}
+		}
+	}
+	return e.vars, nil
+}
+
+// expand replaces the placeholders in s. A placeholder that can't be
+// resolved, such as ${PORT} for a process without ports, is an error.
+func (e *expander) expand(s string) (string, error) {
+	var err error
+	out := placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := m[2 : len(m)-1]
+		v, verr := e.value(name)
+		if verr != nil && err == nil {
+			err = fmt.Errorf("expanding ${%s}: %w", name, verr)
+		}
+		return v
+	})
+	return out, err
+}
+
+// expandKnown expands s if every placeholder in it already has a value,
+// without running git. ok is false otherwise.
+func (e *expander) expandKnown(s string) (out string, ok bool) {
+	for _, match := range placeholder.FindAllStringSubmatch(s, -1) {
+		if _, ok := e.vars[match[1]]; !ok {
+			return "", false
+		}
+	}
+	out, err := e.expand(s)
+	return out, err == nil
+}
+
+// expandEnv returns env with placeholders in its values expanded.
+func (e *expander) expandEnv(env map[string]string) (map[string]string, error) {
+	out := maps.Clone(env)
+	for k, v := range env {
+		expanded, err := e.expand(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = expanded
+	}
+	return out, nil
+}
+
+func (e *expander) value(name string) (string, error) {
+	if v, ok := e.vars[name]; ok {
+		return v, nil
+	}
+	var v string
+	var err error
+	switch {
+	case strings.HasPrefix(name, "PORT"):
+		v, err = e.port(name)
+	case name == "BRANCH":
+		v, err = gitBranch(e.info.Cwd)
+	case name == "WORKTREE":
+		v, err = git(e.info.Cwd, "rev-parse", "--show-toplevel")
+	}
+	if err != nil {
+		return "", err
+	}
+	e.vars[name] = v
+	return v, nil
+}
+
+// port resolves PORT, PORT_2, ... to the process's allocated ports or, if it
+// has none, its declared ones.
+func (e *expander) port(name string) (string, error) {
+	ports := e.info.AllocatedPorts
+	if len(ports) == 0 {
+		ports = e.info.Ports
+	}
+	n := 1
+	if s, ok := strings.CutPrefix(name, "PORT_"); ok {
+		n, _ = strconv.Atoi(s)
+	}
+	if n < 1 || n > len(ports) {
+		return "", fmt.Errorf("the process has %d ports; declare them with ports or allocate_ports", len(ports))
+	}
+	return strconv.Itoa(ports[n-1]), nil
+}
+
+// gitBranch returns the branch checked out in dir, or the abbreviated commit
+// on a detached HEAD.
+func gitBranch(dir string) (string, error) {
+	branch, err := git(dir, "symbolic-ref", "--short", "-q", "HEAD")
+	if err == nil {
+		return branch, nil
+	}
+	return git(dir, "rev-parse", "--short", "HEAD")
+}
+
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s is not in a git repository", dirOrCwd(dir))
+		}
+		return "", fmt.Errorf("running git: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func dirOrCwd(dir string) string {
+	if dir == "" {
+		return "the server's working directory"
+	}
+	return dir
+}
@@ -0,0 +1,117 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	thoughtKeyPrefix = "thought:"
+	// maxThoughtText bounds a journal entry; longer notes belong in the repo.
+	maxThoughtText = 16 * 1024
+)
+
+// Thought is an entry in a project's journal: a decision, TODO or quirk of
+// the environment worth remembering across conversations.
+type Thought struct {
+	ID        string            `json:"id"`
+	Project   string            `json:"project"`
+	Text      string            `json:"text"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// ThoughtFilter controls which thoughts Thoughts returns.
+type ThoughtFilter struct {
+	// Project limits the thoughts to one project's journal.
+	Project string
+	// Tags filters to thoughts matching all specified tag key-value pairs.
+	Tags map[string]string
+	// Since and Until, if set, bound CreatedAt (Until is exclusive).
+	Since, Until time.Time
+	// Limit caps the number of thoughts returned; 0 means no limit.
+	Limit int
+}
+
+// AppendThought adds text to the journal of project.
+func (m *Manager) AppendThought(project, text string, tags map[string]string) (*Thought, error) {
+	if !validName.MatchString(project) {
+		return nil, fmt.Errorf("invalid project name %q: use letters, digits, '.', '_' and '-'", project)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, errors.New("text must not be empty")
+	}
+	if len(text) > maxThoughtText {
+		return nil, fmt.Errorf("text is %d bytes; the limit is %d", len(text), maxThoughtText)
+	}
+	id, err := generateID()
+	if err != nil {
+		return nil, fmt.Errorf("generating thought ID: %w", err)
+	}
+
+	t := Thought{ID: id, Project: project, Text: text, Tags: tags, CreatedAt: time.Now().UTC()}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Set(thoughtKeyPrefix+project+"/"+id, data); err != nil {
+		return nil, fmt.Errorf("persisting thought: %w", err)
+	}
+	return &t, nil
+}
+
+// Thoughts returns the thoughts matching filter, newest first.
+func (m *Manager) Thoughts(filter ThoughtFilter) ([]Thought, error) {
+	prefix := thoughtKeyPrefix
+	if filter.Project != "" {
+		prefix += filter.Project + "/"
+	}
+	keys, err := m.store.List(prefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing thoughts: %w", err)
+	}
+
+	thoughts := make([]Thought, 0, len(keys))
+	for _, key := range keys {
+		data, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var t Thought
+		if err := json.Unmarshal(data, &t); err != nil {
+			continue
+		}
+		if !matchTags(t.Tags, filter.Tags) ||
+			(!filter.Since.IsZero() && t.CreatedAt.Before(filter.Since)) ||
+			(!filter.Until.IsZero() && !t.CreatedAt.Before(filter.Until)) {
+			continue
+		}
+		thoughts = append(thoughts, t)
+	}
+	sort.Slice(thoughts, func(i, j int) bool { return thoughts[i].CreatedAt.After(thoughts[j].CreatedAt) })
+	if filter.Limit > 0 && len(thoughts) > filter.Limit {
+		thoughts = thoughts[:filter.Limit]
+	}
+	return thoughts, nil
+}
+
+// ParseThoughtTime parses a Since or Until bound given as RFC 3339 or as a
+// local date (2006-01-02). A date means the start of that day, or with
+// endOfDay the start of the next, so that Until includes the whole day.
+func ParseThoughtTime(s string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or YYYY-MM-DD", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+package process
+
+import "time"
+
+const (
+	// ExitReasonMaxRuntime records that the Manager stopped a process
+	// because it ran longer than StartOptions.MaxRuntimeSecs.
+	ExitReasonMaxRuntime = "max_runtime"
+	// ExitReasonIdleOutput records that the Manager stopped a process
+	// because it wrote no output for StartOptions.IdleTimeoutSecs.
+	ExitReasonIdleOutput = "idle_output"
+)
+
+// maxIdleCheckInterval bounds how often the log is checked for output.
+const maxIdleCheckInterval = 5 * time.Second
+
+// watchRuntime kills rp's process once maxRuntime has passed, unless it
+// exits for good first. The deadline counts from Start, across restarts.
+func (m *Manager) watchRuntime(id string, rp *runningProc, maxRuntime time.Duration) {
+	timer := time.NewTimer(maxRuntime)
+	defer timer.Stop()
+	select {
+	case <-rp.done:
+	case <-timer.C:
+		m.stopFor(id, rp, ExitReasonMaxRuntime)
+	}
+}
+
+// watchIdle kills info's process once its log has gone unwritten for idle.
+// Time spent paused doesn't count, since a stopped process can't write.
+func (m *Manager) watchIdle(info ProcessInfo, rp *runningProc, idle time.Duration) {
+	ticker := time.NewTicker(min(idle/2, maxIdleCheckInterval))
+	defer ticker.Stop()
+
+	since := time.Now()
+	for {
+		select {
+		case <-rp.done:
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		if current, err := m.lookup(info.ID); err == nil && current.Paused {
+			since = now
+			continue
+		}
+		if out := m.lastOutput(info); out.After(since) {
+			since = out
+		}
+		if now.Sub(since) >= idle {
+			m.stopFor(info.ID, rp, ExitReasonIdleOutput)
+			return
+		}
+	}
+}
+
+// stopFor kills a process on the Manager's own initiative, recording reason
+// as its exit reason.
+func (m *Manager) stopFor(id string, rp *runningProc, reason string) {
+	m.mu.Lock()
+	rp.exitReason = reason
+	m.mu.Unlock()
+	m.Kill(id)
+}
+
+// timedOut reports whether reason is one of the timeouts that give a
+// process the timed_out status.
+func timedOut(reason string) bool {
+	return reason == ExitReasonMaxRuntime || reason == ExitReasonIdleOutput
+}
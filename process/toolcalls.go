@@ -0,0 +1,161 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	toolCallKeyPrefix = "call:"
+	// toolCallIDTime formats the start of a ToolCall ID, so that IDs, and
+	// the store keys made from them, sort by time.
+	toolCallIDTime = "20060102T150405.000000000Z"
+	// DefaultToolCallRetention is how long tool calls are kept unless
+	// configured otherwise.
+	DefaultToolCallRetention = 30 * 24 * time.Hour
+)
+
+// Outcomes of a ToolCall.
+const (
+	// CallOK is a call that returned a result.
+	CallOK = "ok"
+	// CallToolError is a call whose result was an error reported to the
+	// agent, e.g. a failed validation or a port conflict.
+	CallToolError = "tool_error"
+	// CallFailed is a call that failed as a request, e.g. with arguments
+	// that don't match the tool's schema.
+	CallFailed = "error"
+)
+
+// ToolCall records one MCP tool invocation.
+type ToolCall struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Tool string    `json:"tool"`
+	// ArgsHash is the SHA-256 of the arguments' canonical JSON, so that
+	// identical calls can be recognized without storing secrets passed in
+	// them.
+	ArgsHash string `json:"args_hash"`
+	// Target is the process_id or name argument, if the tool takes one.
+	Target     string `json:"target,omitempty"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	// Client is the name the MCP client gave when connecting.
+	Client string `json:"client,omitempty"`
+}
+
+// ToolCallFilter controls which calls ToolCalls returns.
+type ToolCallFilter struct {
+	// Tool and Outcome, if set, must match exactly.
+	Tool    string
+	Outcome string
+	// Since and Until, if set, bound Time (Until is exclusive).
+	Since, Until time.Time
+	// Limit caps the number of calls returned; 0 means no limit.
+	Limit int
+}
+
+// maxToolCallError bounds ToolCall.Error.
+const maxToolCallError = 1024
+
+// RecordToolCall stores c, assigning its ID.
+func (m *Manager) RecordToolCall(c ToolCall) error {
+	suffix, err := generateID()
+	if err != nil {
+		return fmt.Errorf("generating call ID: %w", err)
+	}
+	c.Time = c.Time.UTC()
+	c.ID = c.Time.Format(toolCallIDTime) + "-" + suffix
+	if len(c.Error) > maxToolCallError {
+		c.Error = c.Error[:maxToolCallError] + "…"
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := m.store.Set(toolCallKeyPrefix+c.ID, data); err != nil {
+		return fmt.Errorf("persisting tool call: %w", err)
+	}
+	return nil
+}
+
+// ToolCalls returns the recorded calls matching f, newest first.
+func (m *Manager) ToolCalls(f ToolCallFilter) ([]ToolCall, error) {
+	keys, err := m.store.List(toolCallKeyPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing tool calls: %w", err)
+	}
+	slices.Sort(keys)
+	slices.Reverse(keys)
+
+	var calls []ToolCall
+	for _, key := range keys {
+		// Skip on the time in the key before reading the record.
+		id := strings.TrimPrefix(key, toolCallKeyPrefix)
+		if !f.Until.IsZero() && id >= f.Until.UTC().Format(toolCallIDTime) {
+			continue
+		}
+		if !f.Since.IsZero() && id < f.Since.UTC().Format(toolCallIDTime) {
+			break
+		}
+		data, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var c ToolCall
+		if json.Unmarshal(data, &c) != nil {
+			continue
+		}
+		if (f.Tool != "" && c.Tool != f.Tool) || (f.Outcome != "" && c.Outcome != f.Outcome) {
+			continue
+		}
+		calls = append(calls, c)
+		if f.Limit > 0 && len(calls) >= f.Limit {
+			break
+		}
+	}
+	return calls, nil
+}
+
+// RunToolCallRetention deletes tool calls older than retention now and then
+// every hour until ctx is done.
+func (m *Manager) RunToolCallRetention(ctx context.Context, retention time.Duration) error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if _, err := m.pruneToolCalls(time.Now().Add(-retention)); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneToolCalls deletes the calls made before cutoff and reports how many
+// there were.
+func (m *Manager) pruneToolCalls(cutoff time.Time) (int, error) {
+	keys, err := m.store.List(toolCallKeyPrefix, 0)
+	if err != nil {
+		return 0, fmt.Errorf("listing tool calls: %w", err)
+	}
+	before := toolCallKeyPrefix + cutoff.UTC().Format(toolCallIDTime)
+	n := 0
+	for _, key := range keys {
+		if key >= before {
+			continue
+		}
+		if err := m.store.Delete(key); err != nil {
+			return n, fmt.Errorf("deleting tool call: %w", err)
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,37 @@
+package process
+
+import "syscall"
+
+// ProcessNode is a process belonging to a managed process's group.
+type ProcessNode struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	Command string `json:"command"`
+}
+
+// descendants returns the members of the process group led by pid, excluding
+// the leader itself. Errors are treated as an empty group.
+func descendants(pid int) []ProcessNode {
+	members, err := groupMembers(pid)
+	if err != nil {
+		return nil
+	}
+	nodes := make([]ProcessNode, 0, len(members))
+	for _, n := range members {
+		if n.PID != pid {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+// countTerminated returns how many of nodes no longer exist.
+func countTerminated(nodes []ProcessNode) int {
+	n := 0
+	for _, node := range nodes {
+		if syscall.Kill(node.PID, 0) != nil {
+			n++
+		}
+	}
+	return n
+}
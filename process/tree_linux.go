@@ -0,0 +1,50 @@
+package process
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// groupMembers lists the processes in group pgid by scanning /proc.
+func groupMembers(pgid int) ([]ProcessNode, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var nodes []ProcessNode
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// Format: pid (comm) state ppid pgrp ... — comm may contain spaces
+		// and parentheses, so split after the last ')'.
+		end := bytes.LastIndexByte(stat, ')')
+		start := bytes.IndexByte(stat, '(')
+		if end < 0 || start < 0 || start > end {
+			continue
+		}
+		fields := strings.Fields(string(stat[end+1:]))
+		if len(fields) < 3 {
+			continue
+		}
+		if pgrp, _ := strconv.Atoi(fields[2]); pgrp != pgid {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+
+		command := string(stat[start+1 : end])
+		if cmdline, err := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline")); err == nil && len(cmdline) > 0 {
+			command = strings.TrimSpace(string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '})))
+		}
+		nodes = append(nodes, ProcessNode{PID: pid, PPID: ppid, Command: command})
+	}
+	return nodes, nil
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// groupMembers lists the processes in group pgid using ps.
+func groupMembers(pgid int) ([]ProcessNode, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=,args=").Output()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []ProcessNode
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		if pg, _ := strconv.Atoi(fields[2]); pg != pgid {
+			continue
+		}
+		pid, _ := strconv.Atoi(fields[0])
+		ppid, _ := strconv.Atoi(fields[1])
+		nodes = append(nodes, ProcessNode{PID: pid, PPID: ppid, Command: strings.Join(fields[3:], " ")})
+	}
+	return nodes, nil
+}
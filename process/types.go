@@ -10,11 +10,38 @@ const (
 	StatusExited  ProcessStatus = "exited"
 	StatusFailed  ProcessStatus = "failed"
 	StatusUnknown ProcessStatus = "unknown"
+
+	// StatusPaused marks a running process stopped with SIGSTOP.
+	StatusPaused ProcessStatus = "paused"
+
+	// StatusCrashLooping marks a process whose restart policy was suspended
+	// after it exited too many times in a short window.
+	StatusCrashLooping ProcessStatus = "crash_looping"
+
+	// StatusTimedOut marks a process the Manager stopped because it hit a
+	// timeout; ExitReason says which.
+	StatusTimedOut ProcessStatus = "timed_out"
+
+	// StatusScheduled and StatusRestartScheduled mark the pending runs of
+	// schedules that List includes with IncludePending; they aren't
+	// processes yet.
+	StatusScheduled        ProcessStatus = "scheduled"
+	StatusRestartScheduled ProcessStatus = "restart_scheduled"
+)
+
+// RestartPolicy controls whether the Manager restarts a process after it exits.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "no"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
 )
 
 // ProcessInfo holds the persisted metadata for a managed process.
 type ProcessInfo struct {
 	ID        string            `json:"id"`
+	Name      string            `json:"name,omitempty"`
 	Command   string            `json:"command"`
 	Args      []string          `json:"args"`
 	Cwd       string            `json:"cwd,omitempty"`
@@ -26,12 +53,252 @@ type ProcessInfo struct {
 	ExitCode  *int              `json:"exit_code,omitempty"`
 	ExitedAt  *time.Time        `json:"exited_at,omitempty"`
 	LogPath   string            `json:"log_path"`
+	// StartedClock and ExitedClock are readings of a clock the system
+	// clock being set doesn't move, taken with StartedAt and ExitedAt, for
+	// durations that survive clock changes and suspend.
+	StartedClock *Clock `json:"started_clock,omitempty"`
+	ExitedClock  *Clock `json:"exited_clock,omitempty"`
+	// LogEvicted is set when the storage quota deleted the log file.
+	LogEvicted bool `json:"log_evicted,omitempty"`
+	// PIDStart is the OS's start time of PID, used to tell the process
+	// apart from a later one that reuses the PID. Its unit is platform
+	// specific; it is only compared for equality.
+	PIDStart int64 `json:"pid_start,omitempty"`
+	// Killed is set when the exit followed Kill or Shutdown.
+	Killed bool `json:"killed,omitempty"`
+	// ExitReason is set when the Manager stopped the process itself, e.g.
+	// ExitReasonMaxRuntime.
+	ExitReason string `json:"exit_reason,omitempty"`
+	// MaxRuntimeSecs, if set, is how long the process may run before it is
+	// stopped with the timed_out status.
+	MaxRuntimeSecs int `json:"max_runtime_secs,omitempty"`
+	// IdleTimeoutSecs, if set, is how long the process may go without
+	// writing output before it is stopped with the timed_out status.
+	IdleTimeoutSecs int `json:"idle_timeout_secs,omitempty"`
+	// StopSignal is the signal Kill sends first, e.g. "SIGINT"; empty means
+	// SIGTERM. StopGraceSecs is how long Kill then waits before SIGKILL; 0
+	// means DefaultStopGrace.
+	StopSignal    string `json:"stop_signal,omitempty"`
+	StopGraceSecs int    `json:"stop_grace_secs,omitempty"`
+	// PreStop is a shell command Kill runs, and waits up to
+	// PreStopTimeoutSecs (0 means 30) for, before the stop signal.
+	PreStop            string `json:"pre_stop,omitempty"`
+	PreStopTimeoutSecs int    `json:"pre_stop_timeout_secs,omitempty"`
+	// OnExit is run after each exit.
+	OnExit *ExitHook `json:"on_exit,omitempty"`
+	// Watch restarts the process when matching files change.
+	Watch *FileWatch `json:"watch,omitempty"`
+	// ScheduleID is set for runs started by a Schedule.
+	ScheduleID string `json:"schedule_id,omitempty"`
+	// ReportToken is the secret the process authenticates Report calls
+	// with. Views leave it out.
+	ReportToken string `json:"report_token,omitempty"`
+	// ReadyAt and ReportedPorts are what the process last told Report,
+	// cleared when it exits.
+	ReadyAt       *time.Time `json:"ready_at,omitempty"`
+	ReportedPorts []int      `json:"reported_ports,omitempty"`
+	// AutoTags are the keys of the tags Start filled in from git rather
+	// than being given them; Restart detects them afresh.
+	AutoTags []string `json:"auto_tags,omitempty"`
+	// PreviousID is the process this one replaced when it was started by
+	// Restart.
+	PreviousID string `json:"previous_id,omitempty"`
+	// Warning is the latest exit_warning alert of the current run: log
+	// output suggesting the process is about to die. Cleared when it exits.
+	Warning *Alert `json:"warning,omitempty"`
+	// LineageID is the ID of the first process in the chain of Restarts
+	// that led to this one: the same logical service started again.
+	LineageID string `json:"lineage_id,omitempty"`
+	// PendingEnv is an UpdateEnv change waiting for the process to be
+	// started again.
+	PendingEnv *EnvChange `json:"pending_env,omitempty"`
+	// Nice and IOClass are the process group's CPU niceness and I/O
+	// scheduling class, applied at every spawn and by SetPriority.
+	Nice    int    `json:"nice,omitempty"`
+	IOClass string `json:"io_class,omitempty"`
+	// CleanEnv starts the process with only cleanEnvVars from the server's
+	// environment instead of all of it.
+	CleanEnv bool `json:"clean_env,omitempty"`
+	// Database is set on role=db processes that declared their connection
+	// string and schema commands.
+	Database *Database `json:"database,omitempty"`
+	// DatabaseID is set on migration and reset tasks to the database
+	// process they ran against; their DATABASE_URL is redacted in views.
+	DatabaseID string `json:"database_id,omitempty"`
+	// Exec runs Command directly with Args instead of through the user's
+	// shell.
+	Exec bool `json:"exec,omitempty"`
+	// EnvFiles are the absolute paths of the dotenv files loaded into the
+	// environment, in order, re-read at every spawn.
+	EnvFiles []string `json:"env_files,omitempty"`
+	// DependsOn are the names or IDs of the processes Start waited for.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// Stack is the name of the stack the process was started for.
+	Stack string `json:"stack,omitempty"`
+	// Compose is set on processes StartCompose started to follow a docker
+	// compose service.
+	Compose *ComposeService `json:"compose,omitempty"`
+	// InContainer is set on processes run in a container.
+	InContainer *ContainerSpec `json:"in_container,omitempty"`
+	// Alerts are the most recent high-memory, OOM-kill and log spike
+	// alerts, oldest first.
+	Alerts []Alert `json:"alerts,omitempty"`
+
+	Restart RestartPolicy `json:"restart,omitempty"`
+	// Restarts counts how many times the restart policy relaunched the process.
+	Restarts int `json:"restarts,omitempty"`
+	// RecentExitCodes holds the exit codes of the most recent exits, oldest first.
+	RecentExitCodes []int `json:"recent_exit_codes,omitempty"`
+	// CrashLooping is set once the restart policy gives up.
+	CrashLooping bool `json:"crash_looping,omitempty"`
+
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+
+	// Paused is set while the process group is stopped by Pause.
+	Paused bool `json:"paused,omitempty"`
+	// PTY runs the process in a pseudo-terminal instead of with pipes.
+	PTY bool `json:"pty,omitempty"`
+	// AllocatedPorts were picked by Start and passed as PORT, PORT_2, ...
+	// They are also included in Ports.
+	AllocatedPorts []int `json:"allocated_ports,omitempty"`
+}
+
+// StartOptions describes a process to launch with Manager.Start. It is also
+// stored as the template of a Schedule.
+type StartOptions struct {
+	// Name is an optional human-readable name, unique among running
+	// processes, that can be used in place of the ID.
+	Name    string            `json:"name,omitempty"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args,omitempty"`
+	Cwd     string            `json:"cwd,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Ports   []int             `json:"ports,omitempty"`
+
+	// Restart is the restart policy. An empty value means RestartNever.
+	Restart RestartPolicy `json:"restart,omitempty"`
+
+	// HealthCheck is run periodically while the process is running.
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+	// PTY runs the process in a pseudo-terminal so it sees a TTY.
+	PTY bool `json:"pty,omitempty"`
+	// Force starts the process even if an identical one is already running.
+	Force bool `json:"force,omitempty"`
+	// MaxRuntimeSecs stops the process after this many seconds.
+	MaxRuntimeSecs int `json:"max_runtime_secs,omitempty"`
+	// IdleTimeoutSecs stops the process after this many seconds without
+	// output.
+	IdleTimeoutSecs int `json:"idle_timeout_secs,omitempty"`
+	// StopSignal is the signal to stop the process with instead of
+	// SIGTERM, with or without the SIG prefix: SIGINT, SIGQUIT, SIGHUP,
+	// SIGUSR1 or SIGUSR2.
+	StopSignal string `json:"stop_signal,omitempty"`
+	// StopGraceSecs is how long to wait after the stop signal before
+	// SIGKILL, instead of 5 seconds.
+	StopGraceSecs int `json:"stop_grace_secs,omitempty"`
+	// PreStop is a shell command run in the process's cwd and environment
+	// before it is sent the stop signal, e.g. to close connections or flush
+	// state. Stopping waits for it, for up to PreStopTimeoutSecs (default
+	// 30).
+	PreStop            string `json:"pre_stop,omitempty"`
+	PreStopTimeoutSecs int    `json:"pre_stop_timeout_secs,omitempty"`
+	// OnExit is a command run after each exit of the process, including
+	// ones followed by a restart.
+	OnExit *ExitHook `json:"on_exit,omitempty"`
+	// Watch restarts the process in place, keeping its ID, when files under
+	// its cwd matching the watch's patterns change, whatever its restart
+	// policy.
+	Watch *FileWatch `json:"watch,omitempty"`
+	// AllocatePorts is how many free ports to pick from the Manager's port
+	// range and pass to the process as PORT, PORT_2, ...
+	AllocatePorts int `json:"allocate_ports,omitempty"`
+	// Nice is the niceness (-20 to 19) to run the process group at.
+	Nice int `json:"nice,omitempty"`
+	// IOClass is the I/O scheduling class, IOClassBestEffort or IOClassIdle
+	// (Linux only).
+	IOClass string `json:"io_class,omitempty"`
+	// CleanEnv passes the process only Env, allocated ports and a few basics
+	// (PATH, HOME, ...) instead of the server's whole environment.
+	CleanEnv bool `json:"clean_env,omitempty"`
+	// CreateCwd creates Cwd, with any missing parents, if it doesn't exist.
+	CreateCwd bool `json:"create_cwd,omitempty"`
+	// Database declares the connection string and migrate/reset commands of
+	// a process tagged role=db, for RunMigrations and ResetDatabase.
+	Database *Database `json:"database,omitempty"`
+	// Exec runs Command as the program itself, with Args as its arguments,
+	// instead of as a line for the user's shell. Nothing is quoted or
+	// interpreted: no pipes, globs or $VARS (placeholders still work).
+	Exec bool `json:"exec,omitempty"`
+	// EnvFiles are dotenv files, relative to Cwd, whose variables are added
+	// to the environment; later files win, and Env wins over all of them.
+	EnvFiles []string `json:"env_files,omitempty"`
+	// DependsOn are names or IDs of processes that must be running, and
+	// healthy if they have a health check, before this one is launched.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// DependsTimeoutSecs bounds the wait for DependsOn; 0 means 60s.
+	DependsTimeoutSecs int `json:"depends_timeout_secs,omitempty"`
+	// InContainer runs Command and Args in a container; an empty Command
+	// then runs the image's own. Exec runs them without sh -c.
+	InContainer *ContainerSpec `json:"in_container,omitempty"`
+
+	// scheduleID links a run started by the scheduler to its Schedule.
+	scheduleID string
+	// previousID is the process Restart replaces with this one, and
+	// lineageID that process's lineage.
+	previousID string
+	lineageID  string
+	// databaseID is the database process a migration or reset task is for.
+	databaseID string
+	// stack is the stack StartStack started the process for.
+	stack string
+	// compose is the compose service StartCompose started the process for.
+	compose *ComposeService
 }
 
-// ProcessView extends ProcessInfo with a computed Status field.
+// ProcessView extends ProcessInfo with computed Status and Health fields.
 type ProcessView struct {
 	ProcessInfo
 	Status ProcessStatus `json:"status"`
+	// Health is only set for running processes with a health check.
+	Health HealthStatus `json:"health,omitempty"`
+	// DetectedPorts are the TCP ports the process group is listening on,
+	// found by periodically inspecting its sockets. Only set for running
+	// and paused processes started by this server.
+	DetectedPorts []int `json:"detected_ports,omitempty"`
+	// RSSBytes and CPUPercent are the process group's memory and CPU use,
+	// sampled every 5s, with the same availability as DetectedPorts. CPU%
+	// is relative to one core.
+	RSSBytes   int64   `json:"rss_bytes,omitempty"`
+	CPUPercent float64 `json:"cpu_percent,omitempty"`
+	// Container is the last read state of the container of a compose
+	// process or a process run in a container, checked every 5s, with the
+	// same availability as DetectedPorts.
+	Container *ContainerState `json:"container,omitempty"`
+	// UptimeSecs is how long a running or paused process has been running,
+	// or how long an exited one ran. ExitedSecsAgo is how long ago an
+	// exited process exited. Both come from StartedClock and ExitedClock
+	// when they were read in the current boot, else from the timestamps.
+	UptimeSecs    int64 `json:"uptime_secs,omitempty"`
+	ExitedSecsAgo int64 `json:"exited_secs_ago,omitempty"`
+
+	// Descendants lists the other members of the process group, when
+	// requested with ListFilter.IncludeTree.
+	Descendants []ProcessNode `json:"descendants,omitempty"`
+	// TerminatedDescendants is set by Kill to the number of group members,
+	// other than the leader, that were terminated.
+	TerminatedDescendants int `json:"terminated_descendants,omitempty"`
+	// Dependents is set by Kill to the names, or IDs, of running processes
+	// that declared a dependency on the killed one.
+	Dependents []string `json:"dependents,omitempty"`
+	// RunAt is when a scheduled or restart_scheduled entry will run.
+	RunAt *time.Time `json:"run_at,omitempty"`
+	// Duplicate is set by Start when it returned an already running process
+	// with the same command, args, cwd and tags instead of starting one.
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Lineage summarizes the processes this one replaced, when listed with
+	// ListFilter.CollapseLineage.
+	Lineage *Lineage `json:"lineage,omitempty"`
 }
 
 // ListFilter controls which processes are returned by List.
@@ -44,4 +311,15 @@ type ListFilter struct {
 	// Tags filters to processes matching all specified tag key-value pairs.
 	// A nil or empty map means no tag filtering.
 	Tags map[string]string
+
+	// IncludeTree populates Descendants for running processes.
+	IncludeTree bool
+
+	// IncludePending adds the next run of each schedule, with status
+	// scheduled or restart_scheduled, after the processes.
+	IncludePending bool
+
+	// CollapseLineage lists only the latest process of each lineage, with
+	// its Lineage set.
+	CollapseLineage bool
 }
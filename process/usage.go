@@ -0,0 +1,70 @@
+package process
+
+import "time"
+
+// usageInterval is how often the Manager samples the CPU and memory use of
+// its processes.
+const usageInterval = 5 * time.Second
+
+// groupSample is the summed resource use of a process group at one moment.
+type groupSample struct {
+	// rss is the resident set size in bytes.
+	rss int64
+	// cpu is the CPU time used so far by the members still alive.
+	cpu time.Duration
+}
+
+// watchUsage records the memory and CPU use of rp's process group until it
+// exits for good, raising high_memory alerts for the process id. CPU% is the
+// CPU time used between two samples over the wall time between them, so it
+// can exceed 100 on multi-core machines.
+func (m *Manager) watchUsage(id string, rp *runningProc) {
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+
+	var prev groupSample
+	var prevAt time.Time
+	for {
+		select {
+		case <-rp.done:
+			return
+		case <-timer.C:
+		}
+
+		m.mu.Lock()
+		pid := rp.cmd.Process.Pid
+		m.mu.Unlock()
+
+		s, err := sampleGroup(pid)
+		now := time.Now()
+		if err == nil {
+			m.mu.Lock()
+			rp.rssBytes = s.rss
+			// A member exiting takes its CPU time with it; skip that interval
+			// rather than report a negative rate.
+			if !prevAt.IsZero() && s.cpu >= prev.cpu {
+				rp.cpuPercent = roundPercent(100 * float64(s.cpu-prev.cpu) / float64(now.Sub(prevAt)))
+			}
+			m.mu.Unlock()
+			prev, prevAt = s, now
+			m.checkMemory(id, rp, s.rss)
+		}
+		timer.Reset(usageInterval)
+	}
+}
+
+// roundPercent rounds p to one decimal place.
+func roundPercent(p float64) float64 {
+	return float64(int64(p*10+0.5)) / 10
+}
+
+// usage returns the last sampled memory and CPU use of a process, or zeros if
+// it isn't running under this Manager.
+func (m *Manager) usage(id string) (rss int64, cpuPercent float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rp, ok := m.running[id]; ok {
+		return rp.rssBytes, rp.cpuPercent
+	}
+	return 0, 0
+}
@@ -0,0 +1,53 @@
+package process
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc/PID/stat. It is
+// 100 on every Linux architecture Go supports.
+const clockTicks = 100
+
+// sampleGroup sums the RSS and CPU time of the members of group pgid from
+// /proc/PID/stat.
+func sampleGroup(pgid int) (groupSample, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return groupSample{}, err
+	}
+	pageSize := int64(os.Getpagesize())
+	var s groupSample
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// Fields after the last ')' start at field 3 (state): pgrp is
+		// field 5, utime and stime 14 and 15, rss (in pages) 24.
+		end := bytes.LastIndexByte(stat, ')')
+		if end < 0 {
+			continue
+		}
+		fields := strings.Fields(string(stat[end+1:]))
+		if len(fields) < 22 {
+			continue
+		}
+		if pgrp, _ := strconv.Atoi(fields[2]); pgrp != pgid {
+			continue
+		}
+		utime, _ := strconv.ParseInt(fields[11], 10, 64)
+		stime, _ := strconv.ParseInt(fields[12], 10, 64)
+		rss, _ := strconv.ParseInt(fields[21], 10, 64)
+		s.cpu += time.Duration(utime+stime) * time.Second / clockTicks
+		s.rss += rss * pageSize
+	}
+	return s, nil
+}
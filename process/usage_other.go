@@ -0,0 +1,52 @@
+//go:build !linux
+
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sampleGroup sums the RSS and CPU time of the members of group pgid using
+// ps.
+func sampleGroup(pgid int) (groupSample, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pgid=,rss=,time=").Output()
+	if err != nil {
+		return groupSample{}, err
+	}
+	var s groupSample
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		if pg, _ := strconv.Atoi(fields[0]); pg != pgid {
+			continue
+		}
+		rss, _ := strconv.ParseInt(fields[1], 10, 64)
+		s.rss += rss * 1024 // ps reports KiB
+		s.cpu += parseCPUTime(fields[2])
+	}
+	return s, nil
+}
+
+// parseCPUTime parses ps's cumulative CPU time, "[[DD-]HH:]MM:SS[.ss]".
+func parseCPUTime(v string) time.Duration {
+	var d time.Duration
+	if days, rest, ok := strings.Cut(v, "-"); ok {
+		n, _ := strconv.Atoi(days)
+		d += time.Duration(n) * 24 * time.Hour
+		v = rest
+	}
+	parts := strings.Split(v, ":")
+	// Hours and minutes, each unit 60 times the next.
+	var hm time.Duration
+	for _, p := range parts[:len(parts)-1] {
+		n, _ := strconv.Atoi(p)
+		hm = hm*60 + time.Duration(n)*time.Minute
+	}
+	secs, _ := strconv.ParseFloat(parts[len(parts)-1], 64)
+	return d + hm + time.Duration(secs*float64(time.Second))
+}
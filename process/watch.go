@@ -0,0 +1,257 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultWatchDebounce is how long files must stay unchanged after a
+	// change before a watched process is restarted.
+	defaultWatchDebounce = 500 * time.Millisecond
+	maxWatchDebounceMs   = 60000
+	// watchInterval is how often watched files are scanned for changes.
+	watchInterval = time.Second
+)
+
+// watchSkipDirs are directories never scanned for changes, as nodemon does.
+var watchSkipDirs = []string{".git", "node_modules"}
+
+// FileWatch restarts a process when files under its cwd change.
+type FileWatch struct {
+	// Patterns are slash-separated globs relative to the cwd, such as
+	// "**/*.go" or "config/*.yaml"; "**" matches any number of directories.
+	// A pattern matching a directory covers everything under it.
+	Patterns []string `json:"patterns"`
+	// Ignore are globs, in the same form, for files not to watch.
+	Ignore []string `json:"ignore,omitempty"`
+	// DebounceMs is how long files must stay unchanged after a change
+	// before the restart, so a burst of saves restarts once. 0 means 500.
+	DebounceMs int `json:"debounce_ms,omitempty"`
+}
+
+func (w *FileWatch) validate() error {
+	if len(w.Patterns) == 0 {
+		return errors.New("watch patterns are required")
+	}
+	for _, p := range slices.Concat(w.Patterns, w.Ignore) {
+		if err := validateWatchPattern(p); err != nil {
+			return err
+		}
+	}
+	if w.DebounceMs < 0 || w.DebounceMs > maxWatchDebounceMs {
+		return fmt.Errorf("watch debounce_ms must be between 0 and %d", maxWatchDebounceMs)
+	}
+	return nil
+}
+
+// validateWatchPattern checks that p is a well-formed glob that stays
+// inside the cwd.
+func validateWatchPattern(p string) error {
+	if p == "" || path.IsAbs(p) {
+		return fmt.Errorf("invalid watch pattern %q: must be relative to cwd", p)
+	}
+	for _, seg := range strings.Split(p, "/") {
+		if seg == ".." {
+			return fmt.Errorf("invalid watch pattern %q: must not leave cwd", p)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid watch pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (w *FileWatch) debounce() time.Duration {
+	if w.DebounceMs == 0 {
+		return defaultWatchDebounce
+	}
+	return time.Duration(w.DebounceMs) * time.Millisecond
+}
+
+// fileStamp is what a change to a watched file is detected by.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchFiles restarts info's process in place once files matching its
+// FileWatch change and then settle, until it exits for good. Files are
+// polled rather than subscribed to, so that no watch descriptors are held
+// and changes made while the server was busy aren't missed.
+func (m *Manager) watchFiles(info ProcessInfo, rp *runningProc) {
+	w := info.Watch
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	files := w.scan(info.Cwd)
+	var changed string
+	var changedAt time.Time
+	for {
+		select {
+		case <-rp.done:
+			return
+		case <-ticker.C:
+		}
+		latest := w.scan(info.Cwd)
+		if name := firstChange(files, latest); name != "" {
+			changed, changedAt = name, time.Now()
+		}
+		files = latest
+		if changed == "" || time.Since(changedAt) < w.debounce() {
+			continue
+		}
+		if current, err := m.lookup(info.ID); err == nil && current.Paused {
+			// Restart once resumed.
+			continue
+		}
+		fmt.Fprintf(m.logWriter(info.ID), "thought-process: watch: %s changed, restarting\n", changed)
+		m.reload(info.ID, rp)
+		changed = ""
+	}
+}
+
+// firstChange returns the first of the files added, removed or modified
+// between before and after, or "" if there are none.
+func firstChange(before, after map[string]fileStamp) string {
+	for name, s := range after {
+		if prev, ok := before[name]; !ok || prev != s {
+			return name
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// scan returns the stamps of the files under cwd that w watches, keyed by
+// their slash-separated paths relative to cwd.
+func (w *FileWatch) scan(cwd string) map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	_ = filepath.WalkDir(cwd, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip what can't be read, e.g. a directory removed mid-walk.
+			return nil
+		}
+		rel, err := filepath.Rel(cwd, p)
+		if err != nil || rel == "." {
+			return nil
+		}
+		segs := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			if slices.Contains(watchSkipDirs, d.Name()) || matchesAny(w.Ignore, segs) || !w.couldMatchUnder(segs) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !coveredBy(w.Patterns, segs) || coveredBy(w.Ignore, segs) {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			files[strings.Join(segs, "/")] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+		}
+		return nil
+	})
+	return files
+}
+
+// couldMatchUnder reports whether any pattern could match the directory
+// dir or something under it, so the walk can skip unrelated directories.
+func (w *FileWatch) couldMatchUnder(dir []string) bool {
+	for _, p := range w.Patterns {
+		if matchSegments(strings.Split(p, "/"), dir, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// coveredBy reports whether a pattern matches the file at segs or one of
+// its parent directories.
+func coveredBy(patterns []string, segs []string) bool {
+	for i := 1; i <= len(segs); i++ {
+		if matchesAny(patterns, segs[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, segs []string) bool {
+	for _, p := range patterns {
+		if matchSegments(strings.Split(p, "/"), segs, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches a path against a pattern, both split on "/". With
+// prefix, it reports whether the path could be a directory that the
+// pattern matches or matches something under.
+func matchSegments(pattern, name []string, prefix bool) bool {
+	switch {
+	case len(pattern) == 0:
+		return len(name) == 0 || prefix
+	case pattern[0] == "**":
+		return matchSegments(pattern[1:], name, prefix) ||
+			(len(name) > 0 && matchSegments(pattern, name[1:], prefix))
+	case len(name) == 0:
+		return prefix
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:], prefix)
+}
+
+// reload stops the current incarnation of rp's process, running its
+// pre_stop hook first, and has the wait loop start it again under the same
+// ID whatever its restart policy.
+func (m *Manager) reload(id string, rp *runningProc) {
+	info, err := m.lookup(id)
+	if err != nil {
+		return
+	}
+	m.mu.Lock()
+	if rp.stopped || m.shutdown {
+		m.mu.Unlock()
+		return
+	}
+	rp.reloading = true
+	pid := rp.cmd.Process.Pid
+	m.mu.Unlock()
+
+	m.runPreStop(info)
+	if info.InContainer != nil {
+		stopContainer(info, info.stopGrace())
+	}
+	_ = signalGroup(pid, info.stopSignal())
+
+	// Wait for the wait loop to relaunch the process, or give up on it.
+	deadline := time.Now().Add(info.stopGrace())
+	for time.Now().Before(deadline) {
+		select {
+		case <-rp.done:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+		m.mu.Lock()
+		relaunched := rp.cmd.Process.Pid != pid
+		m.mu.Unlock()
+		if relaunched {
+			return
+		}
+	}
+	_ = signalGroup(pid, syscall.SIGKILL)
+}
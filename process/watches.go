@@ -0,0 +1,162 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"thought-process/store"
+)
+
+const watchKeyPrefix = "watch:"
+
+// LogWatch is a regular expression that raises a log_match event whenever a
+// line of output from a matching process matches it. A watch applies to one
+// process, or to every process whose tags include all of Tags.
+type LogWatch struct {
+	ID        string            `json:"id"`
+	Pattern   string            `json:"pattern"`
+	ProcessID string            `json:"process_id,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// LogMatch describes the output that triggered a log_match event.
+type LogMatch struct {
+	WatchID string `json:"watch_id"`
+	Pattern string `json:"pattern"`
+	// Line is the first matching line; Count is how many lines matched in
+	// the same check.
+	Line  string `json:"line"`
+	Count int    `json:"count"`
+}
+
+// applies reports whether w covers info.
+func (w LogWatch) applies(info ProcessInfo) bool {
+	if w.ProcessID != "" {
+		return w.ProcessID == info.ID
+	}
+	for k, v := range w.Tags {
+		if info.Tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// AddLogWatch registers pattern on the process processID (an ID or name) or,
+// if processID is empty, on every current and future process whose tags
+// include all of tags. One of the two is required.
+func (m *Manager) AddLogWatch(pattern, processID string, tags map[string]string) (*LogWatch, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if (processID == "") == (len(tags) == 0) {
+		return nil, errors.New("exactly one of process_id and tags is required")
+	}
+	if processID != "" {
+		info, err := m.lookup(processID)
+		if err != nil {
+			return nil, err
+		}
+		processID = info.ID
+	}
+
+	id, err := generateID()
+	if err != nil {
+		return nil, fmt.Errorf("generating watch ID: %w", err)
+	}
+	w := LogWatch{ID: id, Pattern: pattern, ProcessID: processID, Tags: tags, CreatedAt: time.Now().UTC()}
+	data, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.store.Set(watchKeyPrefix+id, data); err != nil {
+		return nil, fmt.Errorf("persisting watch: %w", err)
+	}
+	return &w, nil
+}
+
+// LogWatches returns the registered log watches, oldest first.
+func (m *Manager) LogWatches() ([]LogWatch, error) {
+	keys, err := m.store.List(watchKeyPrefix, 0)
+	if err != nil {
+		return nil, fmt.Errorf("listing watches: %w", err)
+	}
+	watches := make([]LogWatch, 0, len(keys))
+	for _, key := range keys {
+		data, err := m.store.Get(key)
+		if err != nil {
+			continue
+		}
+		var w LogWatch
+		if json.Unmarshal(data, &w) == nil {
+			watches = append(watches, w)
+		}
+	}
+	sort.Slice(watches, func(i, j int) bool { return watches[i].CreatedAt.Before(watches[j].CreatedAt) })
+	return watches, nil
+}
+
+// RemoveLogWatch deletes a log watch.
+func (m *Manager) RemoveLogWatch(id string) error {
+	if _, err := m.store.Get(watchKeyPrefix + id); errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("log watch %q not found", id)
+	} else if err != nil {
+		return err
+	}
+	return m.store.Delete(watchKeyPrefix + id)
+}
+
+// checkWatches publishes a log_match event for each watch that applies to
+// info and matches one of lines.
+func (m *Manager) checkWatches(info ProcessInfo, lines [][]byte, compiled map[string]*regexp.Regexp) {
+	watches, _ := m.LogWatches()
+	for _, w := range watches {
+		if !w.applies(info) {
+			continue
+		}
+		re, ok := compiled[w.Pattern]
+		if !ok {
+			var err error
+			if re, err = regexp.Compile(w.Pattern); err != nil {
+				continue
+			}
+			compiled[w.Pattern] = re
+		}
+		if match := matchLines(re, lines); match != nil {
+			match.WatchID = w.ID
+			match.Pattern = w.Pattern
+			m.publishMatch(info, match)
+		}
+	}
+}
+
+// matchLines returns the first of lines that re matches and the number of
+// matching lines, or nil if none match.
+func matchLines(re *regexp.Regexp, lines [][]byte) *LogMatch {
+	var match *LogMatch
+	for _, line := range lines {
+		if !re.Match(line) {
+			continue
+		}
+		if match == nil {
+			match = &LogMatch{Line: strings.TrimRight(string(line), "\r")}
+		}
+		match.Count++
+	}
+	return match
+}
+
+// publishMatch publishes a log_match event for info with the current view of
+// the process.
+func (m *Manager) publishMatch(info ProcessInfo, match *LogMatch) {
+	if current, err := m.lookup(info.ID); err == nil {
+		info = current
+	}
+	m.publishEvent(Event{Type: EventLogMatch, Time: time.Now().UTC(), Process: m.view(info), Match: match})
+}
@@ -0,0 +1,208 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	// webhookAttempts is how many times a delivery is tried before it is
+	// given up on.
+	webhookAttempts = 5
+	// webhookBackoff is the wait before the first retry; it doubles with
+	// each one after.
+	webhookBackoff = time.Second
+	// webhookTimeout bounds each attempt.
+	webhookTimeout = 10 * time.Second
+	// webhookInFlight bounds the deliveries under way to one URL; events
+	// beyond it are dropped, so a dead endpoint can't pile up goroutines.
+	webhookInFlight = 8
+)
+
+// DefaultWebhookEvents are the events a webhook without an event filter
+// receives.
+var DefaultWebhookEvents = []EventType{
+	EventStarted,
+	EventExited,
+	EventCrashed,
+	EventRestarted,
+	EventCrashLooping,
+	EventTimedOut,
+	EventHealthChanged,
+}
+
+// Webhook is a URL events are POSTed to as JSON.
+type Webhook struct {
+	URL string `json:"url"`
+	// Events filters the events delivered; empty means
+	// DefaultWebhookEvents.
+	Events []EventType `json:"events,omitempty"`
+	// Secret, if set, signs each delivery; see RunWebhooks. It may be a
+	// secret reference such as "keychain:NAME".
+	Secret string `json:"secret,omitempty"`
+}
+
+// Validate checks the URL and event filter.
+func (w *Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook url %q must be an http or https URL", w.URL)
+	}
+	for _, t := range w.Events {
+		if !slices.Contains(eventTypes, t) {
+			return fmt.Errorf("webhook %s: unknown event %q", w.URL, t)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the webhook receives events of type t.
+func (w *Webhook) wants(t EventType) bool {
+	if len(w.Events) == 0 {
+		return slices.Contains(DefaultWebhookEvents, t)
+	}
+	return slices.Contains(w.Events, t)
+}
+
+// SetWebhooks replaces the webhooks RunWebhooks delivers to. Deliveries
+// already under way finish with the old ones.
+func (m *Manager) SetWebhooks(hooks []Webhook) error {
+	for i := range hooks {
+		if err := hooks[i].Validate(); err != nil {
+			return err
+		}
+	}
+	m.webhooks.Store(&hooks)
+	return nil
+}
+
+// RunWebhooks POSTs each event to the webhooks set with SetWebhooks that
+// want it, until ctx is done. The body is the Event as JSON, without the
+// process's env, with headers
+// X-Thought-Process-Event (its type), X-Thought-Process-Delivery (an ID
+// shared by retries) and X-Thought-Process-Timestamp (Unix seconds). With a
+// secret, X-Thought-Process-Signature is "sha256=" and the hex HMAC-SHA256
+// of the timestamp, ".", and the body. Network errors, 429s and 5xx
+// responses are retried with backoff; deliveries are concurrent, so they
+// may arrive out of order. Failed deliveries, and events dropped because a
+// URL already has webhookInFlight deliveries under way, are passed to
+// onError.
+func (m *Manager) RunWebhooks(ctx context.Context, onError func(error)) error {
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+	client := &http.Client{Timeout: webhookTimeout}
+	inFlight := make(map[string]chan struct{})
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-events:
+			hooks := m.webhooks.Load()
+			if hooks == nil {
+				continue
+			}
+			for _, hook := range *hooks {
+				if !hook.wants(e.Type) {
+					continue
+				}
+				sem := inFlight[hook.URL]
+				if sem == nil {
+					sem = make(chan struct{}, webhookInFlight)
+					inFlight[hook.URL] = sem
+				}
+				select {
+				case sem <- struct{}{}:
+				default:
+					onError(fmt.Errorf("webhook %s: dropped %s event for %s: %d deliveries already under way", hook.URL, e.Type, e.Process.ID, webhookInFlight))
+					continue
+				}
+				go func() {
+					defer func() { <-sem }()
+					if err := m.deliverWebhook(ctx, client, hook, e); err != nil {
+						onError(fmt.Errorf("webhook %s: %s event for %s: %w", hook.URL, e.Type, e.Process.ID, err))
+					}
+				}()
+			}
+		}
+	}
+}
+
+// deliverWebhook POSTs e to hook, retrying until it is accepted, refused
+// for good or out of attempts.
+func (m *Manager) deliverWebhook(ctx context.Context, client *http.Client, hook Webhook, e Event) error {
+	// Env values are often credentials, and the URL is usually a third
+	// party's.
+	e.Process.Env = nil
+	e.Process.PendingEnv = nil
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	secret, err := m.secrets.Load().Resolve(hook.Secret)
+	if err != nil {
+		return fmt.Errorf("resolving secret: %w", err)
+	}
+	delivery, err := generateID()
+	if err != nil {
+		return err
+	}
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := postWebhook(ctx, client, hook.URL, secret, delivery, e.Type, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookAttempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook makes one delivery attempt, reporting whether a failure is
+// worth retrying.
+func postWebhook(ctx context.Context, client *http.Client, target, secret, delivery string, t EventType, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "thought-process")
+	req.Header.Set("X-Thought-Process-Event", string(t))
+	req.Header.Set("X-Thought-Process-Delivery", delivery)
+	req.Header.Set("X-Thought-Process-Timestamp", timestamp)
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set("X-Thought-Process-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("status %s", resp.Status)
+}
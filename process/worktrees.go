@@ -0,0 +1,118 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"sync"
+)
+
+// Reasons a process is left over from a worktree or branch.
+const (
+	// StaleWorktreeMissing is a worktree tag naming a directory that no
+	// longer exists.
+	StaleWorktreeMissing = "worktree_missing"
+	// StaleBranchDeleted is a branch tag naming a branch the repository no
+	// longer has.
+	StaleBranchDeleted = "branch_deleted"
+)
+
+// StaleProcess is a process whose worktree or branch is gone, as found by
+// CleanupWorktrees or StaleReport.
+type StaleProcess struct {
+	Process ProcessView `json:"process"`
+	// Reason is StaleWorktreeMissing or StaleBranchDeleted.
+	Reason string `json:"reason"`
+	// Killed is set once the process has been stopped.
+	Killed bool `json:"killed"`
+	// Deleted is set once StaleReport has deleted an exited process.
+	Deleted bool `json:"deleted,omitempty"`
+	// Error is why stopping it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// CleanupWorktrees kills every running or paused process whose worktree
+// tag names a directory that no longer exists, or whose branch tag names a
+// branch deleted from its repository, in parallel. With dryRun, they are
+// only reported.
+func (m *Manager) CleanupWorktrees(dryRun bool) ([]StaleProcess, error) {
+	views, err := m.liveViews()
+	if err != nil {
+		return nil, err
+	}
+
+	stale := []StaleProcess{}
+	for _, v := range views {
+		if reason := staleReason(v.ProcessInfo); reason != "" {
+			stale = append(stale, StaleProcess{Process: v, Reason: reason})
+		}
+	}
+	if dryRun {
+		return stale, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range stale {
+		wg.Go(func() {
+			view, err := m.Kill(stale[i].Process.ID)
+			if err != nil {
+				stale[i].Error = err.Error()
+				return
+			}
+			stale[i].Process = *view
+			stale[i].Killed = true
+		})
+	}
+	wg.Wait()
+	return stale, nil
+}
+
+// staleReason returns why info's worktree or branch is gone, or "" if they
+// still exist or can't be checked.
+func staleReason(info ProcessInfo) string {
+	dir := info.Cwd
+	if wt := info.Tags[TagWorktree]; wt != "" {
+		if _, err := os.Stat(wt); errors.Is(err, os.ErrNotExist) {
+			return StaleWorktreeMissing
+		}
+		dir = wt
+	}
+	branch := info.Tags[TagBranch]
+	if branch == "" || !inGitRepo(dir) {
+		return ""
+	}
+	if branchExists(dir, branch) {
+		return ""
+	}
+	return StaleBranchDeleted
+}
+
+// inGitRepo reports whether dir exists and is inside a git repository.
+func inGitRepo(dir string) bool {
+	if _, err := os.Stat(dir); err != nil {
+		return false
+	}
+	_, err := git(dir, "rev-parse", "--git-dir")
+	return err == nil
+}
+
+// branchExists reports whether the repository at dir has the branch. The
+// abbreviated commit a detached HEAD is tagged with counts as existing.
+func branchExists(dir, branch string) bool {
+	if _, err := git(dir, "show-ref", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+		return true
+	}
+	if !isHex(branch) {
+		return false
+	}
+	_, err := git(dir, "rev-parse", "--verify", "--quiet", branch+"^{commit}")
+	return err == nil
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
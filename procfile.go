@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"thought-process/process"
+)
+
+// tagFlags collects repeated -tag key=value flags.
+type tagFlags map[string]string
+
+func (t tagFlags) String() string { return fmt.Sprint(map[string]string(t)) }
+
+func (t tagFlags) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected key=value, got %q", s)
+	}
+	t[key] = value
+	return nil
+}
+
+// runProcfileCommand implements the procfile subcommand: it asks the
+// running server, over its control socket, to start the Procfile in a
+// directory, and prints one line per entry.
+func runProcfileCommand(baseDir string, args []string) {
+	procfileFlags := flag.NewFlagSet("procfile", flag.ExitOnError)
+	file := procfileFlags.String("f", process.DefaultProcfile, "the Procfile, relative to the directory")
+	tags := tagFlags{}
+	procfileFlags.Var(tags, "tag", "key=value tag added to every process (repeatable)")
+	procfileFlags.Usage = func() {
+		fmt.Fprintln(procfileFlags.Output(), "usage: thought-process procfile [-f FILE] [-tag key=value]... [DIR]")
+		procfileFlags.PrintDefaults()
+	}
+	procfileFlags.Parse(args)
+
+	dir := procfileFlags.Arg(0)
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		log.Fatalf("procfile: %v", err)
+	}
+
+	conn, err := net.Dial("unix", filepath.Join(baseDir, "control.sock"))
+	if err != nil {
+		log.Fatalf("procfile: no thought-process server is running (start one from your agent, or run: thought-process daemon start): %v", err)
+	}
+	defer conn.Close()
+
+	req, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "start_procfile",
+		"params":  map[string]any{"cwd": dir, "file": *file, "tags": tags},
+	})
+	if err != nil {
+		log.Fatalf("procfile: %v", err)
+	}
+	if _, err := conn.Write(append(req, '\n')); err != nil {
+		log.Fatalf("procfile: %v", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		log.Fatalf("procfile: reading response: %v", err)
+	}
+	var resp struct {
+		Result []process.StackResult `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		log.Fatalf("procfile: decoding response: %v", err)
+	}
+	if resp.Error != nil {
+		log.Fatalf("procfile: %s", resp.Error.Message)
+	}
+
+	failed := false
+	for _, r := range resp.Result {
+		switch {
+		case r.Error != "":
+			failed = true
+			fmt.Printf("%-12s error: %s\n", r.Name, r.Error)
+		case r.Process.Duplicate:
+			fmt.Printf("%-12s %s already running%s\n", r.Name, r.Process.ID, portList(r.Process.Ports))
+		default:
+			fmt.Printf("%-12s %s started%s\n", r.Name, r.Process.ID, portList(r.Process.Ports))
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// portList formats ports as " on ports 3000, 3001", or "" if there are none.
+func portList(ports []int) string {
+	if len(ports) == 0 {
+		return ""
+	}
+	s := make([]string, len(ports))
+	for i, p := range ports {
+		s[i] = fmt.Sprint(p)
+	}
+	if len(ports) == 1 {
+		return " on port " + s[0]
+	}
+	return " on ports " + strings.Join(s, ", ")
+}
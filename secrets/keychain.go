@@ -0,0 +1,13 @@
+package secrets
+
+import "thought-process/keychain"
+
+// Keychain resolves "keychain:NAME" from the OS keychain, using NAME as the
+// account under Service.
+type Keychain struct {
+	Service string
+}
+
+func (k Keychain) Lookup(name string) (string, error) {
+	return keychain.Get(k.Service, name)
+}
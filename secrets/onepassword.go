@@ -0,0 +1,45 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// opTimeout bounds op read, which may wait on an unlock prompt.
+const opTimeout = 30 * time.Second
+
+// OnePassword resolves "op://vault/item/field" secret references with the
+// 1Password CLI (op read). The CLI must be installed and signed in.
+type OnePassword struct {
+	// Account selects the 1Password account when several are signed in.
+	Account string `json:"account,omitempty"`
+}
+
+func (o OnePassword) Lookup(ref string) (string, error) {
+	args := []string{"read", "--no-newline", "op:" + ref}
+	if o.Account != "" {
+		args = append(args, "--account", o.Account)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "op", args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("op read: timed out after %s; is op signed in?", opTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("op read: %s", msg)
+		}
+		return "", fmt.Errorf("op read: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,60 @@
+// Package secrets resolves secret references in process env values, e.g.
+// "keychain:DB_PASSWORD", "op://dev/db/password" or "vault:myapp/db#password",
+// so that only the reference is ever stored or shown to the agent.
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provider looks up a secret by reference. The reference is the part of the
+// env value after "scheme:".
+type Provider interface {
+	Lookup(ref string) (string, error)
+}
+
+// Config holds per-provider settings from the config file.
+type Config struct {
+	OnePassword OnePassword `json:"op"`
+	Vault       Vault       `json:"vault"`
+}
+
+// Resolver maps reference schemes to the Providers that resolve them.
+type Resolver struct {
+	providers map[string]Provider
+}
+
+// NewResolver returns a Resolver with the keychain, op and vault providers
+// configured from cfg.
+func NewResolver(cfg Config) *Resolver {
+	vault := cfg.Vault
+	return &Resolver{providers: map[string]Provider{
+		"keychain": Keychain{Service: "thought-process"},
+		"op":       cfg.OnePassword,
+		"vault":    &vault,
+	}}
+}
+
+// Register adds or replaces the Provider for scheme.
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.providers[scheme] = p
+}
+
+// Resolve returns the secret value references, or value unchanged if it
+// doesn't start with a registered scheme.
+func (r *Resolver) Resolve(value string) (string, error) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return value, nil
+	}
+	p, ok := r.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+	secret, err := p.Lookup(ref)
+	if err != nil {
+		return "", fmt.Errorf("%s secret %q: %w", scheme, ref, err)
+	}
+	return secret, nil
+}
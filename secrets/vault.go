@@ -0,0 +1,101 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Vault resolves "vault:PATH#FIELD" references from a HashiCorp Vault KV v2
+// secrets engine. The token comes from $VAULT_TOKEN or ~/.vault-token.
+type Vault struct {
+	// Address is the Vault server URL (default $VAULT_ADDR).
+	Address string `json:"address,omitempty"`
+	// Mount is the KV v2 mount path (default "secret").
+	Mount string `json:"mount,omitempty"`
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+var vaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func (v *Vault) Lookup(ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || field == "" {
+		return "", errors.New("reference must be PATH#FIELD")
+	}
+
+	addr := v.Address
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return "", errors.New("no vault address configured (set vault.address in the config file or $VAULT_ADDR)")
+	}
+	mount := v.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return "", err
+	}
+
+	url := strings.TrimRight(addr, "/") + "/v1/" + strings.Trim(mount, "/") + "/data/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := vaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s", resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decoding vault response: %w", err)
+	}
+	val, ok := body.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found", field)
+	}
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(val), nil
+}
+
+// vaultToken returns $VAULT_TOKEN, falling back to the token file written by
+// `vault login`.
+func vaultToken() (string, error) {
+	if t := os.Getenv("VAULT_TOKEN"); t != "" {
+		return t, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		return "", errors.New("no vault token (set $VAULT_TOKEN or run vault login)")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
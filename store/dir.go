@@ -2,13 +2,33 @@ package store
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	// orphanAge is how old a temp file must be before Compact treats it as
+	// left behind by a crashed writer rather than a write in progress.
+	orphanAge = time.Minute
+
+	// versionFile records the filename encoding used in the directory.
+	versionFile = ".version"
+	// formatVersion is the current filename encoding: percent-encoding,
+	// upper-case letters included. Version 2 left upper-case letters as
+	// they were, so keys differing only in case shared a file on
+	// case-insensitive filesystems. Version 1 (no version file) replaced
+	// separators with "__" and "_c_".
+	formatVersion = 3
 )
 
 // DirStore implements Store using one file per key in a directory.
-// Keys are mapped to filenames by escaping path separators.
+// Keys are mapped to filenames with a reversible percent-encoding; names
+// starting with "." are reserved for the store's own files.
 // Writes are atomic (temp file + rename). No long-running locks are held.
 type DirStore struct {
 	dir string
@@ -19,25 +39,36 @@ func NewDirStore(dir string) *DirStore {
 	return &DirStore{dir: dir}
 }
 
-func (s *DirStore) Get(key string) (string, error) {
+func (s *DirStore) Get(key string) ([]byte, error) {
 	data, err := os.ReadFile(s.path(key))
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return "", errors.New("key not found")
+			return nil, ErrNotFound
 		}
-		return "", err
+		return nil, err
 	}
-	return string(data), nil
+	return data, nil
 }
 
-func (s *DirStore) Set(key, value string) error {
+func (s *DirStore) Open(key string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *DirStore) Set(key string, value []byte) error {
 	p := s.path(key)
 	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
 	if err != nil {
 		return err
 	}
 	tmpName := tmp.Name()
-	if _, err := tmp.WriteString(value); err != nil {
+	if _, err := tmp.Write(value); err != nil {
 		tmp.Close()
 		os.Remove(tmpName)
 		return err
@@ -64,10 +95,13 @@ func (s *DirStore) List(prefix string, limit int) ([]string, error) {
 	}
 	var keys []string
 	for _, e := range entries {
-		if e.IsDir() || strings.HasPrefix(e.Name(), ".tmp-") {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		key, err := unescape(e.Name())
+		if err != nil {
 			continue
 		}
-		key := unescape(e.Name())
 		if strings.HasPrefix(key, prefix) {
 			keys = append(keys, key)
 			if limit > 0 && len(keys) >= limit {
@@ -78,6 +112,32 @@ func (s *DirStore) List(prefix string, limit int) ([]string, error) {
 	return keys, nil
 }
 
+// Compact removes temp files left behind by writers that crashed between
+// creating the temp file and renaming it into place.
+func (s *DirStore) Compact(dryRun bool) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), ".tmp-") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil || time.Since(fi.ModTime()) < orphanAge {
+			continue
+		}
+		found = append(found, e.Name())
+		if !dryRun {
+			if err := os.Remove(filepath.Join(s.dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return found, err
+			}
+		}
+	}
+	return found, nil
+}
+
 func (s *DirStore) Close() error {
 	return nil
 }
@@ -86,13 +146,89 @@ func (s *DirStore) path(key string) string {
 	return filepath.Join(s.dir, escape(key))
 }
 
-// escape replaces characters unsafe for filenames.
+// Migrate renames files written with an older filename encoding to the
+// current one. It is a no-op once the directory is up to date.
+func (s *DirStore) Migrate() error {
+	versionPath := filepath.Join(s.dir, versionFile)
+	version := 1
+	if data, err := os.ReadFile(versionPath); err == nil {
+		if version, _ = strconv.Atoi(strings.TrimSpace(string(data))); version >= formatVersion {
+			return nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		key := legacyUnescape(e.Name())
+		if version >= 2 {
+			var err error
+			if key, err = unescape(e.Name()); err != nil {
+				continue
+			}
+		}
+		name := escape(key)
+		if name == e.Name() {
+			continue
+		}
+		if err := os.Rename(filepath.Join(s.dir, e.Name()), filepath.Join(s.dir, name)); err != nil {
+			return fmt.Errorf("migrating %s: %w", e.Name(), err)
+		}
+	}
+	return os.WriteFile(versionPath, []byte(strconv.Itoa(formatVersion)+"\n"), 0o644)
+}
+
+// escape percent-encodes every byte of key outside [a-z0-9_.-], plus a
+// leading ".", so that distinct keys always map to distinct filenames, even
+// on case-insensitive filesystems.
 func escape(key string) string {
-	r := strings.NewReplacer("/", "__", "\\", "__", ":", "_c_")
-	return r.Replace(key)
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if isFilenameSafe(c) && !(i == 0 && c == '.') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// unescape reverses escape.
+func unescape(name string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '%' {
+			b.WriteByte(name[i])
+			continue
+		}
+		if i+2 >= len(name) {
+			return "", fmt.Errorf("truncated escape in %q", name)
+		}
+		c, err := strconv.ParseUint(name[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", name)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func isFilenameSafe(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
 }
 
-func unescape(name string) string {
+// legacyUnescape decodes a version 1 filename. Both "/" and "\\" were written
+// as "__", so the result is a best guess.
+func legacyUnescape(name string) string {
 	r := strings.NewReplacer("_c_", ":", "__", "/")
 	return r.Replace(name)
 }
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedPrefix marks values written by Encrypted.
+var encryptedPrefix = []byte("tpenc1:")
+
+// KeySize is the length of an Encrypted key in bytes.
+const KeySize = 32
+
+// ErrDecrypt is returned when an encrypted value can't be decrypted, either
+// because the key is wrong or the data is damaged.
+var ErrDecrypt = errors.New("cannot decrypt value: wrong key or corrupted data")
+
+// ErrPlaintext is returned when a value read through Encrypted isn't
+// encrypted. EncryptPlaintext encrypts those written before encryption was
+// enabled.
+var ErrPlaintext = errors.New("value is not encrypted")
+
+// Encrypted wraps a Store, encrypting values with AES-256-GCM before they are
+// written and decrypting them on read. Values that aren't encrypted are an
+// error, so a record planted or left in plaintext isn't trusted silently.
+type Encrypted struct {
+	Store
+	aead cipher.AEAD
+}
+
+// NewEncrypted wraps s. key must be KeySize random bytes, base64- or
+// hex-encoded, e.g. the output of `openssl rand -base64 32`.
+func NewEncrypted(s Store, key string) (*Encrypted, error) {
+	raw, err := decodeKey(strings.TrimSpace(key))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypted{Store: s, aead: aead}, nil
+}
+
+func decodeKey(key string) ([]byte, error) {
+	if raw, err := base64.StdEncoding.DecodeString(key); err == nil && len(raw) == KeySize {
+		return raw, nil
+	}
+	if raw, err := hex.DecodeString(key); err == nil && len(raw) == KeySize {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("encryption key must be %d random bytes, base64- or hex-encoded (openssl rand -base64 %d)", KeySize, KeySize)
+}
+
+// IsEncrypted reports whether value was written by an Encrypted store.
+func IsEncrypted(value []byte) bool {
+	return bytes.HasPrefix(value, encryptedPrefix)
+}
+
+func (s *Encrypted) Get(key string) ([]byte, error) {
+	value, err := s.Store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.decrypt(key, value)
+}
+
+// Open decrypts the whole value up front; AES-GCM can't authenticate a
+// partial read.
+func (s *Encrypted) Open(key string) (io.ReadCloser, error) {
+	value, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(value)), nil
+}
+
+func (s *Encrypted) Set(key string, value []byte) error {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	out := append([]byte(nil), encryptedPrefix...)
+	out = append(out, nonce...)
+	// Bind the ciphertext to its key so records can't be swapped on disk.
+	out = s.aead.Seal(out, nonce, value, []byte(key))
+	return s.Store.Set(key, out)
+}
+
+// EncryptPlaintext encrypts every value that isn't encrypted yet and returns
+// how many it rewrote. It trusts whatever is in the store, so it is meant to
+// be run once, deliberately, after enabling encryption on existing data.
+func (s *Encrypted) EncryptPlaintext() (int, error) {
+	keys, err := s.Store.List("", 0)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, key := range keys {
+		value, err := s.Store.Get(key)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+		if IsEncrypted(value) {
+			continue
+		}
+		if err := s.Set(key, value); err != nil {
+			return n, fmt.Errorf("%s: %w", key, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Compact forwards to the wrapped store if it implements Compactor.
+func (s *Encrypted) Compact(dryRun bool) ([]string, error) {
+	c, ok := s.Store.(Compactor)
+	if !ok {
+		return nil, nil
+	}
+	return c.Compact(dryRun)
+}
+
+func (s *Encrypted) decrypt(key string, value []byte) ([]byte, error) {
+	data, ok := bytes.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return nil, ErrPlaintext
+	}
+	n := s.aead.NonceSize()
+	if len(data) < n {
+		return nil, ErrDecrypt
+	}
+	plain, err := s.aead.Open(nil, data[:n], data[n:], []byte(key))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
@@ -0,0 +1,162 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the latency histogram.
+var latencyBuckets = []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// OpStats holds the recorded metrics for one store operation.
+type OpStats struct {
+	Count  uint64
+	Errors uint64
+	// Buckets[i] counts calls that took at most latencyBuckets[i] seconds.
+	Buckets    []uint64
+	SumSeconds float64
+}
+
+// Instrumented wraps a Store and records call counts, error counts and
+// latency histograms for each operation. ErrNotFound is not counted as an
+// error.
+type Instrumented struct {
+	Store
+
+	mu  sync.Mutex
+	ops map[string]*OpStats
+}
+
+// NewInstrumented wraps s.
+func NewInstrumented(s Store) *Instrumented {
+	return &Instrumented{Store: s, ops: make(map[string]*OpStats)}
+}
+
+func (s *Instrumented) Get(key string) ([]byte, error) {
+	defer s.observe("get", time.Now())
+	v, err := s.Store.Get(key)
+	s.fail("get", err)
+	return v, err
+}
+
+func (s *Instrumented) Open(key string) (io.ReadCloser, error) {
+	defer s.observe("open", time.Now())
+	r, err := s.Store.Open(key)
+	s.fail("open", err)
+	return r, err
+}
+
+func (s *Instrumented) Set(key string, value []byte) error {
+	defer s.observe("set", time.Now())
+	err := s.Store.Set(key, value)
+	s.fail("set", err)
+	return err
+}
+
+func (s *Instrumented) Delete(key string) error {
+	defer s.observe("delete", time.Now())
+	err := s.Store.Delete(key)
+	s.fail("delete", err)
+	return err
+}
+
+func (s *Instrumented) List(prefix string, limit int) ([]string, error) {
+	defer s.observe("list", time.Now())
+	keys, err := s.Store.List(prefix, limit)
+	s.fail("list", err)
+	return keys, err
+}
+
+// Compact forwards to the wrapped store if it implements Compactor.
+func (s *Instrumented) Compact(dryRun bool) ([]string, error) {
+	c, ok := s.Store.(Compactor)
+	if !ok {
+		return nil, nil
+	}
+	return c.Compact(dryRun)
+}
+
+// Snapshot returns a copy of the metrics recorded so far, keyed by operation.
+func (s *Instrumented) Snapshot() map[string]OpStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]OpStats, len(s.ops))
+	for op, st := range s.ops {
+		cp := *st
+		cp.Buckets = append([]uint64(nil), st.Buckets...)
+		out[op] = cp
+	}
+	return out
+}
+
+// WriteMetrics writes the recorded metrics in the Prometheus text format.
+func (s *Instrumented) WriteMetrics(w io.Writer) error {
+	snap := s.Snapshot()
+	ops := make([]string, 0, len(snap))
+	for op := range snap {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	fmt.Fprintln(w, "# HELP thought_process_store_errors_total Store operations that returned an error.")
+	fmt.Fprintln(w, "# TYPE thought_process_store_errors_total counter")
+	for _, op := range ops {
+		fmt.Fprintf(w, "thought_process_store_errors_total{op=%q} %d\n", op, snap[op].Errors)
+	}
+
+	fmt.Fprintln(w, "# HELP thought_process_store_duration_seconds Store operation latency.")
+	fmt.Fprintln(w, "# TYPE thought_process_store_duration_seconds histogram")
+	for _, op := range ops {
+		st := snap[op]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "thought_process_store_duration_seconds_bucket{op=%q,le=\"%g\"} %d\n", op, le, st.Buckets[i])
+		}
+		fmt.Fprintf(w, "thought_process_store_duration_seconds_bucket{op=%q,le=\"+Inf\"} %d\n", op, st.Count)
+		fmt.Fprintf(w, "thought_process_store_duration_seconds_sum{op=%q} %g\n", op, st.SumSeconds)
+		_, err := fmt.Fprintf(w, "thought_process_store_duration_seconds_count{op=%q} %d\n", op, st.Count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observe records a call to op that started at start.
+func (s *Instrumented) observe(op string, start time.Time) {
+	secs := time.Since(start).Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats(op)
+	st.Count++
+	st.SumSeconds += secs
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			st.Buckets[i]++
+		}
+	}
+}
+
+// fail counts err against op unless it is nil or ErrNotFound.
+func (s *Instrumented) fail(op string, err error) {
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats(op).Errors++
+}
+
+// stats returns the OpStats for op, creating it if needed. s.mu must be held.
+func (s *Instrumented) stats(op string) *OpStats {
+	st, ok := s.ops[op]
+	if !ok {
+		st = &OpStats{Buckets: make([]uint64, len(latencyBuckets))}
+		s.ops[op] = st
+	}
+	return st
+}
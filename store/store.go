@@ -1,16 +1,27 @@
 package store
 
-import "io"
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Get and Open when a key does not exist.
+var ErrNotFound = errors.New("key not found")
 
 // Store defines a persistent key/value store.
 type Store interface {
 	io.Closer
 
-	// Get retrieves the value for a key. Returns an error if the key does not exist.
-	Get(key string) (string, error)
+	// Get retrieves the value for a key. Returns ErrNotFound if the key does not exist.
+	Get(key string) ([]byte, error)
+
+	// Open returns a reader over the value for a key, for values too large to
+	// hold in memory. The caller must close it. Returns ErrNotFound if the key
+	// does not exist.
+	Open(key string) (io.ReadCloser, error)
 
 	// Set stores a key/value pair, creating or overwriting as needed.
-	Set(key, value string) error
+	Set(key string, value []byte) error
 
 	// Delete removes a key. Idempotent — no error if the key does not exist.
 	Delete(key string) error
@@ -19,3 +30,11 @@ type Store interface {
 	// Returns at most limit keys (0 means no limit).
 	List(prefix string, limit int) ([]string, error)
 }
+
+// Compactor is implemented by stores that can clean up leftovers from
+// interrupted writes.
+type Compactor interface {
+	// Compact finds leftovers from interrupted writes and removes them unless
+	// dryRun is set. It returns a description of each leftover found.
+	Compact(dryRun bool) ([]string, error)
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+// AccessLog returns middleware that records every tools/call request, with
+// its outcome and duration, through mgr.RecordToolCall.
+func AccessLog(mgr process.ProcessManager) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if method != "tools/call" || !ok || call.Params == nil {
+				return next(ctx, method, req)
+			}
+
+			start := time.Now()
+			result, err := next(ctx, method, req)
+
+			rec := process.ToolCall{
+				Time:       start,
+				Tool:       call.Params.Name,
+				Outcome:    process.CallOK,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			rec.ArgsHash, rec.Target = hashArgs(call.Params.Arguments)
+			if call.Session != nil {
+				if p := call.Session.InitializeParams(); p != nil && p.ClientInfo != nil {
+					rec.Client = p.ClientInfo.Name
+				}
+			}
+			if err != nil {
+				rec.Outcome, rec.Error = process.CallFailed, err.Error()
+			} else if res, ok := result.(*mcp.CallToolResult); ok && res.IsError {
+				rec.Outcome = process.CallToolError
+				for _, c := range res.Content {
+					if text, ok := c.(*mcp.TextContent); ok {
+						rec.Error = text.Text
+						break
+					}
+				}
+			}
+			if rerr := mgr.RecordToolCall(rec); rerr != nil {
+				log.Printf("recording tool call: %v", rerr)
+			}
+			return result, err
+		}
+	}
+}
+
+// hashArgs returns the SHA-256 of the canonical JSON of raw arguments (map
+// keys sorted, no whitespace), and their process_id or else name.
+func hashArgs(raw json.RawMessage) (hash, target string) {
+	var args any
+	if json.Unmarshal(raw, &args) == nil {
+		if canonical, err := json.Marshal(args); err == nil {
+			raw = canonical
+		}
+	}
+	sum := sha256.Sum256(raw)
+	if m, ok := args.(map[string]any); ok {
+		for _, key := range []string{"process_id", "name"} {
+			if s, ok := m[key].(string); ok && s != "" {
+				target = s
+				break
+			}
+		}
+	}
+	return hex.EncodeToString(sum[:]), target
+}
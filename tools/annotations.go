@@ -0,0 +1,23 @@
+package tools
+
+import "github.com/modelcontextprotocol/go-sdk/mcp"
+
+// Tool annotations let MCP clients auto-approve safe calls and prompt only
+// for the ones that change or stop processes. They are hints, not guarantees.
+
+// readOnly marks a tool that only inspects state.
+func readOnly(title string) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{Title: title, ReadOnlyHint: true}
+}
+
+// reversible marks a tool that changes state in a way that can be undone.
+func reversible(title string) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{Title: title, DestructiveHint: boolPtr(false), IdempotentHint: true}
+}
+
+// destructive marks a tool that starts, stops or drives processes.
+func destructive(title string, idempotent bool) *mcp.ToolAnnotations {
+	return &mcp.ToolAnnotations{Title: title, DestructiveHint: boolPtr(true), IdempotentHint: idempotent}
+}
+
+func boolPtr(b bool) *bool { return &b }
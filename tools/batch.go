@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type StartProcessesArgs struct {
+	Processes []StartProcessArgs `json:"processes" jsonschema:"the processes to start. Each entry accepts the same fields as start_process; its depends_on must refer to the names of other entries"`
+}
+
+// BatchResult is the outcome of one definition passed to start_processes.
+type BatchResult struct {
+	Index   int                  `json:"index"`
+	Name    string               `json:"name,omitempty"`
+	Process *process.ProcessView `json:"process,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+func registerStartProcesses(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "start_processes",
+		Annotations: destructive("Start processes", false),
+		Description: `Start several long-running processes in one call — e.g. the database, API and frontend of a dev environment.
+
+All definitions are validated together before anything starts: names must be unique, depends_on must refer to names in the batch without cycles, and no two definitions may declare the same port. Processes are started in dependency order, each waiting for its dependencies to be running (and healthy, if they have a health check); if a process fails to start, everything that depends on it is skipped. Returns one result per definition, in the order given.
+
+Follow the same tagging guidance as start_process.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StartProcessesArgs) (*mcp.CallToolResult, any, error) {
+		order, problems := planBatch(args.Processes)
+		if len(problems) > 0 {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "invalid batch, nothing was started:\n- " + strings.Join(problems, "\n- ")},
+				},
+			}, nil, nil
+		}
+
+		results := make([]BatchResult, len(args.Processes))
+		failed := make(map[string]bool)
+		for _, i := range order {
+			def := args.Processes[i]
+			results[i] = BatchResult{Index: i, Name: def.Name}
+
+			var skip []string
+			for _, dep := range def.DependsOn {
+				if failed[dep] {
+					skip = append(skip, dep)
+				}
+			}
+			if len(skip) > 0 {
+				results[i].Error = "skipped: dependency failed to start: " + strings.Join(skip, ", ")
+				failed[def.Name] = true
+				continue
+			}
+
+			view, err := mgr.Start(def.startOptions())
+			if err != nil {
+				results[i].Error = err.Error()
+				failed[def.Name] = true
+				continue
+			}
+			results[i].Process = view
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
+
+// planBatch validates defs and returns the indexes of defs in dependency
+// order. Any validation problems are returned instead of an order.
+func planBatch(defs []StartProcessArgs) ([]int, []string) {
+	var problems []string
+	if len(defs) == 0 {
+		return nil, []string{"processes must not be empty"}
+	}
+
+	byName := make(map[string]int)
+	portOwner := make(map[int]int)
+	for i, def := range defs {
+		label := batchLabel(i, def)
+		if def.Command == "" && def.InContainer == nil {
+			problems = append(problems, label+": command is required")
+		}
+		if def.Name != "" {
+			if j, dup := byName[def.Name]; dup {
+				problems = append(problems, fmt.Sprintf("%s: name also used by definition %d", label, j))
+			} else {
+				byName[def.Name] = i
+			}
+		}
+		for _, port := range def.Ports {
+			if j, taken := portOwner[port]; taken {
+				problems = append(problems, fmt.Sprintf("%s: port %d also declared by %s", label, port, batchLabel(j, defs[j])))
+			} else {
+				portOwner[port] = i
+			}
+		}
+	}
+	for i, def := range defs {
+		for _, dep := range def.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: depends on unknown name %q", batchLabel(i, def), dep))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	// Kahn's algorithm, preferring the caller's order among ready definitions.
+	pending := make([]int, len(defs))
+	dependents := make(map[int][]int)
+	for i, def := range defs {
+		pending[i] = len(def.DependsOn)
+		for _, dep := range def.DependsOn {
+			dependents[byName[dep]] = append(dependents[byName[dep]], i)
+		}
+	}
+	order := make([]int, 0, len(defs))
+	started := make([]bool, len(defs))
+	for len(order) < len(defs) {
+		progressed := false
+		for i := range defs {
+			if started[i] || pending[i] > 0 {
+				continue
+			}
+			started[i] = true
+			order = append(order, i)
+			for _, d := range dependents[i] {
+				pending[d]--
+			}
+			progressed = true
+			break
+		}
+		if !progressed {
+			var cycle []string
+			for i, def := range defs {
+				if !started[i] {
+					cycle = append(cycle, batchLabel(i, def))
+				}
+			}
+			return nil, []string{"dependency cycle between " + strings.Join(cycle, ", ")}
+		}
+	}
+	return order, nil
+}
+
+// batchLabel describes a definition for error messages.
+func batchLabel(i int, def StartProcessArgs) string {
+	if def.Name != "" {
+		return fmt.Sprintf("definition %d (%s)", i, def.Name)
+	}
+	return fmt.Sprintf("definition %d", i)
+}
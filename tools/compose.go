@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type StartComposeArgs struct {
+	Cwd      string            `json:"cwd" jsonschema:"the compose project directory. Accepts 'project:NAME/sub/path'"`
+	Files    []string          `json:"files,omitempty" jsonschema:"compose files, relative to cwd, passed with -f. Defaults to compose's own lookup (compose.yaml, docker-compose.yml, ...)"`
+	Services []string          `json:"services,omitempty" jsonschema:"the services to start. Defaults to all"`
+	Tags     map[string]string `json:"tags,omitempty" jsonschema:"tags added to every service's process (e.g. branch, worktree), besides role=<service> and compose=<project>"`
+}
+
+// RegisterContainerTools registers start_compose on the given MCP server.
+func RegisterContainerTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "start_compose",
+		Annotations: destructive("Start compose project", false),
+		Description: `Run 'docker compose up -d' in a project and track each service as a process, so containers show up in list_processes next to native processes. Use this instead of start_process with 'docker compose up' when you want per-service status and logs.
+
+Each service's process follows its logs (get_process_logs works as for any process) and exits when the container stops. Its 'container' field shows the container's state, health and published ports, checked every 5s. Processes are tagged role=<service> and compose=<project>. kill_process stops the service's container; restart_processes brings it up again. Services already tracked are returned as they are, so calling this again is safe. Returns one result per service.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StartComposeArgs) (*mcp.CallToolResult, any, error) {
+		results, err := mgr.StartCompose(process.ComposeOptions{Cwd: args.Cwd, Files: args.Files, Services: args.Services, Tags: args.Tags})
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
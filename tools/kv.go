@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type KVSetArgs struct {
+	Key   string `json:"key" jsonschema:"the key, up to 128 letters, digits, '.', '_', ':', '/' and '-'; use a prefix per project or topic (e.g. 'myapp/ports')"`
+	Value string `json:"value" jsonschema:"the value to store, up to 64 KiB; JSON is fine"`
+}
+
+type KVGetArgs struct {
+	Key string `json:"key" jsonschema:"the key to read"`
+}
+
+type KVListArgs struct {
+	Prefix string `json:"prefix,omitempty" jsonschema:"only list keys starting with this prefix"`
+}
+
+type KVDeleteArgs struct {
+	Key string `json:"key" jsonschema:"the key to remove"`
+}
+
+// RegisterKVTools registers kv_set, kv_get, kv_list and kv_delete on the
+// given MCP server.
+func RegisterKVTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "kv_set",
+		Annotations: destructive("Set scratchpad value", true),
+		Description: `Store a small value in a scratchpad shared by every agent and conversation using this machine's thought-process — e.g. the ports you chose for a stack, or notes about how an environment was set up. Replaces any value already stored under the key.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args KVSetArgs) (*mcp.CallToolResult, any, error) {
+		if args.Key == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "key is required"},
+				},
+			}, nil, nil
+		}
+
+		entry, err := mgr.KVSet(args.Key, args.Value)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "kv_get",
+		Annotations: readOnly("Get scratchpad value"),
+		Description: `Read a value stored with kv_set, with when it was last updated.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args KVGetArgs) (*mcp.CallToolResult, any, error) {
+		if args.Key == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "key is required"},
+				},
+			}, nil, nil
+		}
+
+		entry, err := mgr.KVGet(args.Key)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "kv_list",
+		Annotations: readOnly("List scratchpad values"),
+		Description: `List the scratchpad's keys and values, sorted by key, optionally only those starting with 'prefix'.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args KVListArgs) (*mcp.CallToolResult, any, error) {
+		entries, err := mgr.KVList(args.Prefix)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "kv_delete",
+		Annotations: destructive("Delete scratchpad value", true),
+		Description: `Remove a key from the scratchpad. Removing a key that isn't there is not an error.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args KVDeleteArgs) (*mcp.CallToolResult, any, error) {
+		if args.Key == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "key is required"},
+				},
+			}, nil, nil
+		}
+
+		if err := mgr.KVDelete(args.Key); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("deleted %s", args.Key)},
+			},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type LoadTestProcessArgs struct {
+	ProcessID   string `json:"process_id" jsonschema:"the ID or name of a running process serving HTTP"`
+	Path        string `json:"path,omitempty" jsonschema:"the path to GET (default /), e.g. /api/items?limit=20"`
+	Port        int    `json:"port,omitempty" jsonschema:"which of the process's ports to target (default: the first one it listens on or declared)"`
+	Requests    int    `json:"requests,omitempty" jsonschema:"total number of requests, up to 10000 (default 100)"`
+	Concurrency int    `json:"concurrency,omitempty" jsonschema:"requests in flight at once, up to 100 (default 10)"`
+}
+
+// RegisterLoadTestTools registers load_test_process on the given MCP server.
+func RegisterLoadTestTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "load_test_process",
+		Annotations: destructive("Load test process", false),
+		Description: `Send a bounded burst of HTTP GET requests ('requests' in total, 'concurrency' at a time) to a running process's port and report latency percentiles (p50/p90/p99), throughput, status codes and errors (failed requests and 5xx responses).
+
+Use it as a quick performance sanity check after a code change — run it before and after, or against the same role on two branches — not as a real benchmark: client and server share the machine. Only the process's own ports can be targeted.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args LoadTestProcessArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		result, err := mgr.LoadTest(ctx, args.ProcessID, process.LoadTestOptions{
+			Port:        args.Port,
+			Path:        args.Path,
+			Requests:    args.Requests,
+			Concurrency: args.Concurrency,
+		})
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
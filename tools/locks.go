@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type AcquireLockArgs struct {
+	Name     string `json:"name" jsonschema:"the lock name, shared by everyone who must not run at the same time (e.g. db-migrations)"`
+	Owner    string `json:"owner,omitempty" jsonschema:"who is taking the lock and why (e.g. 'feature-x: running migrations'), shown to others who find it taken"`
+	TTLSecs  int    `json:"ttl_secs,omitempty" jsonschema:"seconds until the lock expires on its own if not released (default 300). Pick longer than the operation should take"`
+	WaitSecs int    `json:"wait_secs,omitempty" jsonschema:"seconds to wait for the lock if someone else holds it (default 0: fail right away)"`
+	Token    string `json:"token,omitempty" jsonschema:"the token from an earlier acquire_lock, to renew a lock you hold for another ttl_secs"`
+}
+
+type ReleaseLockArgs struct {
+	Name  string `json:"name" jsonschema:"the lock name"`
+	Token string `json:"token" jsonschema:"the token acquire_lock returned"`
+}
+
+// RegisterLockTools registers acquire_lock and release_lock on the given MCP
+// server.
+func RegisterLockTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "acquire_lock",
+		Annotations: reversible("Acquire lock"),
+		Description: `Take a named lock shared by every agent using this machine's thought-process, to serialize conflicting operations such as database migrations, dependency installs or schema codegen on a shared repo.
+
+Returns a token; pass it to release_lock when done, or to acquire_lock again to renew the lock. If another agent holds the lock, this fails with who holds it and until when — or waits up to wait_secs for it. Locks expire after ttl_secs, so a crashed agent can't block others forever.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args AcquireLockArgs) (*mcp.CallToolResult, any, error) {
+		if args.Name == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "name is required"},
+				},
+			}, nil, nil
+		}
+
+		lock, err := mgr.AcquireLock(ctx, process.LockOptions{
+			Name:  args.Name,
+			Owner: args.Owner,
+			TTL:   time.Duration(args.TTLSecs) * time.Second,
+			Wait:  time.Duration(args.WaitSecs) * time.Second,
+			Token: args.Token,
+		})
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(lock)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "release_lock",
+		Annotations: reversible("Release lock"),
+		Description: `Release a lock taken with acquire_lock, so others waiting for it can proceed. Requires the token acquire_lock returned.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ReleaseLockArgs) (*mcp.CallToolResult, any, error) {
+		if args.Name == "" || args.Token == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "name and token are required"},
+				},
+			}, nil, nil
+		}
+
+		if err := mgr.ReleaseLock(args.Name, args.Token); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("released lock %s", args.Name)},
+			},
+		}, nil, nil
+	})
+}
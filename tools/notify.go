@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+// notifyEvents sends the events that levelOf gives a level to each
+// connected session as logging notifications from logger, so agents learn
+// of them without polling. Sessions only receive them once their client
+// has set a log level, and then only those at or above it.
+func notifyEvents(server *mcp.Server, mgr process.ProcessManager, logger string, levelOf func(process.Event) mcp.LoggingLevel) {
+	events, _ := mgr.Subscribe()
+	for e := range events {
+		level := levelOf(e)
+		if level == "" {
+			continue
+		}
+		params := &mcp.LoggingMessageParams{
+			Level:  level,
+			Logger: logger,
+			Data:   e,
+		}
+		for ss := range server.Sessions() {
+			ss.Log(context.Background(), params)
+		}
+	}
+}
+
+// exitLevel is the notification level of an event about a process exiting
+// on its own, or about to, or "" for other events. Exits caused by kill_process or a
+// restart aren't news to the agent and aren't sent.
+func exitLevel(e process.Event) mcp.LoggingLevel {
+	switch e.Type {
+	case process.EventCrashed, process.EventCrashLooping, process.EventOOMKilled:
+		return "error"
+	case process.EventTimedOut, process.EventDiedInSleep, process.EventExitWarning:
+		return "warning"
+	case process.EventExited:
+		if !e.Process.Killed {
+			return "notice"
+		}
+	}
+	return ""
+}
+
+// logMatchLevel is the notification level of log_match events.
+func logMatchLevel(e process.Event) mcp.LoggingLevel {
+	if e.Type == process.EventLogMatch {
+		return "warning"
+	}
+	return ""
+}
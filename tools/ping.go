@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Version is the server version reported to clients and by ping.
+const Version = "0.3.0"
+
+// serverStart is when this server process started, for ping's uptime.
+var serverStart = time.Now()
+
+type PingArgs struct{}
+
+// PingResult is returned by the ping tool.
+type PingResult struct {
+	Version    string    `json:"version"`
+	ServerTime time.Time `json:"server_time"`
+	UptimeSecs int64     `json:"uptime_secs"`
+	PID        int       `json:"pid"`
+	// RoundTripMs is the time for the server to ping the client back over
+	// the same session, or -1 if the client didn't answer.
+	RoundTripMs int64  `json:"round_trip_ms"`
+	PingError   string `json:"ping_error,omitempty"`
+}
+
+// RegisterPing registers the ping tool on the given MCP server.
+func RegisterPing(server *mcp.Server) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "ping",
+		Annotations: readOnly("Ping"),
+		Description: `Check that the thought-process server is alive and responsive. Returns its version, current time, uptime and PID, and the round-trip time of a ping from the server back to the client.
+
+Use this to debug connectivity — e.g. after the client reconnects, or to see whether a restarted server is a new process (PID and uptime change).`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args PingArgs) (*mcp.CallToolResult, any, error) {
+		now := time.Now()
+		result := PingResult{
+			Version:     Version,
+			ServerTime:  now.UTC(),
+			UptimeSecs:  int64(now.Sub(serverStart).Seconds()),
+			PID:         os.Getpid(),
+			RoundTripMs: -1,
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		if err := req.Session.Ping(pingCtx, nil); err != nil {
+			result.PingError = err.Error()
+		} else {
+			result.RoundTripMs = time.Since(start).Milliseconds()
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
@@ -3,57 +3,311 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"thought-process/process"
 )
 
 type StartProcessArgs struct {
+	Name    string            `json:"name,omitempty" jsonschema:"a human-readable name (e.g. frontend-dev), unique among running processes, that other tools accept in place of the process ID"`
 	Command string            `json:"command" jsonschema:"the command to run (e.g. npm, python, go, docker-compose). Do NOT use this for short-lived commands like grep, ls, cat, etc. — use your built-in shell tools for those instead"`
 	Args    []string          `json:"args,omitempty" jsonschema:"arguments for the command (e.g. [\"run\", \"dev\", \"--port\", \"3001\"])"`
-	Cwd     string            `json:"cwd,omitempty" jsonschema:"working directory for the command. Set this to the worktree or repo root so the process runs in the correct context"`
-	Env     map[string]string `json:"env,omitempty" jsonschema:"environment variables to set for the process (e.g. {\"NODE_ENV\": \"development\", \"PORT\": \"3001\"}). These are added to the current environment, not replacing it"`
-	Tags    map[string]string `json:"tags,omitempty" jsonschema:"key-value metadata tags for organizing and filtering processes. Always tag with context you have: 'branch' (git branch name), 'worktree' (worktree path), 'role' (e.g. 'frontend', 'backend', 'db'), 'stack' (e.g. 'next', 'rails'). Tags let you find and manage related processes later"`
+	Cwd     string            `json:"cwd,omitempty" jsonschema:"working directory for the command. Set this to the worktree or repo root so the process runs in the correct context. 'project:NAME/sub/dir' resolves inside a project registered with register_project and may not escape it. It must exist unless create_cwd is set"`
+	Env     map[string]string `json:"env,omitempty" jsonschema:"environment variables to set for the process (e.g. {\"NODE_ENV\": \"development\", \"PORT\": \"3001\"}). These are added to the current environment, not replacing it. Secret references ('keychain:NAME', 'op://vault/item/field', 'vault:PATH#FIELD') are resolved at start so the secret never appears here"`
+	Tags    map[string]string `json:"tags,omitempty" jsonschema:"key-value metadata tags for organizing and filtering processes. Always tag with context you have: 'branch' (git branch name) and 'worktree' (worktree root; both detected from cwd if omitted), 'role' (e.g. 'frontend', 'backend', 'db'), 'stack' (e.g. 'next', 'rails'). Tags let you find and manage related processes later"`
 	Ports   []int             `json:"ports,omitempty" jsonschema:"ports this process listens on. Always specify known ports so you can detect conflicts and avoid port collisions across branches/worktrees"`
+	Restart string            `json:"restart,omitempty" jsonschema:"restart policy: 'no' (default), 'on-failure' (restart after a non-zero exit) or 'always'. A process that exits 5 times within a minute is marked crash_looping and no longer restarted"`
+	Exec    bool              `json:"exec,omitempty" jsonschema:"run command directly as the program with args as its arguments, without a shell (e.g. command './bin/server', args ['--greeting', 'it is $5 & up']). Use when arguments contain quotes or other characters a shell would mangle; pipes, globs, && and $VARS then don't work, but ${PORT}-style placeholders still do"`
+	PTY     bool              `json:"pty,omitempty" jsonschema:"run the process in a pseudo-terminal so tools that check for a TTY (vite, jest, rails) print progress output and colors and interactive prompts work. Logs then contain the raw terminal output including ANSI escape codes"`
+
+	CreateCwd     bool `json:"create_cwd,omitempty" jsonschema:"create cwd (and missing parents) if it doesn't exist, e.g. for a scratch or output directory"`
+	Force         bool `json:"force,omitempty" jsonschema:"start a new copy even if a process with the same command, args, cwd and tags is already running"`
+	AllocatePorts int  `json:"allocate_ports,omitempty" jsonschema:"number of free ports (up to 16) to pick for the process, passed to it as PORT, PORT_2, PORT_3... and added to ports. Use this instead of hard-coding ports so each branch/worktree gets its own; reference them in args as ${PORT}"`
+
+	MaxRuntimeSecs  int `json:"max_runtime_secs,omitempty" jsonschema:"stop the process (stop_signal, then SIGKILL after stop_grace_secs) once it has run this many seconds; it then shows status timed_out. Use for test runs, benchmarks and anything that might hang"`
+	IdleTimeoutSecs int `json:"idle_timeout_secs,omitempty" jsonschema:"stop the process once it has written no output for this many seconds (e.g. 600); it then shows status timed_out with exit_reason idle_output. Catches hung builds and stuck watchers. Time spent paused doesn't count"`
+
+	StopSignal    string `json:"stop_signal,omitempty" jsonschema:"signal that asks the process to stop: SIGTERM (default), SIGINT, SIGQUIT, SIGHUP, SIGUSR1 or SIGUSR2. Use SIGINT for servers that only shut down cleanly on Ctrl-C (e.g. webpack-dev-server) and SIGQUIT for ones like nginx whose graceful shutdown is on SIGQUIT"`
+	StopGraceSecs int    `json:"stop_grace_secs,omitempty" jsonschema:"seconds to wait after stop_signal before SIGKILL (default 5, at most 600). Raise it for servers that drain connections or flush data on shutdown"`
+
+	PreStop            string         `json:"pre_stop,omitempty" jsonschema:"shell command run before the stop signal whenever the process is stopped (kill, restart, time limits, server shutdown), e.g. 'npm run db:disconnect'. It runs in cwd with the process's env and ${PORT}-style placeholders, its output goes to the process's log, and stopping waits for it; a failing hook doesn't prevent the stop"`
+	PreStopTimeoutSecs int            `json:"pre_stop_timeout_secs,omitempty" jsonschema:"how long pre_stop may run before it is killed and the stop goes ahead (default 30, at most 600)"`
+	OnExit             *ExitHookArgs  `json:"on_exit,omitempty" jsonschema:"a command run after every exit of the process (e.g. a cleanup script or a notification), so you don't have to poll for it"`
+	Watch              *FileWatchArgs `json:"watch,omitempty" jsonschema:"restart the process, keeping its ID, when files under cwd change, like nodemon for any command"`
+
+	Nice    int    `json:"nice,omitempty" jsonschema:"CPU niceness from -20 to 19 (higher is lower priority; negative values usually need root). Run background builds and test watchers at e.g. 10 so they don't starve the interactive dev server"`
+	IOClass string `json:"io_class,omitempty" jsonschema:"I/O scheduling class on Linux: 'idle' (only gets disk time nobody else wants) or 'best-effort' (the default class)"`
+
+	EnvFiles   []string `json:"env_files,omitempty" jsonschema:"dotenv files (KEY=VALUE lines, relative to cwd, e.g. [\".env\", \".env.local\"]) whose variables are added to the environment; later files override earlier ones and env overrides them all. They are read again on every restart"`
+	InheritEnv *bool    `json:"inherit_env,omitempty" jsonschema:"pass the server's whole environment to the process (default true). Set false for a clean environment with only env, allocated ports and PATH, HOME, USER, SHELL, TMPDIR and LANG, so variables leaked from the user's shell can't change how the process behaves"`
+
+	DependsOn          []string `json:"depends_on,omitempty" jsonschema:"names or IDs of processes (e.g. [\"db\"]) that must be running, and healthy if they have a health_check, before this one is launched. The call waits for them"`
+	DependsTimeoutSecs int      `json:"depends_timeout_secs,omitempty" jsonschema:"how long to wait for depends_on before giving up without starting anything (default 60)"`
+
+	Database    *DatabaseArgs    `json:"database,omitempty" jsonschema:"for a process tagged role=db: its connection string and the commands run_migrations and reset_database run against it"`
+	HealthCheck *HealthCheckArgs `json:"health_check,omitempty" jsonschema:"a probe run periodically while the process is running; its result is reported as health (starting/healthy/unhealthy) in list_processes"`
+	InContainer *ContainerArgs   `json:"in_container,omitempty" jsonschema:"run command and args inside a container of this image instead of on the host. command may then be empty to run the image's own command"`
+}
+
+type ContainerArgs struct {
+	Image   string   `json:"image" jsonschema:"the image to run (e.g. postgres:16, node:22-alpine)"`
+	Runtime string   `json:"runtime,omitempty" jsonschema:"'docker' or 'podman' (default: docker if installed, else podman)"`
+	Volumes []string `json:"volumes,omitempty" jsonschema:"mounts as SOURCE:TARGET[:ro] (e.g. [\"./:/app\", \"pgdata:/var/lib/postgresql/data\"]); a source starting with '.' is relative to cwd, one without '/' is a named volume"`
+	Ports   []string `json:"ports,omitempty" jsonschema:"published ports as HOST:CONTAINER (e.g. [\"5433:5432\"]), or PORT for the same on both. Host ports are added to ports and checked for conflicts"`
+	Workdir string   `json:"workdir,omitempty" jsonschema:"working directory inside the container (default: the image's)"`
+}
+
+// containerSpec converts the tool arguments into a process.ContainerSpec.
+func (a *ContainerArgs) containerSpec() *process.ContainerSpec {
+	if a == nil {
+		return nil
+	}
+	return &process.ContainerSpec{
+		Image:   a.Image,
+		Runtime: a.Runtime,
+		Volumes: a.Volumes,
+		Ports:   a.Ports,
+		Workdir: a.Workdir,
+	}
+}
+
+type HealthCheckArgs struct {
+	HTTP         string `json:"http,omitempty" jsonschema:"URL that must answer GET with a 2xx/3xx status (e.g. http://localhost:$PORT/health)"`
+	TCP          string `json:"tcp,omitempty" jsonschema:"host:port that must accept TCP connections (e.g. localhost:5432)"`
+	Command      string `json:"command,omitempty" jsonschema:"shell command run in the process cwd that must exit 0 (e.g. pg_isready)"`
+	IntervalSecs int    `json:"interval_secs,omitempty" jsonschema:"seconds between checks (default 10)"`
+}
+
+type ExitHookArgs struct {
+	Command       string `json:"command" jsonschema:"shell command run in cwd with the process's env plus THOUGHT_PROCESS_ID, THOUGHT_PROCESS_NAME, THOUGHT_PROCESS_EXIT_CODE (empty if unknown), THOUGHT_PROCESS_EXIT (exited, crashed, crash_looping or timed_out) and THOUGHT_PROCESS_LOG (the log file path). Its output is appended to the process's log"`
+	OnFailureOnly bool   `json:"on_failure_only,omitempty" jsonschema:"only run after failures: non-zero exits and timeouts the process wasn't killed for"`
+	TimeoutSecs   int    `json:"timeout_secs,omitempty" jsonschema:"how long the hook may run before it is killed (default 30, at most 600). A restart policy's relaunch waits for the hook"`
+}
+
+type FileWatchArgs struct {
+	Patterns   []string `json:"patterns" jsonschema:"globs relative to cwd, e.g. **/*.go or src; ** matches any number of directories and a matching directory covers everything under it. .git and node_modules are never watched"`
+	Ignore     []string `json:"ignore,omitempty" jsonschema:"globs, in the same form, for files not to watch, e.g. a build output directory"`
+	DebounceMs int      `json:"debounce_ms,omitempty" jsonschema:"how long files must stay unchanged after a change before restarting (default 500, at most 60000)"`
+}
+
+// startOptions converts the tool arguments into process.StartOptions.
+func (a StartProcessArgs) startOptions() process.StartOptions {
+	return process.StartOptions{
+		Name:    a.Name,
+		Command: a.Command,
+		Args:    a.Args,
+		Cwd:     a.Cwd,
+		Env:     a.Env,
+		Tags:    a.Tags,
+		Ports:   a.Ports,
+		Restart: process.RestartPolicy(a.Restart),
+		PTY:     a.PTY,
+
+		CreateCwd:     a.CreateCwd,
+		Force:         a.Force,
+		AllocatePorts: a.AllocatePorts,
+		HealthCheck:   a.HealthCheck.healthCheck(),
+
+		MaxRuntimeSecs:  a.MaxRuntimeSecs,
+		IdleTimeoutSecs: a.IdleTimeoutSecs,
+		StopSignal:      a.StopSignal,
+		StopGraceSecs:   a.StopGraceSecs,
+
+		PreStop:            a.PreStop,
+		PreStopTimeoutSecs: a.PreStopTimeoutSecs,
+		OnExit:             a.OnExit.exitHook(),
+		Watch:              a.Watch.fileWatch(),
+
+		Nice:     a.Nice,
+		IOClass:  a.IOClass,
+		CleanEnv: a.InheritEnv != nil && !*a.InheritEnv,
+		EnvFiles: a.EnvFiles,
+		Exec:     a.Exec,
+		Database: a.Database.database(),
+
+		DependsOn:          a.DependsOn,
+		DependsTimeoutSecs: a.DependsTimeoutSecs,
+		InContainer:        a.InContainer.containerSpec(),
+	}
+}
+
+// exitHook converts the tool arguments into a process.ExitHook.
+func (a *ExitHookArgs) exitHook() *process.ExitHook {
+	if a == nil {
+		return nil
+	}
+	return &process.ExitHook{
+		Command:       a.Command,
+		OnFailureOnly: a.OnFailureOnly,
+		TimeoutSecs:   a.TimeoutSecs,
+	}
+}
+
+// fileWatch converts the tool arguments into a process.FileWatch.
+func (a *FileWatchArgs) fileWatch() *process.FileWatch {
+	if a == nil {
+		return nil
+	}
+	return &process.FileWatch{
+		Patterns:   a.Patterns,
+		Ignore:     a.Ignore,
+		DebounceMs: a.DebounceMs,
+	}
+}
+
+// healthCheck converts the tool arguments into a process.HealthCheck.
+func (a *HealthCheckArgs) healthCheck() *process.HealthCheck {
+	if a == nil {
+		return nil
+	}
+	return &process.HealthCheck{
+		HTTP:         a.HTTP,
+		TCP:          a.TCP,
+		Command:      a.Command,
+		IntervalSecs: a.IntervalSecs,
+	}
 }
 
 type ListProcessesArgs struct {
 	ExitedSinceSecs *int              `json:"exited_since_duration,omitempty" jsonschema:"only include exited processes that exited within this many seconds ago (default 10). Increase this to see processes that crashed or exited further in the past"`
 	Tags            map[string]string `json:"tags,omitempty" jsonschema:"filter to processes matching all specified tags (e.g. {\"branch\": \"main\", \"service\": \"api\"}). Only processes with all matching tag key-value pairs are returned"`
+	IncludeTree     bool              `json:"include_tree,omitempty" jsonschema:"include the child processes (PID, parent PID, command) of each running process, e.g. the node and esbuild processes spawned by 'npm run dev'"`
+	IncludePending  *bool             `json:"include_pending,omitempty" jsonschema:"include the next run of each schedule, with status scheduled (a start) or restart_scheduled and its run_at time (default true)"`
+	CollapseLineage bool              `json:"collapse_lineage,omitempty" jsonschema:"list each restarted service once: only the latest process of each chain of restarts, with a lineage entry giving the total restart count and the earlier processes' IDs and exit codes"`
+	GroupBy         string            `json:"group_by,omitempty" jsonschema:"group the processes by a tag's value, as tag:KEY (e.g. tag:branch): returns one entry per value with count, counts by status and its processes, instead of a flat list"`
 }
 
 type GetProcessLogsArgs struct {
-	ProcessID string `json:"process_id" jsonschema:"the ID of the process to get logs for (from start_process or list_processes)"`
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to get logs for (from start_process or list_processes)"`
+}
+
+type GetProcessErrorsArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to get errors for (from start_process or list_processes)"`
 }
 
 type KillProcessArgs struct {
-	ProcessID string `json:"process_id" jsonschema:"the ID of the process to kill (from start_process or list_processes)"`
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to kill (from start_process or list_processes)"`
+	Signal    string `json:"signal,omitempty" jsonschema:"signal to send instead of the process's stop_signal (e.g. SIGINT, SIGQUIT)"`
+	GraceSecs int    `json:"grace_secs,omitempty" jsonschema:"seconds to wait before SIGKILL instead of the process's stop_grace_secs"`
+}
+
+type DeleteProcessArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to delete (from list_processes)"`
+	Force     bool   `json:"force,omitempty" jsonschema:"kill the process first if it is still running, instead of refusing"`
+}
+
+type KillProcessesArgs struct {
+	Tags map[string]string `json:"tags" jsonschema:"kill every running process that has all of these tags (e.g. {\"branch\": \"feature-x\"}). At least one tag is required"`
+}
+
+type CleanupWorktreesArgs struct {
+	DryRun bool `json:"dry_run,omitempty" jsonschema:"only report the stale processes, without killing them"`
+}
+
+type StaleReportArgs struct {
+	Cleanup bool `json:"cleanup,omitempty" jsonschema:"kill the stale running processes and delete the stale exited ones"`
+}
+
+type RestartProcessesArgs struct {
+	Tags map[string]string `json:"tags" jsonschema:"restart every running process that has all of these tags (e.g. {\"branch\": \"feature-x\"}). At least one tag is required"`
+}
+
+type UpdateProcessEnvArgs struct {
+	ProcessID string            `json:"process_id" jsonschema:"the ID or name of the running process whose env to change"`
+	Set       map[string]string `json:"set,omitempty" jsonschema:"environment variables to add or replace (e.g. {\"LOG_LEVEL\": \"debug\"}); secret references such as keychain:NAME work as in start_process"`
+	Unset     []string          `json:"unset,omitempty" jsonschema:"names of environment variables to remove"`
+	Restart   bool              `json:"restart,omitempty" jsonschema:"restart the process now to apply the change. Otherwise the change is kept as pending_env and applied the next time the process is restarted (restart_processes, the dashboard or its restart policy)"`
+}
+
+type SetPriorityArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the running process to reprioritize"`
+	Nice      *int   `json:"nice,omitempty" jsonschema:"new CPU niceness from -20 to 19 for the whole process group (higher is lower priority). Lowering it usually needs root"`
+	IOClass   string `json:"io_class,omitempty" jsonschema:"new I/O scheduling class on Linux: 'idle' or 'best-effort'"`
+}
+
+type PauseProcessArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to pause (from start_process or list_processes)"`
+}
+
+type ResumeProcessArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the paused process to resume"`
+}
+
+type SendInputArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to write to"`
+	Input     string `json:"input" jsonschema:"the text to send; a trailing newline is added if missing"`
+}
+
+type InteractProcessArgs struct {
+	ProcessID   string `json:"process_id" jsonschema:"the ID or name of the process to interact with"`
+	Input       string `json:"input" jsonschema:"the line to send (e.g. 'User.count'); a trailing newline is added if missing"`
+	Until       string `json:"until,omitempty" jsonschema:"regex that ends the wait as soon as the output matches it, typically the REPL's prompt (e.g. 'irb\\(main\\).*> $' or '^> $'), so slow statements aren't cut off"`
+	TimeoutSecs int    `json:"timeout_secs,omitempty" jsonschema:"longest time to wait for output, up to 60 (default 5)"`
+	SettleMs    int    `json:"settle_ms,omitempty" jsonschema:"without until: stop once output has paused for this many milliseconds (default 500)"`
 }
 
 type GetFreePortArgs struct{}
 
-// RegisterProcessTools registers start_process, list_processes, and
-// get_process_logs on the given MCP server.
+type GetSummaryArgs struct{}
+
+type GetPortMapArgs struct{}
+
+type FindProcessByPortArgs struct {
+	Port int `json:"port" jsonschema:"the TCP port to look up (e.g. 3000)"`
+}
+
+// RegisterProcessTools registers start_process, start_processes,
+// start_procfile, list_processes, get_process_logs, get_process_errors,
+// kill_process, delete_process, kill_processes, cleanup_worktrees,
+// stale_branch_report, restart_processes, update_process_env, set_priority,
+// pause_process, resume_process, send_input, interact_process,
+// get_free_port, find_process_by_port, get_port_map and get_summary on the
+// given MCP server, and tells connected clients of processes exiting on
+// their own with logging notifications.
 func RegisterProcessTools(server *mcp.Server, mgr process.ProcessManager) {
+	go notifyEvents(server, mgr, "process", exitLevel)
+
 	mcp.AddTool(server, &mcp.Tool{
-		Name: "start_process",
+		Name:        "start_process",
+		Annotations: destructive("Start process", false),
 		Description: `Start and track a long-running process (dev servers, watchers, builds, databases, etc.). Returns a process ID for checking logs and stopping it later.
 
 USE THIS FOR: processes that run continuously or for a long time — dev servers (npm run dev, python manage.py runserver), file watchers, docker-compose, database servers, queue workers, build processes, test suites.
 DO NOT USE FOR: short-lived commands like grep, ls, cat, git status, curl — use your built-in shell/bash tools for those.
 
 IMPORTANT — always tag your processes for isolation:
-- Set 'branch' tag to the current git branch name
-- Set 'worktree' tag to the worktree path when working in git worktrees
+- 'branch' and 'worktree' are filled in from the git checkout of 'cwd' when you don't set them (listed in auto_tags); set them yourself outside a repository
 - Set 'role' tag to describe the process role (e.g. 'frontend', 'backend', 'api', 'db', 'worker')
 - Specify 'ports' so you can detect conflicts across branches/worktrees
 - Use 'cwd' to pin the process to the correct directory
 
-Before starting a process, call list_processes first to check if an equivalent process is already running — avoid spawning duplicates. When working across multiple branches or worktrees, use different ports per branch to prevent conflicts.`,
+Give long-lived services a 'name' (e.g. 'frontend-dev'): names are unique among running processes, so starting a name that is already running fails and returns the existing process instead of a duplicate. Every tool that takes a process_id also accepts a name.
+
+Set 'restart' to 'on-failure' or 'always' for services that should come back after crashing. If the process keeps exiting, it is marked crash_looping in list_processes with its recent exit codes — check get_process_logs rather than restarting it by hand.
+
+Set 'health_check' (http, tcp or command; $PORT expands to the first declared port) so list_processes can tell you whether a running server is actually healthy, not just alive.
+
+Declared ports are checked against other running processes and the OS: if one is taken, nothing is started and the error names the process holding it — a tracked process's ID, name and tags, or an untracked program's pid and command (tell the user what to stop; don't kill programs you didn't start). Kill it or pick another port (get_free_port).
+
+Set 'allocate_ports' to let the server pick free ports instead: they are passed as PORT, PORT_2, ... (use "$PORT" in args) and returned in ports/allocated_ports, so parallel branches never collide.
+
+Instead of assembling strings yourself, use placeholders in command, args and env values: ${PORT} (${PORT_2}, ...) is the allocated or else declared port, ${BRANCH} the git branch and ${WORKTREE} the git worktree root of cwd, and ${ID} the process ID. They are filled in by the server on every start, so restarts get the new ports and ID; an unresolvable one fails the start.
+
+Set 'depends_on' to the names of processes this one needs (e.g. the database for an API server): the start waits until they are running, or healthy if they have a health check, and fails if one has stopped or the wait times out.
+
+Set 'in_container' to run the command in a Docker or Podman container, e.g. a database image: the container is named thought-process-<id>, its output is the process's log, killing the process stops the container, and its state is reported as container. Pass env as usual; only the variables you set (env, env_files, allocated ports) reach the container.
+
+Set 'max_runtime_secs' for anything that might hang (test suites, benchmarks, one-off scripts): the process is stopped when the time is up and shows as timed_out with exit_reason "max_runtime". 'idle_timeout_secs' does the same for a process that stops writing output (exit_reason "idle_output"), e.g. a hung build.
+
+If a process with the same command, args, cwd and tags is already running, it is returned with "duplicate": true and nothing new is started; pass 'force' only if you really want a second copy. Before starting a process, call list_processes first to check if an equivalent process is already running. When working across multiple branches or worktrees, use different ports per branch to prevent conflicts.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args StartProcessArgs) (*mcp.CallToolResult, any, error) {
-		if args.Command == "" {
+		if args.Command == "" && args.InContainer == nil {
 			return &mcp.CallToolResult{
 				IsError: true,
 				Content: []mcp.Content{
@@ -62,7 +316,45 @@ Before starting a process, call list_processes first to check if an equivalent p
 			}, nil, nil
 		}
 
-		view, err := mgr.Start(args.Command, args.Args, args.Cwd, args.Env, args.Tags, args.Ports)
+		view, err := mgr.Start(args.startOptions())
+		if errors.Is(err, process.ErrNameTaken) {
+			text := err.Error()
+			if data, mErr := json.Marshal(view); mErr == nil {
+				text += "\n" + string(data)
+			}
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: text},
+				},
+			}, nil, nil
+		}
+		var conflict *process.PortConflictError
+		if errors.As(err, &conflict) {
+			text := err.Error()
+			if data, mErr := json.Marshal(conflict); mErr == nil {
+				text += "\n" + string(data)
+			}
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: text},
+				},
+			}, nil, nil
+		}
+		var cwdErr *process.CwdError
+		if errors.As(err, &cwdErr) {
+			text := err.Error()
+			if data, mErr := json.Marshal(cwdErr); mErr == nil {
+				text += "\n" + string(data)
+			}
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: text},
+				},
+			}, nil, nil
+		}
 		if err != nil {
 			return nil, nil, fmt.Errorf("starting process: %w", err)
 		}
@@ -79,8 +371,12 @@ Before starting a process, call list_processes first to check if an equivalent p
 		}, nil, nil
 	})
 
+	registerStartProcesses(server, mgr)
+	registerStartProcfile(server, mgr)
+
 	mcp.AddTool(server, &mcp.Tool{
-		Name: "list_processes",
+		Name:        "list_processes",
+		Annotations: readOnly("List processes"),
 		Description: `List all tracked long-running processes with their current status, tags, and ports.
 
 Call this BEFORE starting a new process to avoid duplicates and port conflicts. Use it to:
@@ -89,6 +385,10 @@ Call this BEFORE starting a new process to avoid duplicates and port conflicts.
 - Detect port conflicts before starting a new service
 - Find the process ID you need for get_process_logs or kill_process
 - Check if a previously started process has crashed (look for exited processes)
+- See how much memory (rss_bytes) and CPU (cpu_percent) each running process group uses
+- See what is about to run: schedule runs are listed as 'scheduled' or 'restart_scheduled' with run_at (cancel one with cancel_pending)
+- Get a per-branch overview with group_by: "tag:branch"
+- See each service once, however often it was restarted, with collapse_lineage
 
 Running processes persist across conversations — always check what's already running.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListProcessesArgs) (*mcp.CallToolResult, any, error) {
@@ -96,12 +396,28 @@ Running processes persist across conversations — always check what's already r
 		if args.ExitedSinceSecs != nil {
 			secs = *args.ExitedSinceSecs
 		}
-		views, err := mgr.List(process.ListFilter{ExitedSinceSecs: secs, Tags: args.Tags})
+		pending := args.IncludePending == nil || *args.IncludePending
+		var groupTag string
+		if args.GroupBy != "" {
+			tag, err := process.ParseGroupBy(args.GroupBy)
+			if err != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
+					IsError: true,
+				}, nil, nil
+			}
+			groupTag = tag
+		}
+		views, err := mgr.List(process.ListFilter{ExitedSinceSecs: secs, Tags: args.Tags, IncludeTree: args.IncludeTree, IncludePending: pending, CollapseLineage: args.CollapseLineage})
 		if err != nil {
 			return nil, nil, fmt.Errorf("listing processes: %w", err)
 		}
 
-		data, err := json.Marshal(views)
+		var result any = views
+		if groupTag != "" {
+			result = process.GroupByTag(views, groupTag)
+		}
+		data, err := json.Marshal(result)
 		if err != nil {
 			return nil, nil, fmt.Errorf("marshaling response: %w", err)
 		}
@@ -114,7 +430,8 @@ Running processes persist across conversations — always check what's already r
 	})
 
 	mcp.AddTool(server, &mcp.Tool{
-		Name: "get_process_logs",
+		Name:        "get_process_logs",
+		Annotations: readOnly("Get process logs"),
 		Description: `Get the last ~100KB of combined stdout/stderr logs for a tracked process.
 
 Use this to debug issues with long-running processes: check for startup errors, runtime exceptions, request failures, build errors, or test output. This is your primary debugging tool for any process started with start_process — always check logs when something isn't working as expected (e.g. a dev server won't respond, a build seems stuck, tests are failing).`,
@@ -146,10 +463,51 @@ Use this to debug issues with long-running processes: check for startup errors,
 	})
 
 	mcp.AddTool(server, &mcp.Tool{
-		Name: "kill_process",
-		Description: `Kill a tracked process (SIGTERM, then SIGKILL after 5s if still alive).
+		Name:        "get_process_errors",
+		Annotations: readOnly("Get process errors"),
+		Description: `Get the distinct errors a tracked process has printed since it started, most recently seen first, instead of reading through repeated stack traces in get_process_logs.
 
-Use this to stop processes you no longer need — e.g. when switching branches, tearing down a dev environment, freeing a port for reuse, or cleaning up before starting a fresh instance. Always kill old processes for a branch/worktree before starting replacements to avoid port conflicts and resource waste.`,
+Error lines and the stack trace after them are grouped into fingerprints: occurrences that differ only in numbers, addresses or IDs count as the same error. Each has a message, a sample of the trace, a count and first/last seen times. A count that keeps rising is a recurring known error; one with count 1 and a recent first_seen is new.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args GetProcessErrorsArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		fps, err := mgr.ErrorFingerprints(args.ProcessID)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(fps)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "kill_process",
+		Annotations: destructive("Kill process", true),
+		Description: `Kill a tracked process (its stop_signal, SIGTERM by default, then SIGKILL after its stop_grace_secs, 5s by default, if still alive). Pass signal or grace_secs to override them for this kill. A pre_stop hook set at start runs, and is waited for, before the signal.
+
+Use this to stop processes you no longer need — e.g. when switching branches, tearing down a dev environment, freeing a port for reuse, or cleaning up before starting a fresh instance. Always kill old processes for a branch/worktree before starting replacements to avoid port conflicts and resource waste.
+
+If running processes declared depends_on this one, they are listed in 'dependents' with a warning; they keep running but will likely fail, so kill or restart them too.`,
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args KillProcessArgs) (*mcp.CallToolResult, any, error) {
 		if args.ProcessID == "" {
 			return &mcp.CallToolResult{
@@ -160,7 +518,46 @@ Use this to stop processes you no longer need — e.g. when switching branches,
 			}, nil, nil
 		}
 
-		view, err := mgr.Kill(args.ProcessID)
+		view, err := mgr.KillWith(args.ProcessID, process.KillOptions{Signal: args.Signal, GraceSecs: args.GraceSecs})
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		content := []mcp.Content{&mcp.TextContent{Text: string(data)}}
+		if len(view.Dependents) > 0 {
+			warning := fmt.Sprintf("warning: these running processes depend on %s and may now fail: %s", args.ProcessID, strings.Join(view.Dependents, ", "))
+			content = append([]mcp.Content{&mcp.TextContent{Text: warning}}, content...)
+		}
+		return &mcp.CallToolResult{Content: content}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "delete_process",
+		Annotations: destructive("Delete process", true),
+		Description: `Remove an exited process from the list for good: its record, error fingerprints and log file are deleted. Returns the process as it was last recorded.
+
+A process that is still running (or paused, or about to be restarted by its restart policy) is refused; kill it first, or pass force to kill and delete it in one call. Exited processes are also deleted automatically after the retention period, so this is only needed to clear something out sooner.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args DeleteProcessArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.Delete(args.ProcessID, args.Force)
 		if err != nil {
 			return &mcp.CallToolResult{
 				IsError: true,
@@ -183,7 +580,380 @@ Use this to stop processes you no longer need — e.g. when switching branches,
 	})
 
 	mcp.AddTool(server, &mcp.Tool{
-		Name: "get_free_port",
+		Name:        "kill_processes",
+		Annotations: destructive("Kill processes by tag", true),
+		Description: `Kill every running or paused process matching a tag selector, in parallel, and return their final state.
+
+Use this to tear down everything for a branch or worktree in one call (e.g. tags {"branch": "feature-x"}) instead of listing and killing processes one by one.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args KillProcessesArgs) (*mcp.CallToolResult, any, error) {
+		if len(args.Tags) == 0 {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "at least one tag is required"},
+				},
+			}, nil, nil
+		}
+
+		views, err := mgr.KillMatching(args.Tags)
+		data, mErr := json.Marshal(views)
+		if mErr != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", mErr)
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error() + "\n" + string(data)},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "cleanup_worktrees",
+		Annotations: destructive("Clean up stale worktrees", true),
+		Description: `Kill every running or paused process left over from a deleted worktree or branch, and report them: ones whose 'worktree' tag names a directory that no longer exists (reason "worktree_missing"), and ones whose 'branch' tag names a branch their repository no longer has (reason "branch_deleted").
+
+Run this after removing worktrees or merging and deleting branches, so their dev servers don't hold ports and memory for days. Pass 'dry_run' to see what would be killed first.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args CleanupWorktreesArgs) (*mcp.CallToolResult, any, error) {
+		stale, err := mgr.CleanupWorktrees(args.DryRun)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+		data, err := json.Marshal(stale)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "stale_branch_report",
+		Annotations: destructive("Report stale worktrees and branches", true),
+		Description: `Cross-reference every tracked process, running or exited, with the git worktrees and branches that still exist, and list the ones left over from a deleted worktree or branch.
+
+Returns "repositories" (each repository processes run in: its git_dir, worktrees with their branch and process count, and local branches, from git in the processes' worktree or cwd) and "stale" (each process whose 'worktree' tag names a missing directory, reason "worktree_missing", or whose 'branch' tag names a branch the repository no longer has, reason "branch_deleted"). Pass 'cleanup' to kill the stale running processes and delete the records and logs of the stale exited ones in the same call; each entry then has killed, deleted or an error. cleanup_worktrees only looks at running processes.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StaleReportArgs) (*mcp.CallToolResult, any, error) {
+		report, err := mgr.StaleReport(args.Cleanup)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "restart_processes",
+		Annotations: destructive("Restart processes by tag", false),
+		Description: `Restart every running or paused process matching a tag selector, in parallel: each is killed and started again with the same command, env, tags and options.
+
+Use this to bounce a whole stack (e.g. tags {"branch": "feature-x"}) after a dependency install, config or schema change. Restarted processes get new IDs; each result has the previous_id, the new process, or an error.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args RestartProcessesArgs) (*mcp.CallToolResult, any, error) {
+		if len(args.Tags) == 0 {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "at least one tag is required"},
+				},
+			}, nil, nil
+		}
+
+		results, err := mgr.RestartMatching(args.Tags)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "update_process_env",
+		Annotations: destructive("Update process env", false),
+		Description: `Change the environment variables of a running process, instead of killing it and starting it again with a hand-copied env.
+
+A process can't change its env while it runs, so the change is applied by restarting it: pass restart=true to do that now (the result is the new process, with a new ID). Without it the change is recorded as pending_env, shown in list_processes, and applied the next time the process restarts — with restart_processes, from the dashboard, or by its restart policy. Repeated updates before a restart are combined.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args UpdateProcessEnvArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.UpdateEnv(args.ProcessID, args.Set, args.Unset, args.Restart)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "set_priority",
+		Annotations: reversible("Set process priority"),
+		Description: `Renice a running process and all its children, and/or change their I/O scheduling class (Linux), without restarting it.
+
+Use this when a background build, test watcher or indexer is making the interactive dev server sluggish: set nice to 10-19 (lower priority) and io_class to 'idle'. The new priority is shown in list_processes as nice/io_class and kept if the process is restarted. Raising priority again (lowering nice) usually needs root.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args SetPriorityArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.SetPriority(args.ProcessID, args.Nice, args.IOClass)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "pause_process",
+		Annotations: reversible("Pause process"),
+		Description: `Temporarily freeze a tracked process and all its children (SIGSTOP). It keeps its memory and ports but uses no CPU, and shows as 'paused' in list_processes.
+
+Use this to quiet a CPU-hungry build or test watcher while working in another worktree, instead of killing it and starting it again later. Resume it with resume_process. Note that a paused server does not answer requests.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args PauseProcessArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.Pause(args.ProcessID)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "resume_process",
+		Annotations: reversible("Resume process"),
+		Description: `Resume a process frozen with pause_process (SIGCONT). It continues exactly where it left off.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ResumeProcessArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.Resume(args.ProcessID)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "send_input",
+		Annotations: destructive("Send input", false),
+		Description: `Write a line of text to a tracked process's stdin, e.g. to answer an interactive prompt or run a statement in a REPL or database console.
+
+Check get_process_logs afterwards to see the response. Nothing is sent to exited processes. Input is at most 64 KiB, and fails after 5s if the process isn't reading it. Without pty, stdin is a pipe that stays open while the process runs, so a program that reads stdin to EOF (cat, wc, a script piping input) waits forever; pass such input through a file or args instead.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args SendInputArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.SendInput(args.ProcessID, args.Input)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "interact_process",
+		Annotations: destructive("Interact with process", false),
+		Description: `Send a line to a running REPL or console (rails console, node, python, psql) started with start_process, and get back the output it writes in response — one step of a scripted session, without polling get_process_logs.
+
+Start the REPL with pty: true so it prints prompts and flushes output as it would in a terminal. Collection stops when the output matches 'until' (pass the prompt), when output pauses for settle_ms, when the process exits, or after timeout_secs; 'stop' says which. The output includes the echoed input in PTY mode and has ANSI escape codes removed.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args InteractProcessArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		opts := process.InteractOptions{
+			Timeout: time.Duration(args.TimeoutSecs) * time.Second,
+			Settle:  time.Duration(args.SettleMs) * time.Millisecond,
+		}
+		if args.Until != "" {
+			re, err := regexp.Compile("(?m)" + args.Until)
+			if err != nil {
+				return &mcp.CallToolResult{
+					IsError: true,
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("invalid until pattern: %v", err)},
+					},
+				}, nil, nil
+			}
+			opts.Until = re
+		}
+
+		result, err := mgr.Interact(ctx, args.ProcessID, args.Input, opts)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_free_port",
+		Annotations: readOnly("Get free port"),
 		Description: `Get an available TCP port on the local machine.
 
 Use this when you need to start a process on a dynamic port and don't have a specific port in mind. The returned port was free at the time of the call, but there is a small race window before your process binds to it — another process could claim it first. If your process fails to bind, retry this tool once or twice before giving up.
@@ -203,4 +973,81 @@ Typical usage: call get_free_port, then pass the returned port to start_process
 			},
 		}, nil, nil
 	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_process_by_port",
+		Annotations: readOnly("Find process by port"),
+		Description: `Find out who is listening on a TCP port — "who owns 3000?" in one call.
+
+Returns listening=false if the port is free. Otherwise returns the tracked process (with its tags, so you can tell which branch or worktree it belongs to) when the listener is part of one, and the PID and command line of the listening OS process. A result with a PID but no process is something started outside thought-process.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args FindProcessByPortArgs) (*mcp.CallToolResult, any, error) {
+		owner, err := mgr.FindByPort(args.Port)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(owner)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_port_map",
+		Annotations: readOnly("Get port map"),
+		Description: `Get every port in use or spoken for, in one call — use it when picking ports for a new service.
+
+Returns "ports", mapping each port to the processes claiming it: process_id, name, role and branch tags, status, url (for running processes) and source. Running and paused processes claim their allocated, declared, self-reported and detected ports; the next run of a schedule and stack definitions that aren't running claim the ports they declare ("schedule" and "stack" sources), since starting them will need those ports. More than one claim on a port means a conflict. "allocation_range" is where allocate_ports picks from.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args GetPortMapArgs) (*mcp.CallToolResult, any, error) {
+		portMap, err := mgr.PortMap()
+		if err != nil {
+			return nil, nil, fmt.Errorf("mapping ports: %w", err)
+		}
+
+		data, err := json.Marshal(portMap)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_summary",
+		Annotations: readOnly("Get summary"),
+		Description: `Get a compact count of processes: running, paused, failing (failed or crash looping in the last hour, not counting killed ones) and unhealthy (running but failing their health check).
+
+Use this for a quick "is anything broken?" check; call list_processes for the details.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args GetSummaryArgs) (*mcp.CallToolResult, any, error) {
+		summary, err := mgr.Summary()
+		if err != nil {
+			return nil, nil, fmt.Errorf("summarizing processes: %w", err)
+		}
+
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
 }
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type StartProcfileArgs struct {
+	Cwd  string            `json:"cwd" jsonschema:"the directory containing the Procfile; processes run here. Accepts 'project:NAME/sub/path'"`
+	File string            `json:"file,omitempty" jsonschema:"path to the Procfile, relative to cwd. Defaults to 'Procfile'"`
+	Tags map[string]string `json:"tags,omitempty" jsonschema:"tags added to every process (e.g. branch, worktree), besides role=<Procfile key>"`
+}
+
+func registerStartProcfile(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "start_procfile",
+		Annotations: destructive("Start Procfile", false),
+		Description: `Start every entry of a Procfile (the "name: command" file used by foreman, overmind and Heroku) as a tracked process.
+
+Each entry runs through the shell in cwd and is tagged role=<its key>. As with foreman, a .env file in cwd is loaded into every process, and an entry whose command uses $PORT gets a free port allocated and exported as PORT. Entries already running with the same command, cwd and tags are returned as they are, so calling this again is safe. Returns one result per entry, in file order.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StartProcfileArgs) (*mcp.CallToolResult, any, error) {
+		results, err := mgr.StartProcfile(process.ProcfileOptions{Cwd: args.Cwd, File: args.File, Tags: args.Tags})
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(results)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
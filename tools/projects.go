@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type RegisterProjectArgs struct {
+	Name string `json:"name" jsonschema:"short project name used in cwd references (e.g. webapp)"`
+	Path string `json:"path" jsonschema:"absolute path to the project's root directory"`
+}
+
+type ListProjectsArgs struct{}
+
+type ListProjectTasksArgs struct {
+	Project string `json:"project" jsonschema:"a registered project name, or the absolute path of a project root or worktree"`
+}
+
+type RunTaskByNameArgs struct {
+	Project string            `json:"project" jsonschema:"a registered project name, or the absolute path of a project root or worktree (use the worktree you are working in)"`
+	Task    string            `json:"task" jsonschema:"the task name from list_project_tasks (e.g. seed, generate-types)"`
+	Tags    map[string]string `json:"tags,omitempty" jsonschema:"extra tags for the task's process, e.g. {\"branch\": \"feature-x\"}; project and task are always set"`
+	Env     map[string]string `json:"env,omitempty" jsonschema:"environment variables added to the task's own"`
+}
+
+// RegisterProjectTools registers register_project, list_projects,
+// list_project_tasks and run_task_by_name on the given MCP server.
+func RegisterProjectTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "register_project",
+		Annotations: reversible("Register project"),
+		Description: `Register a project root directory under a short name. Afterwards start_process accepts cwd values like 'project:webapp/packages/api' instead of long absolute paths, and rejects ones that would escape the project (via '..' or symlinks).
+
+Registering an existing name replaces its path. Projects can also be preset in ~/.thought-process/config.json.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args RegisterProjectArgs) (*mcp.CallToolResult, any, error) {
+		if args.Name == "" || args.Path == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "name and path are required"},
+				},
+			}, nil, nil
+		}
+
+		project, err := mgr.RegisterProject(args.Name, args.Path)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(project)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_projects",
+		Annotations: readOnly("List projects"),
+		Description: `List the registered project roots that start_process cwd values can refer to as 'project:NAME/...'.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListProjectsArgs) (*mcp.CallToolResult, any, error) {
+		projects, err := mgr.Projects()
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing projects: %w", err)
+		}
+
+		data, err := json.Marshal(projects)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_project_tasks",
+		Annotations: readOnly("List project tasks"),
+		Description: `List the named tasks ("seed", "reset", "generate-types", ...) a project declares in the "tasks" of its ` + process.ManifestFile + ` manifest, with their commands and descriptions. Run one with run_task_by_name instead of reconstructing its command line.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListProjectTasksArgs) (*mcp.CallToolResult, any, error) {
+		if args.Project == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "project is required"},
+				},
+			}, nil, nil
+		}
+
+		tasks, err := mgr.ProjectTasks(args.Project)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(tasks)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "run_task_by_name",
+		Annotations: destructive("Run project task", false),
+		Description: `Run a task declared in a project's ` + process.ManifestFile + ` manifest (see list_project_tasks) as a tracked process, in the cwd the task declares relative to the project root. The process is tagged project and task; pass your branch in tags. It returns right away; follow it with list_processes or get_process_logs.
+
+Pass the worktree path as project to run the task in that worktree with its version of the manifest.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args RunTaskByNameArgs) (*mcp.CallToolResult, any, error) {
+		if args.Project == "" || args.Task == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "project and task are required"},
+				},
+			}, nil, nil
+		}
+
+		view, err := mgr.RunTask(args.Project, args.Task, args.Tags, args.Env)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
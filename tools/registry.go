@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+// Group is a set of related tools that are enabled or disabled together.
+type Group struct {
+	Name string
+	// Optional groups are registered only when explicitly enabled, keeping
+	// the default tool list small.
+	Optional bool
+	Register func(server *mcp.Server, mgr process.ProcessManager)
+}
+
+// Groups lists every tool group in registration order.
+var Groups = []Group{
+	{Name: "process", Register: RegisterProcessTools},
+	{Name: "wait", Register: RegisterWaitTools},
+	{Name: "projects", Register: RegisterProjectTools},
+	{Name: "watches", Register: RegisterWatchTools},
+	{Name: "stacks", Register: RegisterStackTools},
+	{Name: "schedules", Optional: true, Register: RegisterScheduleTools},
+	{Name: "locks", Optional: true, Register: RegisterLockTools},
+	{Name: "kv", Optional: true, Register: RegisterKVTools},
+	{Name: "thoughts", Optional: true, Register: RegisterThoughtTools},
+	{Name: "loadtest", Optional: true, Register: RegisterLoadTestTools},
+	{Name: "containers", Optional: true, Register: RegisterContainerTools},
+	{Name: "database", Optional: true, Register: RegisterDatabaseTools},
+	{Name: "audit", Optional: true, Register: RegisterToolCallTools},
+	{Name: "diagnostics", Optional: true, Register: func(server *mcp.Server, _ process.ProcessManager) {
+		RegisterPing(server)
+	}},
+}
+
+// Register registers the default tool groups plus the optional groups named
+// in enable, minus the groups named in disable. "all" in enable turns on
+// every optional group. Unknown group names are an error.
+func Register(server *mcp.Server, mgr process.ProcessManager, enable, disable []string) error {
+	for _, name := range slices.Concat(enable, disable) {
+		if name != "all" && !slices.ContainsFunc(Groups, func(g Group) bool { return g.Name == name }) {
+			return fmt.Errorf("unknown tool group %q", name)
+		}
+	}
+
+	for _, g := range Groups {
+		on := !g.Optional || slices.Contains(enable, g.Name) || slices.Contains(enable, "all")
+		if on && !slices.Contains(disable, g.Name) {
+			g.Register(server, mgr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type ScheduleProcessArgs struct {
+	StartProcessArgs
+	DelaySecs int    `json:"delay_secs,omitempty" jsonschema:"start the process once, this many seconds from now"`
+	Cron      string `json:"cron,omitempty" jsonschema:"start the process every time this cron expression fires, in the server's local time: 'minute hour day-of-month month day-of-week' (e.g. '*/15 * * * *'), @hourly, @daily, @weekly, @monthly or '@every 90s'"`
+}
+
+type ScheduleRestartArgs struct {
+	ProcessID string `json:"process_id" jsonschema:"the ID or name of the process to restart on a schedule"`
+	Cron      string `json:"cron" jsonschema:"restart the process every time this cron expression fires, in the server's local time (e.g. '0 4 * * *' for 4am every night), @daily or '@every 12h'"`
+}
+
+type ListSchedulesArgs struct{}
+
+type CancelPendingArgs struct {
+	ID string `json:"id" jsonschema:"the ID of a scheduled or restart_scheduled entry from list_processes (the schedule's ID)"`
+}
+
+type CancelScheduleArgs struct {
+	ScheduleID string `json:"schedule_id" jsonschema:"the schedule ID returned by schedule_process or list_schedules"`
+}
+
+// RegisterScheduleTools registers schedule_process, schedule_restart,
+// list_schedules, cancel_pending and cancel_schedule on the given MCP server.
+func RegisterScheduleTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "schedule_process",
+		Annotations: destructive("Schedule process", false),
+		Description: `Start a process later: once after 'delay_secs', or repeatedly on a 'cron' expression (e.g. re-run a data sync every 15 minutes with cron '*/15 * * * *'). Accepts the same fields as start_process.
+
+Each run is a separate process tagged with the schedule's ID as schedule_id, so list_processes, get_process_logs and kill_process work on it as usual. A run is skipped (and last_error says so) while the previous one — or any identical process — is still running; pass max_runtime_secs to stop runs that hang. Runs missed while no server was up are not caught up on.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ScheduleProcessArgs) (*mcp.CallToolResult, any, error) {
+		if args.Command == "" || (args.DelaySecs == 0 && args.Cron == "") {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "command and one of delay_secs or cron are required"},
+				},
+			}, nil, nil
+		}
+
+		schedule, err := mgr.AddSchedule(args.startOptions(), time.Duration(args.DelaySecs)*time.Second, args.Cron)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(schedule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "schedule_restart",
+		Annotations: destructive("Schedule restart", false),
+		Description: `Restart a running process every time a 'cron' expression fires — e.g. a nightly bounce with cron '0 4 * * *' for a service that leaks memory over long runs.
+
+Each restart kills the process and starts it again with the same options, like restart_processes; the new process has a new ID and previous_id pointing at the one it replaced. The schedule follows the process across restarts (by name if it has one), and records each restart in runs, last_run and last_process_id. A restart is skipped, with last_error saying so, while the process isn't running. Cancel it with cancel_schedule.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ScheduleRestartArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" || args.Cron == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id and cron are required"},
+				},
+			}, nil, nil
+		}
+
+		schedule, err := mgr.AddRestartSchedule(args.ProcessID, args.Cron)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(schedule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_schedules",
+		Annotations: readOnly("List schedules"),
+		Description: `List schedules with their next_run, last_run, number of runs, last_process_id and last_error. One-shot schedules that have run have no next_run.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListSchedulesArgs) (*mcp.CallToolResult, any, error) {
+		schedules, err := mgr.Schedules()
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing schedules: %w", err)
+		}
+
+		data, err := json.Marshal(schedules)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "cancel_pending",
+		Annotations: destructive("Cancel pending run", false),
+		Description: `Cancel a run that hasn't happened yet: an entry with status scheduled or restart_scheduled in list_processes.
+
+A one-shot schedule (delay_secs) is removed entirely. A recurring one skips just this run; the result shows its new next_run. To stop all future runs use cancel_schedule.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args CancelPendingArgs) (*mcp.CallToolResult, any, error) {
+		if args.ID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "id is required"},
+				},
+			}, nil, nil
+		}
+
+		schedule, err := mgr.CancelPending(args.ID)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+		if schedule == nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: fmt.Sprintf("canceled pending run %s", args.ID)},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(schedule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "cancel_schedule",
+		Annotations: destructive("Cancel schedule", false),
+		Description: `Cancel a schedule so it starts no more runs. A run that is already going keeps running; stop it with kill_process.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args CancelScheduleArgs) (*mcp.CallToolResult, any, error) {
+		if args.ScheduleID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "schedule_id is required"},
+				},
+			}, nil, nil
+		}
+
+		if err := mgr.CancelSchedule(args.ScheduleID); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("canceled schedule %s", args.ScheduleID)},
+			},
+		}, nil, nil
+	})
+}
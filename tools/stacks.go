@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type DefineStackArgs struct {
+	Name      string             `json:"name" jsonschema:"the stack's name (e.g. 'shop-dev'); defining an existing stack replaces its definitions"`
+	Processes []StartProcessArgs `json:"processes" jsonschema:"the processes in the stack. Each entry accepts the same fields as start_process and needs a unique name; depends_on naming other entries decides the start order"`
+}
+
+type StackArgs struct {
+	Name string `json:"name" jsonschema:"the name of a stack defined with define_stack"`
+}
+
+type ListStacksArgs struct{}
+
+// RegisterStackTools registers define_stack, list_stacks, start_stack,
+// stop_stack, restart_stack and delete_stack on the given MCP server.
+func RegisterStackTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "define_stack",
+		Annotations: reversible("Define stack"),
+		Description: `Save a named stack: a set of process definitions (e.g. database, API and frontend) that start_stack, stop_stack and restart_stack then manage together, in this and later conversations.
+
+Nothing is started. Names must be unique within the stack, depends_on must not form a cycle, and no two processes may declare the same port. Tag the definitions as you would for start_process.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args DefineStackArgs) (*mcp.CallToolResult, any, error) {
+		if args.Name == "" || len(args.Processes) == 0 {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "name and processes are required"},
+				},
+			}, nil, nil
+		}
+
+		defs := make([]process.StartOptions, len(args.Processes))
+		for i, def := range args.Processes {
+			defs[i] = def.startOptions()
+		}
+		return stackResult(args.Name, func(name string) (any, error) { return mgr.DefineStack(name, defs) })
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_stacks",
+		Annotations: readOnly("List stacks"),
+		Description: `List the defined stacks with their definitions and current members: for each definition, its running process or else the last one started for the stack.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListStacksArgs) (*mcp.CallToolResult, any, error) {
+		stacks, err := mgr.Stacks()
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing stacks: %w", err)
+		}
+
+		data, err := json.Marshal(stacks)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "start_stack",
+		Annotations: destructive("Start stack", true),
+		Description: `Start every process of a stack in dependency order, each waiting for its dependencies to be running (and healthy, if they have a health check). Members that are already running are returned as they are, so this is safe to call again; if a process fails to start, the ones depending on it are skipped. Returns one result per definition.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StackArgs) (*mcp.CallToolResult, any, error) {
+		return stackResult(args.Name, func(name string) (any, error) { return mgr.StartStack(name) })
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "stop_stack",
+		Annotations: destructive("Stop stack", true),
+		Description: `Kill every running or paused process of a stack, dependents before the processes they depend on, and return their final state. The stack stays defined for start_stack.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StackArgs) (*mcp.CallToolResult, any, error) {
+		return stackResult(args.Name, func(name string) (any, error) { return mgr.StopStack(name) })
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "restart_stack",
+		Annotations: destructive("Restart stack", false),
+		Description: `Stop a stack and start it again from its current definitions, e.g. after changing them with define_stack. Returns one result per definition, as start_stack does.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StackArgs) (*mcp.CallToolResult, any, error) {
+		return stackResult(args.Name, func(name string) (any, error) { return mgr.RestartStack(name) })
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "delete_stack",
+		Annotations: destructive("Delete stack", true),
+		Description: `Delete a stack's definition. Its processes keep running; stop them first with stop_stack if they are no longer needed.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args StackArgs) (*mcp.CallToolResult, any, error) {
+		if args.Name == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "name is required"},
+				},
+			}, nil, nil
+		}
+
+		if err := mgr.DeleteStack(args.Name); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("deleted stack %s", args.Name)},
+			},
+		}, nil, nil
+	})
+}
+
+// stackResult runs a stack operation on name and returns its result as JSON,
+// or its error as an IsError result.
+func stackResult(name string, run func(string) (any, error)) (*mcp.CallToolResult, any, error) {
+	if name == "" {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: "name is required"},
+			},
+		}, nil, nil
+	}
+
+	result, err := run(name)
+	if err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: err.Error()},
+			},
+		}, nil, nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling response: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(data)},
+		},
+	}, nil, nil
+}
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+// defaultThoughtLimit caps list_thoughts unless limit is given.
+const defaultThoughtLimit = 50
+
+type AppendThoughtArgs struct {
+	Project string            `json:"project" jsonschema:"the project whose journal to add to, usually a name from list_projects or the repo's directory name"`
+	Text    string            `json:"text" jsonschema:"the entry: a decision and why, a TODO, an environment quirk, anything a later conversation should know"`
+	Tags    map[string]string `json:"tags,omitempty" jsonschema:"tags for finding the entry later, e.g. {\"kind\": \"decision\", \"branch\": \"feature-x\"}"`
+}
+
+type ListThoughtsArgs struct {
+	Project string            `json:"project,omitempty" jsonschema:"only list this project's journal"`
+	Tags    map[string]string `json:"tags,omitempty" jsonschema:"only list entries with all of these tags"`
+	Since   string            `json:"since,omitempty" jsonschema:"only list entries written at or after this time (RFC 3339, or YYYY-MM-DD for the start of that day)"`
+	Until   string            `json:"until,omitempty" jsonschema:"only list entries written before this time (RFC 3339, or YYYY-MM-DD to include that whole day)"`
+	Limit   int               `json:"limit,omitempty" jsonschema:"the maximum number of entries to return, newest first (default 50)"`
+}
+
+// RegisterThoughtTools registers append_thought and list_thoughts on the
+// given MCP server.
+func RegisterThoughtTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "append_thought",
+		Annotations: destructive("Append thought", false),
+		Description: `Add a timestamped entry to a project's journal — decisions and their reasons, TODOs, quirks of the environment (e.g. "the api needs redis running before migrations") — so later conversations and other agents can find it with list_thoughts. The journal is also browsable on the dashboard.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args AppendThoughtArgs) (*mcp.CallToolResult, any, error) {
+		if args.Project == "" || args.Text == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "project and text are required"},
+				},
+			}, nil, nil
+		}
+
+		thought, err := mgr.AppendThought(args.Project, args.Text, args.Tags)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(thought)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_thoughts",
+		Annotations: readOnly("List thoughts"),
+		Description: `List journal entries written with append_thought, newest first. Filter by project, tags and a since/until date range. Check a project's journal when you start working on it.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListThoughtsArgs) (*mcp.CallToolResult, any, error) {
+		filter := process.ThoughtFilter{Project: args.Project, Tags: args.Tags, Limit: args.Limit}
+		if filter.Limit <= 0 {
+			filter.Limit = defaultThoughtLimit
+		}
+		var err error
+		if args.Since != "" {
+			filter.Since, err = process.ParseThoughtTime(args.Since, false)
+		}
+		if err == nil && args.Until != "" {
+			filter.Until, err = process.ParseThoughtTime(args.Until, true)
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		thoughts, err := mgr.Thoughts(filter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := json.Marshal(thoughts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+// defaultToolCallLimit caps list_tool_calls unless limit is given.
+const defaultToolCallLimit = 100
+
+type ListToolCallsArgs struct {
+	Tool    string `json:"tool,omitempty" jsonschema:"only list calls of this tool, e.g. kill_process"`
+	Outcome string `json:"outcome,omitempty" jsonschema:"only list calls with this outcome: 'ok', 'tool_error' (the tool reported an error) or 'error' (the request itself failed)"`
+	Since   string `json:"since,omitempty" jsonschema:"only list calls made at or after this time (RFC 3339, or YYYY-MM-DD for the start of that day)"`
+	Until   string `json:"until,omitempty" jsonschema:"only list calls made before this time (RFC 3339, or YYYY-MM-DD to include that whole day)"`
+	Limit   int    `json:"limit,omitempty" jsonschema:"the maximum number of calls to return, newest first (default 100)"`
+}
+
+// RegisterToolCallTools registers list_tool_calls on the given MCP server.
+func RegisterToolCallTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_tool_calls",
+		Annotations: readOnly("List tool calls"),
+		Description: `List the tool calls made to this machine's thought-process servers by any agent, newest first: tool, target process, outcome, error, duration, client and a hash of the arguments (arguments themselves are not stored). Use it to reconstruct what was started, killed or restarted and when, e.g. "what happened to the api yesterday afternoon".`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListToolCallsArgs) (*mcp.CallToolResult, any, error) {
+		filter := process.ToolCallFilter{Tool: args.Tool, Outcome: args.Outcome, Limit: args.Limit}
+		if filter.Limit <= 0 {
+			filter.Limit = defaultToolCallLimit
+		}
+		var err error
+		if args.Since != "" {
+			filter.Since, err = process.ParseThoughtTime(args.Since, false)
+		}
+		if err == nil && args.Until != "" {
+			filter.Until, err = process.ParseThoughtTime(args.Until, true)
+		}
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		calls, err := mgr.ToolCalls(filter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := json.Marshal(calls)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
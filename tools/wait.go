@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+const defaultWaitTimeoutSecs = 30
+
+type WaitForPortArgs struct {
+	Port        int    `json:"port" jsonschema:"the TCP port to wait for"`
+	Host        string `json:"host,omitempty" jsonschema:"the host to connect to (default 127.0.0.1)"`
+	TimeoutSecs *int   `json:"timeout_secs,omitempty" jsonschema:"maximum number of seconds to wait (default 30)"`
+}
+
+type WaitForURLArgs struct {
+	URL         string `json:"url" jsonschema:"the URL to poll with GET requests (e.g. http://localhost:3000/health)"`
+	TimeoutSecs *int   `json:"timeout_secs,omitempty" jsonschema:"maximum number of seconds to wait (default 30)"`
+}
+
+type WaitUntilReadyArgs struct {
+	ProcessID   string `json:"process_id" jsonschema:"the ID or name of the process to wait for (from start_process or list_processes)"`
+	Port        int    `json:"port,omitempty" jsonschema:"wait until this local TCP port accepts connections"`
+	LogPattern  string `json:"log_pattern,omitempty" jsonschema:"wait until a line of the process output matches this regular expression (e.g. 'ready in|Listening on')"`
+	Reported    bool   `json:"reported,omitempty" jsonschema:"wait until the process reports itself ready over the control socket, for apps that do"`
+	TimeoutSecs *int   `json:"timeout_secs,omitempty" jsonschema:"maximum number of seconds to wait (default 60)"`
+}
+
+// WaitResult is returned by the wait tools when the target becomes ready.
+type WaitResult struct {
+	Ready     bool  `json:"ready"`
+	ElapsedMs int64 `json:"elapsed_ms"`
+}
+
+// RegisterWaitTools registers wait_for_port, wait_for_url and
+// wait_until_ready on the given MCP server.
+func RegisterWaitTools(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "wait_for_port",
+		Annotations: readOnly("Wait for port"),
+		Description: `Block until a TCP port accepts connections, or until the timeout expires.
+
+Use this to wait for dependencies that are not managed by thought-process — e.g. a database in Docker Desktop, a cloud tunnel, or a service started outside this session — instead of sleeping for an arbitrary amount of time.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args WaitForPortArgs) (*mcp.CallToolResult, any, error) {
+		if args.Port <= 0 || args.Port > 65535 {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "port must be between 1 and 65535"},
+				},
+			}, nil, nil
+		}
+		host := args.Host
+		if host == "" {
+			host = "127.0.0.1"
+		}
+		addr := net.JoinHostPort(host, strconv.Itoa(args.Port))
+		return waitResult(ctx, process.TCPProbe(addr), args.TimeoutSecs)
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "wait_for_url",
+		Annotations: readOnly("Wait for URL"),
+		Description: `Block until a URL responds to GET with a 2xx or 3xx status, or until the timeout expires.
+
+Use this to wait for HTTP services that are not managed by thought-process — e.g. a tunnel endpoint or a container's health route — instead of sleeping for an arbitrary amount of time.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args WaitForURLArgs) (*mcp.CallToolResult, any, error) {
+		if args.URL == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "url is required"},
+				},
+			}, nil, nil
+		}
+		return waitResult(ctx, process.HTTPProbe(args.URL), args.TimeoutSecs)
+	})
+
+	registerWaitUntilReady(server, mgr)
+}
+
+func registerWaitUntilReady(server *mcp.Server, mgr process.ProcessManager) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "wait_until_ready",
+		Annotations: readOnly("Wait until ready"),
+		Description: `Block until a tracked process is ready, or until the timeout expires. Use this right after start_process instead of sleeping and polling get_process_logs.
+
+Readiness is, in order of preference:
+- 'port' accepts TCP connections, if given
+- the process reports itself ready (ready_at is set), if 'reported' is given
+- a line of output matches 'log_pattern', if given
+- the process's health check passes, if it was started with one
+- its first declared port accepts TCP connections
+
+Fails immediately if the process exits while waiting — check get_process_logs in that case.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args WaitUntilReadyArgs) (*mcp.CallToolResult, any, error) {
+		if args.ProcessID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "process_id is required"},
+				},
+			}, nil, nil
+		}
+
+		cond := process.ReadyCondition{Port: args.Port, Reported: args.Reported}
+		if args.LogPattern != "" {
+			re, err := regexp.Compile(args.LogPattern)
+			if err != nil {
+				return &mcp.CallToolResult{
+					IsError: true,
+					Content: []mcp.Content{
+						&mcp.TextContent{Text: fmt.Sprintf("invalid log_pattern: %v", err)},
+					},
+				}, nil, nil
+			}
+			cond.LogPattern = re
+		}
+
+		secs := 60
+		if args.TimeoutSecs != nil {
+			secs = *args.TimeoutSecs
+		}
+		ctx, cancel := context.WithTimeout(ctx, time.Duration(secs)*time.Second)
+		defer cancel()
+
+		view, err := mgr.WaitReady(ctx, args.ProcessID, cond)
+		if err != nil {
+			text := err.Error()
+			if view != nil {
+				if data, mErr := json.Marshal(view); mErr == nil {
+					text += "\n" + string(data)
+				}
+			}
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: text},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(view)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+}
+
+// waitResult runs probe until it succeeds or the timeout expires and converts
+// the outcome into a tool result.
+func waitResult(ctx context.Context, probe process.Probe, timeoutSecs *int) (*mcp.CallToolResult, any, error) {
+	secs := defaultWaitTimeoutSecs
+	if timeoutSecs != nil {
+		secs = *timeoutSecs
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(secs)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := process.WaitFor(ctx, probe, 500*time.Millisecond); err != nil {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("not ready after %ds: %v", secs, err)},
+			},
+		}, nil, nil
+	}
+
+	data, err := json.Marshal(WaitResult{Ready: true, ElapsedMs: time.Since(start).Milliseconds()})
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling response: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(data)},
+		},
+	}, nil, nil
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"thought-process/process"
+)
+
+type AddLogWatchArgs struct {
+	Pattern   string            `json:"pattern" jsonschema:"regular expression (Go RE2 syntax) matched against each line of output, e.g. 'FATAL|out of memory' or '(?i)panic'"`
+	ProcessID string            `json:"process_id,omitempty" jsonschema:"the process ID or name to watch"`
+	Tags      map[string]string `json:"tags,omitempty" jsonschema:"watch every current and future process whose tags include all of these instead (e.g. {\"branch\": \"feature-x\"})"`
+}
+
+type ListLogWatchesArgs struct{}
+
+type RemoveLogWatchArgs struct {
+	WatchID string `json:"watch_id" jsonschema:"the watch ID returned by add_log_watch"`
+}
+
+// RegisterWatchTools registers add_log_watch, list_log_watches and
+// remove_log_watch on the given MCP server, and forwards log watch matches
+// to connected clients as logging notifications.
+func RegisterWatchTools(server *mcp.Server, mgr process.ProcessManager) {
+	go notifyEvents(server, mgr, "log_watch", logMatchLevel)
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "add_log_watch",
+		Annotations: reversible("Add log watch"),
+		Description: `Alert when a process's output matches a regex, so you notice "FATAL", "out of memory" or a failing test while working on something else.
+
+Watch one process with 'process_id', or every process (including ones started later) whose tags include all of 'tags'. Only output written after the watch is added is checked, line by line, about once a second.
+
+Each match is sent as an MCP logging notification (level "warning", logger "log_watch") to clients that have set a log level, published as a log_match event on the dashboard, which shows it as a toast, and on the control socket. Remove watches you no longer need with remove_log_watch.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args AddLogWatchArgs) (*mcp.CallToolResult, any, error) {
+		if args.Pattern == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "pattern is required"},
+				},
+			}, nil, nil
+		}
+
+		watch, err := mgr.AddLogWatch(args.Pattern, args.ProcessID, args.Tags)
+		if err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		data, err := json.Marshal(watch)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_log_watches",
+		Annotations: readOnly("List log watches"),
+		Description: `List the log watches registered with add_log_watch.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListLogWatchesArgs) (*mcp.CallToolResult, any, error) {
+		watches, err := mgr.LogWatches()
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing log watches: %w", err)
+		}
+
+		data, err := json.Marshal(watches)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshaling response: %w", err)
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "remove_log_watch",
+		Annotations: destructive("Remove log watch", false),
+		Description: `Remove a log watch registered with add_log_watch.`,
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args RemoveLogWatchArgs) (*mcp.CallToolResult, any, error) {
+		if args.WatchID == "" {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: "watch_id is required"},
+				},
+			}, nil, nil
+		}
+
+		if err := mgr.RemoveLogWatch(args.WatchID); err != nil {
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{
+					&mcp.TextContent{Text: err.Error()},
+				},
+			}, nil, nil
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("removed log watch %s", args.WatchID)},
+			},
+		}, nil, nil
+	})
+}